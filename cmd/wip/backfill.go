@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/query"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(backfillCmd)
+	backfillCmd.Flags().String("since", "", "Only import commits after a date (e.g. '2024-01-01', '2 weeks ago')")
+	backfillCmd.Flags().String("author", "", "Only import commits by an author (name or email pattern, 'me' for your user.email)")
+}
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill [path]",
+	Short: "Import past commits from git log",
+	Long: `Import the commits of a repository (default: the current one) as git commit events,
+at their original dates. Commits that are already recorded are skipped, so it is safe to run again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceStr, _ := cmd.Flags().GetString("since")
+		author, _ := cmd.Flags().GetString("author")
+
+		var since time.Time
+		if sinceStr != "" {
+			var err error
+			if since, err = query.ParseDate(sinceStr, time.Now()); err != nil {
+				return fmt.Errorf("could not parse 'since' date: %w", err)
+			}
+		}
+
+		// The repository context is read from the working directory
+		if len(args) == 1 {
+			if err := os.Chdir(args[0]); err != nil {
+				return fmt.Errorf("failed to open repository: %w", err)
+			}
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		runGit := func(args ...string) ([]byte, error) {
+			return exec.Command("git", args...).Output()
+		}
+
+		u := usecase.NewBackfillUsecase(a.Store, runGit)
+		res, err := u.Backfill(usecase.BackfillOptions{Since: since, Author: author})
+		if err != nil {
+			return fmt.Errorf("failed to backfill: %w", err)
+		}
+
+		fmt.Printf("✅ Imported %d commits (%d already recorded)\n", res.Imported, res.Skipped)
+		return nil
+	},
+}
@@ -15,10 +15,10 @@ func init() {
 }
 
 var captureCmd = &cobra.Command{
-	Use:    "capture [type]",
+	Use:    "capture [type] [hook args...]",
 	Short:  "Capture internal events (e.g. git-commit)",
 	Hidden: true, // Internal use only
-	Args:   cobra.ExactArgs(1),
+	Args:   cobra.MinimumNArgs(1),
 	RunE:   runCaptureWrapper,
 }
 
@@ -30,16 +30,16 @@ func runCaptureWrapper(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize app: %w", err)
 	}
 
-	// Default git show implementation
-	defaultGitShow := func() ([]byte, error) {
-		return exec.Command("git", "show", "--stat", "--oneline", "--no-color", "HEAD").Output()
+	runGit := func(args ...string) ([]byte, error) {
+		return exec.Command("git", args...).Output()
 	}
 
-	u := usecase.NewCaptureUsecase(a.Store)
+	u := usecase.NewCaptureUsecase(a.Store, runGit)
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
-	return u.CaptureEvent(eventType, cwd, defaultGitShow)
+	// The hook's arguments and stdin are passed on as git gave them
+	return u.CaptureEvent(eventType, cwd, usecase.HookInput{Args: args[1:], Stdin: os.Stdin})
 }
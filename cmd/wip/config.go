@@ -96,6 +96,18 @@ var configListCmd = &cobra.Command{
 		}
 		fmt.Println()
 
+		fmt.Println("Store:")
+		backend := cfg.Store.Backend
+		if backend == "" {
+			backend = "files (default)"
+		}
+		fmt.Printf("  Backend: %s\n", backend)
+		fmt.Println()
+
+		fmt.Println("Shell Integration:")
+		fmt.Printf("  Command threshold: %s\n", cfg.Shell.Threshold())
+		fmt.Println()
+
 		fmt.Println("Sync Configuration:")
 		if cfg.Sync.Obsidian != nil && cfg.Sync.Obsidian.Enabled {
 			fmt.Printf("  Obsidian: Enabled\n")
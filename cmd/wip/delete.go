@@ -2,55 +2,80 @@ package main
 
 import (
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation")
 }
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete [id]",
 	Short: "Delete an event",
-	Long:  `Delete an event. If no ID is specified, the latest event of the current month is deleted.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Move an event to the trash. If no ID is specified, the latest event is deleted after confirmation.
+The ID can be shortened to any unambiguous prefix, like a git hash.
+
+Deleted events are hidden everywhere but can be brought back with 'wip restore <id>'
+or 'wip undo'. Use 'wip trash purge' to delete them permanently.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeEventID,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+
 		// Initialize app with centralized dependencies
 		a, err := app.New()
 		if err != nil {
 			return fmt.Errorf("failed to initialize app: %w", err)
 		}
 
-		var eventID string
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		var target *model.WipsEvent
 
 		if len(args) > 0 {
-			eventID = args[0]
+			target, err = a.Store.GetEvent(args[0])
+			if err != nil {
+				return err
+			}
+			if target.Type == model.EventTypeUndo {
+				return fmt.Errorf("undo events cannot be deleted; use 'wip redo' instead")
+			}
 		} else {
-			// Find latest event
-			now := time.Now()
-			start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-			events, err := a.Store.GetEvents(start, now)
+			// Find latest event that is still visible
+			target, err = latestEvent(a.Store, j)
 			if err != nil {
-				return fmt.Errorf("failed to get events: %w", err)
+				return err
 			}
-			if len(events) == 0 {
-				return fmt.Errorf("no events found for this month")
+			resolved, _ := j.ResolveEvent(*target)
+			content := resolved.Content
+
+			// Without an explicit ID, make sure the right event is deleted
+			if !yes {
+				fmt.Printf("Delete latest event: %s? [y/N] ", strings.SplitN(content, "\n", 2)[0])
+				var resp string
+				fmt.Scanln(&resp)
+				if resp != "y" && resp != "Y" {
+					fmt.Println("Aborted.")
+					return nil
+				}
 			}
-			eventID = events[len(events)-1].ID
-			fmt.Printf("Deleting latest event: %s\n", events[len(events)-1].Content)
 		}
 
-		// Confirm? (Maybe later. Simple delete is fine for now.)
-
-		err = a.Store.DeleteEvent(eventID)
-		if err != nil {
-			return fmt.Errorf("failed to delete event %s: %w", eventID, err)
+		if err := usecase.NewTrashUsecase(a.Store).Trash(target.ID); err != nil {
+			return fmt.Errorf("failed to delete event %s: %w", target.ID, err)
 		}
 
-		fmt.Printf("Event %s deleted.\n", eventID)
+		fmt.Printf("🗑️  Event %s moved to the trash.\n", target.ID)
 		return nil
 	},
 }
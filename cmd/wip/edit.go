@@ -5,107 +5,80 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strconv"
 	"time"
 
-	"github.com/oklog/ulid/v2"
 	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
 	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(editCmd)
+	editCmd.Flags().Bool("revert", false, "Restore a previous revision: wip edit --revert <id> <rev>")
 }
 
 var editCmd = &cobra.Command{
 	Use:     "edit [id]",
 	Aliases: []string{"e"},
 	Short:   "Edit an event",
-	Long:    `Edit an event using the default editor ($EDITOR). If no ID is specified, the latest event of the current month is edited.`,
-	Args:    cobra.MaximumNArgs(1),
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if len(args) != 0 {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
+	Long: `Edit an event using the default editor ($EDITOR). If no ID is specified, the latest event is edited.
+The ID can be shortened to any unambiguous prefix, like a git hash.
 
-		a, err := app.New()
-		if err != nil {
-			return nil, cobra.ShellCompDirectiveError
-		}
-
-		// Get events for the current month
-		now := time.Now()
-		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		events, err := a.Store.GetEvents(start, now)
-		if err != nil {
-			return nil, cobra.ShellCompDirectiveError
-		}
-
-		var completions []string
-		for i := len(events) - 1; i >= 0; i-- { // Reverse order (newest first)
-			e := events[i]
-			// Limit content preview length
-			preview := e.Content
-			if len(preview) > 50 {
-				preview = preview[:47] + "..."
-			}
-			// Format: "ID\tContent Preview"
-			completions = append(completions, fmt.Sprintf("%s\t%s", e.ID, preview))
-		}
-		return completions, cobra.ShellCompDirectiveNoFileComp
-	},
+Every edit keeps the previous content as a revision. Use 'wip history <id>' to
+see them and 'wip edit --revert <id> <rev>' to restore one.`,
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: completeEventID,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		revert, _ := cmd.Flags().GetBool("revert")
+
 		// Initialize app with centralized dependencies
 		a, err := app.New()
 		if err != nil {
 			return fmt.Errorf("failed to initialize app: %w", err)
 		}
 
+		// Undone events cannot be edited, and undone edits are not shown
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
 		var eventID string
 		var targetEvent model.WipsEvent
 
-		if len(args) > 0 {
-			eventID = args[0]
-			// Find event by ID
-			uid, err := ulid.Parse(eventID)
-			if err != nil {
-				return fmt.Errorf("invalid event ID: %w", err)
+		if revert {
+			if len(args) != 2 {
+				return fmt.Errorf("--revert requires an event ID and a revision number")
 			}
-			ts := ulid.Time(uid.Time())
-
-			// We use a small window around the timestamp to find the event
-			// ULID has ms precision, while stored time.Now() has better precision.
-			// Exact match won't work.
-			events, err := a.Store.GetEvents(ts.Add(-1*time.Minute), ts.Add(1*time.Minute))
+			rev, err := strconv.Atoi(args[1])
 			if err != nil {
-				return fmt.Errorf("failed to get events: %w", err)
+				return fmt.Errorf("invalid revision %q: %w", args[1], err)
 			}
+			return revertEvent(a.Store, j, args[0], rev)
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("accepts at most 1 arg(s), received %d", len(args))
+		}
 
-			found := false
-			for _, e := range events {
-				if e.ID == eventID {
-					targetEvent = e
-					found = true
-					break
-				}
-			}
-			if !found {
-				return fmt.Errorf("event not found: %s", eventID)
+		if len(args) > 0 {
+			found, err := findEditable(a.Store, j, args[0])
+			if err != nil {
+				return err
 			}
-
+			eventID = found.ID
+			targetEvent, _ = j.ResolveEvent(*found)
 		} else {
-			// Get latest event of this month
-			now := time.Now()
-			start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-			events, err := a.Store.GetEvents(start, now)
+			// Get the latest event
+			latest, err := latestEvent(a.Store, j)
 			if err != nil {
-				return fmt.Errorf("failed to get events: %w", err)
-			}
-			if len(events) == 0 {
-				return fmt.Errorf("no events found for this month")
+				return err
 			}
-			targetEvent = events[len(events)-1]
-			eventID = targetEvent.ID
+			eventID = latest.ID
+			targetEvent, _ = j.ResolveEvent(*latest)
 		}
 
 		// Edit content
@@ -119,13 +92,17 @@ var editCmd = &cobra.Command{
 			return nil
 		}
 
-		// Update event
-		err = a.Store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
-			e.Content = newContent
-			return nil
-		})
+		refs, err := usecase.ResolveRefs(a.Store, newContent)
 		if err != nil {
-			return fmt.Errorf("failed to update event %s: %w", eventID, err)
+			return err
+		}
+
+		// Update event, keeping the previous content so the edit can be undone
+		if err := journal.Edit(a.Store, eventID, newContent, time.Now(), refs...); err != nil {
+			return err
+		}
+		if err := usecase.RecordLinks(a.Store, eventID, "", refs); err != nil {
+			return err
 		}
 
 		fmt.Printf("Event %s updated.\n", eventID)
@@ -133,6 +110,59 @@ var editCmd = &cobra.Command{
 	},
 }
 
+// findEditable looks up an event by ID or prefix and checks that it can be edited.
+// The event is returned as stored, without the journal applied.
+func findEditable(s store.Store, j *journal.Journal, id string) (*model.WipsEvent, error) {
+	e, err := s.GetEvent(id)
+	if err != nil {
+		return nil, err
+	}
+	if e.Type == model.EventTypeUndo {
+		return nil, fmt.Errorf("undo events cannot be edited")
+	}
+	if e.Trashed() != nil {
+		return nil, fmt.Errorf("event %s is in the trash (run 'wip restore %s' to restore it)", e.ID, e.ID)
+	}
+	if _, visible := j.ResolveEvent(*e); !visible {
+		return nil, fmt.Errorf("event %s has been undone (run 'wip redo' to restore it)", e.ID)
+	}
+	return e, nil
+}
+
+// revertEvent restores the content of a previous revision as a new edit,
+// so the revert itself shows up in the history and can be undone.
+func revertEvent(s store.Store, j *journal.Journal, id string, rev int) error {
+	e, err := findEditable(s, j, id)
+	if err != nil {
+		return err
+	}
+	eventID := e.ID
+
+	versions := journal.Versions(e)
+	if rev < 1 || rev > len(versions) {
+		return fmt.Errorf("revision %d not found (event %s has revisions 1-%d)", rev, eventID, len(versions))
+	}
+	if rev == j.EffectiveRev(e) {
+		fmt.Printf("Event %s is already at revision %d.\n", eventID, rev)
+		return nil
+	}
+
+	content := versions[rev-1].Content
+	refs, err := usecase.ResolveRefs(s, content)
+	if err != nil {
+		return err
+	}
+	if err := journal.Edit(s, eventID, content, time.Now(), refs...); err != nil {
+		return err
+	}
+	if err := usecase.RecordLinks(s, eventID, "", refs); err != nil {
+		return err
+	}
+
+	fmt.Printf("Event %s reverted to revision %d.\n", eventID, rev)
+	return nil
+}
+
 func openEditor(content string) (string, error) {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(fsckCmd)
+	fsckCmd.Flags().Bool("repair", false, "Quarantine bad lines into .corrupt files and move misfiled events")
+}
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the data store for corruption",
+	Long: `Scan every event file and dictionary and report problems:
+malformed lines, duplicate event IDs, events filed under the wrong month,
+context IDs missing from their dictionary and dictionaries that cannot be parsed.
+
+With --repair, malformed lines and exact duplicates are moved into a .corrupt
+file next to the file they came from (nothing is discarded), misfiled events are
+moved to the right month and broken dictionaries are set aside and reset.
+Dictionaries kept from the event log (undo, changes, tasks and links) are
+filled again on next use; the others get entries again as events are recorded.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repair, _ := cmd.Flags().GetBool("repair")
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		result, err := store.Fsck(a.Store, repair)
+		if err != nil {
+			return fmt.Errorf("failed to check store: %w", err)
+		}
+
+		fmt.Printf("Checked %d files, %d events.\n", result.Files, result.Events)
+		if len(result.Problems) == 0 {
+			fmt.Println("✅ No problems found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range result.Problems {
+			location := p.File
+			if p.Line > 0 {
+				location = fmt.Sprintf("%s:%d", p.File, p.Line)
+			}
+			status := ""
+			if p.Repaired {
+				status = "(repaired)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", location, p.Kind, p.ID, p.Detail, status)
+		}
+		w.Flush()
+
+		if !repair {
+			fmt.Printf("\nFound %d problem(s). Run 'wip fsck --repair' to fix what can be fixed automatically.\n", len(result.Problems))
+			return nil
+		}
+		fmt.Printf("\n✅ Repaired %d of %d problem(s).\n", result.Repaired(), len(result.Problems))
+		if left := len(result.Problems) - result.Repaired(); left > 0 {
+			fmt.Printf("%d problem(s) need manual attention.\n", left)
+		}
+		return nil
+	},
+}
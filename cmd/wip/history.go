@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/diff"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffDeleteColor = color.New(color.FgRed).SprintFunc()
+	diffInsertColor = color.New(color.FgGreen).SprintFunc()
+	faintColor      = color.New(color.Faint).SprintFunc()
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history <id>",
+	Short: "Show the revision history of an event",
+	Long: `Show every revision of an event with its timestamp and a diff against the previous one.
+
+Restore a revision with 'wip edit --revert <id> <rev>'.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEventID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		e, err := a.Store.GetEvent(args[0])
+		if err != nil {
+			return err
+		}
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		versions := journal.Versions(e)
+		effective := j.EffectiveRev(e)
+
+		icon, _ := ui.FormatEventWithStyle(*e)
+		fmt.Printf("%s %s  (%d revisions)\n", icon, e.ID, len(versions))
+
+		for i, v := range versions {
+			var labels []string
+			if i == 0 {
+				labels = append(labels, "original")
+			}
+			if v.Rev == effective {
+				labels = append(labels, "current")
+			} else if v.Rev > effective {
+				labels = append(labels, "undone")
+			}
+
+			header := fmt.Sprintf("rev %d  %s", v.Rev, ui.TimeColor(v.TS.Format("2006-01-02 15:04:05")))
+			if len(labels) > 0 {
+				header += "  " + faintColor("("+strings.Join(labels, ", ")+")")
+			}
+			fmt.Printf("\n%s\n", header)
+
+			if i == 0 {
+				for _, line := range strings.Split(strings.TrimSuffix(v.Content, "\n"), "\n") {
+					fmt.Printf("    %s\n", line)
+				}
+				continue
+			}
+			for _, line := range diff.Lines(versions[i-1].Content, v.Content) {
+				switch line.Op {
+				case diff.Delete:
+					fmt.Printf("  %s\n", diffDeleteColor("- "+line.Text))
+				case diff.Insert:
+					fmt.Printf("  %s\n", diffInsertColor("+ "+line.Text))
+				default:
+					fmt.Printf("    %s\n", line.Text)
+				}
+			}
+		}
+		return nil
+	},
+}
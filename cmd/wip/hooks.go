@@ -4,12 +4,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"text/tabwriter"
 
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/config"
+	"github.com/rynskrmt/wips-cli/internal/git"
+	"github.com/rynskrmt/wips-cli/internal/hooks"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.AddCommand(hooksStatusCmd)
+	hooksInstallCmd.Flags().Bool("global", false, "Install hooks for every repository through the global core.hooksPath")
+	hooksUninstallCmd.Flags().Bool("global", false, "Remove the global hooks and restore the previous core.hooksPath")
+	hooksStatusCmd.Flags().Bool("all", false, "Check every repository wip has recorded events in")
+	hooksStatusCmd.Flags().Bool("global", false, "Check the global hooks")
 	rootCmd.AddCommand(hooksCmd)
 }
 
@@ -21,44 +33,239 @@ var hooksCmd = &cobra.Command{
 var hooksInstallCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install wip git hooks",
+	Long: `Install the git hooks that record commits in wip.
+
+Existing hooks are kept. wip inserts a marked block into shell scripts, and
+moves other hooks to <hook>.local and runs them before recording the event.
+In repositories using husky, the block goes into .husky/<hook>; with lefthook,
+a command is added to lefthook-local.yml, which needs a pre-push hook
+configured in lefthook.yml to be piped.
+
+With --global, wip writes hooks to ~/.wip/hooks and sets the global
+core.hooksPath to it, so commits are recorded in every repository. Those hooks
+run each repository's own .git/hooks scripts first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if global, _ := cmd.Flags().GetBool("global"); global {
+			dir, err := globalHooksDir()
+			if err != nil {
+				return err
+			}
+			previous, err := hooks.InstallGlobal(dir)
+			if err != nil {
+				return fmt.Errorf("failed to install global hooks: %w", err)
+			}
+			fmt.Printf("✅ Installed global git hooks: %s\n", dir)
+			if previous != "" {
+				fmt.Printf("Note: core.hooksPath was %s; it is no longer used, and will be restored by 'wip hooks uninstall --global'.\n", previous)
+			}
+			return nil
+		}
+
+		repo, err := currentRepo()
+		if err != nil {
+			return err
+		}
+
+		written, err := repo.Install()
+		if err != nil {
+			return fmt.Errorf("failed to install hooks: %w", err)
+		}
+
+		for _, path := range written {
+			fmt.Printf("Installed git hook: %s\n", path)
+		}
+		switch repo.Manager {
+		case hooks.ManagerHusky:
+			fmt.Println("Note: .husky/ is shared through the repository; commit the change to enable it for everyone.")
+		case hooks.ManagerLefthook:
+			fmt.Println("Run 'lefthook install' to apply the change.")
+		}
+		return nil
+	},
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove wip git hooks",
+	Long:  `Remove the wip-managed block from the git hooks of the current repository. The rest of each hook script is kept, and hooks moved to <hook>.local are put back.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// 1. Check if in git repo
-		gitDir := ".git"
-		if _, err := os.Stat(gitDir); os.IsNotExist(err) {
-			return fmt.Errorf("not a git repository (no .git directory found)")
-		}
-
-		// Prepare hook path
-		hookPath := filepath.Join(gitDir, "hooks", "post-commit")
-
-		// Create hook content
-		script := `#!/bin/sh
-# wip hook: fail silently if wip is not found or fails
-if command -v wip >/dev/null 2>&1; then
-  wip capture git-commit || true
-fi
-exit 0
-`
-
-		// Check if file exists
-		// For MVP, checking if file exists and warning is safer.
-
-		if _, err := os.Stat(hookPath); err == nil {
-			// File exists
-			fmt.Printf("Warning: %s already exists. Overwrite? [y/N] ", hookPath)
-			var resp string
-			fmt.Scanln(&resp)
-			if resp != "y" && resp != "Y" {
-				fmt.Println("Aborted.")
+		if global, _ := cmd.Flags().GetBool("global"); global {
+			dir, err := globalHooksDir()
+			if err != nil {
+				return err
+			}
+			previous, removed, err := hooks.UninstallGlobal(dir)
+			if err != nil {
+				return fmt.Errorf("failed to uninstall global hooks: %w", err)
+			}
+			if !removed {
+				fmt.Println("No global wip hooks installed.")
 				return nil
 			}
+			if previous != "" {
+				fmt.Printf("✅ Removed global git hooks; core.hooksPath restored to %s\n", previous)
+			} else {
+				fmt.Println("✅ Removed global git hooks.")
+			}
+			return nil
 		}
 
-		if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
-			return fmt.Errorf("failed to write hook: %w", err)
+		repo, err := currentRepo()
+		if err != nil {
+			return err
 		}
 
-		fmt.Printf("Installed git hook: %s\n", hookPath)
+		changed, err := repo.Uninstall()
+		if err != nil {
+			return fmt.Errorf("failed to uninstall hooks: %w", err)
+		}
+		if len(changed) == 0 {
+			fmt.Println("No wip hooks installed.")
+			return nil
+		}
+		for _, path := range changed {
+			fmt.Printf("Removed git hook: %s\n", path)
+		}
 		return nil
 	},
 }
+
+var hooksStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which wip git hooks are installed",
+	Long: `Show the state of each wip git hook in the current repository:
+installed, missing, or stale (left by an older version, outdated or not executable).
+Run 'wip hooks install' to fix stale hooks.
+
+With --all, every repository recorded in wip is checked.
+With --global, the hooks installed by 'wip hooks install --global' are checked.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		global, _ := cmd.Flags().GetBool("global")
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		defer w.Flush()
+
+		if global {
+			dir, err := globalHooksDir()
+			if err != nil {
+				return err
+			}
+			for _, st := range hooks.InspectGlobal(dir) {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", st.Hook.Name, formatHookState(st), st.Path)
+			}
+			return nil
+		}
+
+		if !all {
+			repo, err := currentRepo()
+			if err != nil {
+				return err
+			}
+			for _, st := range repo.Inspect() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", st.Hook.Name, formatHookState(st), st.Path)
+			}
+			return nil
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+		repos, err := a.Store.LoadDict("repos")
+		if err != nil {
+			return fmt.Errorf("failed to load repos: %w", err)
+		}
+
+		roots := repoRoots(repos)
+		if len(roots) == 0 {
+			fmt.Println("No repositories recorded yet.")
+			return nil
+		}
+		for _, root := range roots {
+			repo, err := openRepo(root)
+			if err != nil {
+				fmt.Fprintf(w, "%s\t-\tℹ️  repository not found\n", root)
+				continue
+			}
+			for _, st := range repo.Inspect() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", root, st.Hook.Name, formatHookState(st))
+			}
+		}
+		return nil
+	},
+}
+
+// currentRepo returns the hooks of the repository containing the working directory.
+// It works from subdirectories, linked worktrees, submodules and bare repositories.
+func currentRepo() (hooks.Repo, error) {
+	repo, err := openRepo("")
+	if err != nil {
+		return hooks.Repo{}, fmt.Errorf("not a git repository")
+	}
+	return repo, nil
+}
+
+// openRepo returns the hooks of the repository containing dir, or the working directory if dir is empty.
+func openRepo(dir string) (hooks.Repo, error) {
+	layout, err := git.GetLayout(dir)
+	if err != nil {
+		return hooks.Repo{}, err
+	}
+
+	hooksDir := layout.HooksDir
+	// Under 'wip hooks install --global', git's hooks path is the global
+	// directory, which runs the repository's own hooks
+	if global, err := globalHooksDir(); err == nil {
+		if real, err := filepath.EvalSymlinks(global); err == nil {
+			global = real
+		}
+		if hooksDir == global {
+			hooksDir = filepath.Join(layout.CommonDir, "hooks")
+		}
+	}
+	return hooks.Open(layout.Toplevel, hooksDir), nil
+}
+
+// globalHooksDir returns the directory holding the global hooks, next to the config file.
+func globalHooksDir() (string, error) {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "hooks"), nil
+}
+
+// repoRoots returns the distinct repository roots in the repos dictionary, sorted.
+func repoRoots(repos map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var roots []string
+	for _, v := range repos {
+		info, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Entries written from git.Info use capitalized keys
+		root, _ := info["root"].(string)
+		if root == "" {
+			root, _ = info["Root"].(string)
+		}
+		if root != "" && !seen[root] {
+			seen[root] = true
+			roots = append(roots, root)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+func formatHookState(st hooks.Status) string {
+	switch st.State {
+	case hooks.StateInstalled:
+		return "✅ installed"
+	case hooks.StateStale:
+		return "⚠️  stale (" + st.Detail + ")"
+	default:
+		return "❌ missing"
+	}
+}
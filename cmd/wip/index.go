@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/index"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexRebuildCmd)
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the full-text search index",
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Rebuild the search index from all events",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		ix, err := rebuildSearchIndex(a.Store)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✅ Indexed %d events.\n", ix.Len())
+		return nil
+	},
+}
+
+// loadSearchIndex returns the search index, building it on first use.
+func loadSearchIndex(s store.Store) (*index.Index, error) {
+	ix, err := index.Load(s.GetRootDir())
+	if err == index.ErrNotBuilt {
+		fmt.Fprintln(os.Stderr, "Building search index...")
+		return rebuildSearchIndex(s)
+	}
+	return ix, err
+}
+
+// rebuildSearchIndex indexes every event in the store from scratch.
+func rebuildSearchIndex(s store.Store) (*index.Index, error) {
+	events, err := s.QueryEvents(store.Query{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	ix, err := index.Build(s.GetRootDir(), events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search index: %w", err)
+	}
+	return ix, nil
+}
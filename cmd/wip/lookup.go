@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// completionLimit is the number of recent events offered when completing an empty ID.
+const completionLimit = 50
+
+// latestEvent returns the newest event that is still shown, as stored.
+func latestEvent(s store.Store, j *journal.Journal) (*model.WipsEvent, error) {
+	var latest *model.WipsEvent
+	err := s.IterateEvents(store.Query{Reverse: true}, func(e *model.WipsEvent) error {
+		if _, visible := j.ResolveEvent(*e); !visible {
+			return nil
+		}
+		latest = e
+		return store.ErrStop
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no events found")
+	}
+	return latest, nil
+}
+
+// completeEventID completes the first argument with event IDs.
+// An empty argument offers the most recent events; otherwise the prefix is
+// resolved through Store.GetEvent like the commands themselves do.
+func completeEventID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	a, err := app.New()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	j, err := journal.Load(a.Store)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var events []model.WipsEvent
+	if toComplete == "" {
+		err = a.Store.IterateEvents(store.Query{Reverse: true}, func(e *model.WipsEvent) error {
+			events = append(events, *e)
+			if len(events) >= completionLimit {
+				return store.ErrStop
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+	} else {
+		e, err := a.Store.GetEvent(toComplete)
+		var ambiguous *store.AmbiguousIDError
+		switch {
+		case err == nil:
+			events = []model.WipsEvent{*e}
+		case errors.As(err, &ambiguous):
+			events = ambiguous.Candidates
+		default:
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	var completions []string
+	for _, e := range j.Resolve(events) {
+		// Limit content preview length
+		preview := strings.SplitN(e.Content, "\n", 2)[0]
+		if len(preview) > 50 {
+			preview = preview[:47] + "..."
+		}
+		// Format: "ID\tContent Preview"
+		completions = append(completions, fmt.Sprintf("%s\t%s", e.ID, preview))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(replyCmd)
+}
+
+var replyCmd = &cobra.Command{
+	Use:   "reply <id> <message>",
+	Short: "Record a note replying to an event",
+	Long: `Record a note replying to an event, e.g. the answer to a question noted earlier.
+The ID can be shortened to any unambiguous prefix, like a git hash.
+'wip show <id>' shows the event with all its replies.
+
+Any note can also reference events by writing [[id-prefix]] in its content.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeEventID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %w", err)
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		parent, err := findEditable(a.Store, j, args[0])
+		if err != nil {
+			return err
+		}
+
+		event, err := usecase.NewNoteUsecase(a.Store).Reply(parent.ID, args[1], cwd)
+		if err != nil {
+			return err
+		}
+		if event != nil {
+			fmt.Printf("✅ Reply recorded: %s (ID: %s)\n", event.Content, event.ID)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportTimeCmd)
+	reportTimeCmd.Flags().Bool("week", false, "Report this week")
+	reportTimeCmd.Flags().Bool("last-week", false, "Report last week")
+	reportTimeCmd.Flags().IntP("days", "d", 0, "Report the past N days")
+	reportTimeCmd.Flags().String("date", "", "Report a specific date (YYYY-MM-DD)")
+	reportTimeCmd.Flags().Bool("inferred", false, "Estimate the time from notes, tasks and commits instead of sessions")
+	reportTimeCmd.Flags().Duration("gap", usecase.DefaultInferGap, "With --inferred, the longest gap between events counted as work")
+	reportTimeCmd.Flags().Bool("include-hidden", false, "Include hidden directories in output")
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show reports",
+}
+
+var reportTimeCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Show the time worked per repository, branch and tag",
+	Long: `Show the time worked per repository, branch and tag, from the sessions recorded
+by 'wip start' and 'wip stop'. Without a period option it is today.
+
+With --inferred the time is estimated from notes, tasks and commits instead:
+each one counts as work since the previous one, up to --gap.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		week, _ := cmd.Flags().GetBool("week")
+		lastWeek, _ := cmd.Flags().GetBool("last-week")
+		days, _ := cmd.Flags().GetInt("days")
+		date, _ := cmd.Flags().GetString("date")
+		inferred, _ := cmd.Flags().GetBool("inferred")
+		gap, _ := cmd.Flags().GetDuration("gap")
+		includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+
+		period := usecase.SummaryOptions{Week: week, LastWeek: lastWeek, Days: days, Date: date}
+		start, end, err := period.Period(time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid date: %w", err)
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		opts := usecase.TimeReportOptions{Start: start, End: end, Inferred: inferred, Gap: gap}
+		if !includeHidden {
+			opts.HiddenDirs = a.HiddenDirs()
+		}
+		report, err := usecase.NewSessionUsecase(a.Store).Report(opts)
+		if err != nil {
+			return fmt.Errorf("failed to get report: %w", err)
+		}
+
+		from, to := report.Start.Format("2006-01-02"), report.End.Format("2006-01-02")
+		if from == to {
+			fmt.Println(ui.DateColor("Time worked on " + from))
+		} else {
+			fmt.Println(ui.DateColor(fmt.Sprintf("Time worked from %s to %s", from, to)))
+		}
+		if report.Total == 0 {
+			fmt.Println("No time recorded.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\nBy repository")
+		for _, r := range report.Repos {
+			fmt.Fprintf(w, "  %s\t%s\n", r.Name, ui.FormatWorked(r.Duration))
+			for _, b := range r.Branches {
+				fmt.Fprintf(w, "    🌿 %s\t%s\n", b.Name, ui.FormatWorked(b.Duration))
+			}
+		}
+		if len(report.Tags) > 0 {
+			fmt.Fprintln(w, "\nBy tag")
+			for _, t := range report.Tags {
+				fmt.Fprintf(w, "  %s\t%s\n", t.Name, ui.FormatWorked(t.Duration))
+			}
+		}
+		fmt.Fprintf(w, "\nTotal\t%s\n", ui.FormatWorked(report.Total))
+		return w.Flush()
+	},
+}
@@ -55,17 +55,17 @@ func TestRunCapture(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	mockGitShow := func() ([]byte, error) {
-		return []byte("commit abc1234\n 1 file changed"), nil
+	mockGit := func(args ...string) ([]byte, error) {
+		return []byte("\x1eabc1234def\x00\x00Dev\x00dev@example.com\x002024-01-02T03:04:05Z\x002024-01-02T03:04:05Z\x00Fix parser\x00\x00\n\n3\t1\tparser.go\n"), nil
 	}
 
-	u := usecase.NewCaptureUsecase(s)
-	err = u.CaptureEvent("git-commit", tempDir, mockGitShow)
+	u := usecase.NewCaptureUsecase(s, mockGit)
+	err = u.CaptureEvent("git-commit", tempDir, usecase.HookInput{})
 	if err != nil {
 		t.Errorf("CaptureEvent() error = %v", err)
 	}
 
-	verifyEventExists(t, tempDir, model.EventTypeGitCommit, "commit abc1234\n 1 file changed")
+	verifyEventExists(t, tempDir, model.EventTypeGitCommit, "abc1234 Fix parser")
 }
 
 func verifyEventExists(t *testing.T, rootDir string, eventType model.EventType, content string) {
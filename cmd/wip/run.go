@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	// Flags after the command name belong to the command
+	runCmd.Flags().SetInterspersed(false)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run -- <command> [args...]",
+	Short: "Run a command and record its result",
+	Long: `Run a command, passing its input and output through unchanged, then record a command event
+with its exit code, duration and the end of its output. wip exits with the command's exit code.`,
+	Example: `  wip run -- go test ./...
+  wip run -- make deploy`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %w", err)
+		}
+
+		// The command runs first, so that opening the store never holds it up
+		run, err := runCommand(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wip: %v\n", err)
+		}
+
+		event, err := recordCommand(run, cwd)
+		if err != nil {
+			// The command's result matters more than the journal
+			fmt.Fprintf(os.Stderr, "wip: failed to record command: %v\n", err)
+		} else if event != nil {
+			result := fmt.Sprintf("exit %d, %s", run.ExitCode, ui.FormatElapsed(run.Duration))
+			if run.Failed() {
+				fmt.Fprintf(os.Stderr, "❌ Command failed (%s): %s (ID: %s)\n", result, event.Content, event.ID)
+			} else {
+				fmt.Fprintf(os.Stderr, "✅ Command recorded (%s): %s (ID: %s)\n", result, event.Content, event.ID)
+			}
+		}
+
+		if run.ExitCode != 0 {
+			os.Exit(run.ExitCode)
+		}
+		return nil
+	},
+}
+
+// recordCommand opens the store and records run as a command event.
+func recordCommand(run model.Command, cwd string) (*model.WipsEvent, error) {
+	a, err := app.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize app: %w", err)
+	}
+	return usecase.NewCommandUsecase(a.Store).RecordCommand(run, cwd)
+}
+
+// runCommand runs argv with the terminal's stdin, stdout and stderr, keeping the end of its output.
+// If the command cannot be started, the error is returned with the command
+// failed with exit code 127, as shells report a command not found.
+func runCommand(argv []string) (model.Command, error) {
+	run := model.Command{Argv: argv}
+	tail := &usecase.OutputTail{}
+
+	c := exec.Command(argv[0], argv[1:]...)
+	c.Stdin = os.Stdin
+	c.Stdout = io.MultiWriter(os.Stdout, tail)
+	c.Stderr = io.MultiWriter(os.Stderr, tail)
+
+	// Ctrl+C reaches the command too; wip stays alive to record how it ended
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	start := time.Now()
+	err := c.Run()
+	run.Duration = time.Since(start)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			// Shells report a command killed by a signal as 128+n
+			run.ExitCode = 128 + int(ws.Signal())
+		}
+	default:
+		err = fmt.Errorf("failed to run %s: %w", argv[0], err)
+		run.ExitCode = 127
+		run.Output = err.Error()
+		return run, err
+	}
+
+	run.Output, run.Truncated = tail.String()
+	return run, nil
+}
@@ -11,7 +11,11 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/index"
+	"github.com/rynskrmt/wips-cli/internal/journal"
 	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/query"
+	"github.com/rynskrmt/wips-cli/internal/store"
 	"github.com/rynskrmt/wips-cli/internal/ui"
 	"github.com/spf13/cobra"
 	"github.com/tj/go-naturaldate"
@@ -23,24 +27,78 @@ func init() {
 	searchCmd.Flags().StringP("to", "t", "", "End date (e.g. 'today', '2023-12-31')")
 	searchCmd.Flags().BoolP("regex", "r", false, "Treat query as regular expression")
 	searchCmd.Flags().StringSlice("tag", []string{}, "Filter by tags (e.g. 'bug', 'feature')")
-	searchCmd.Flags().String("type", "", "Filter by event type (note, commit)")
+	searchCmd.Flags().String("type", "", "Filter by event type (any value of type:, e.g. note, commit, task)")
+	searchCmd.Flags().String("sort", "", "Result order: relevance (default with a query) or time")
 }
 
 var searchCmd = &cobra.Command{
-	Use:   "search [query]",
+	Use:   "search [query...]",
 	Short: "Search for events",
-	Long:  `Search for events containing the specified keyword using natural language date filters.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Search for events using a small query language and natural language date filters.
+
+Words are looked up in a full-text index and results are ranked by relevance.
+All terms must match unless combined with OR; use "quoted text" for an exact
+phrase and a trailing * for prefix matching (e.g. auth*). Japanese text is
+matched by character bigrams.
+
+Words match whole words rather than any substring as in earlier versions:
+auth no longer matches authentication; use auth* for that.
+
+Fields narrow the search by metadata:
+  repo:NAME      repository name (globs allowed, e.g. repo:wips-*)
+  branch:NAME    branch name (e.g. branch:feat/*)
+  type:TYPE      note, commit, checkout, merge, rewrite, push, command,
+                 dir, task, session or undo
+  tag:NAME       #NAME in the content, or added with 'wip tag add'
+  dir:PATH       working directory or any of its subdirectories
+  after:DATE     on or after the date (YYYY-MM-DD, yesterday, ...)
+  before:DATE    before the date
+  id:PREFIX      event ID prefix
+
+Terms can be grouped with parentheses and combined with AND, OR and NOT;
+a leading - negates a term.
+
+Examples:
+  wip search 'repo:wips-cli branch:feat/* type:commit tag:bug -tag:wontfix'
+  wip search '(tag:bug OR tag:regression) "login page" after:2024-01-01'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		query := ""
-		if len(args) > 0 {
-			query = args[0]
-		}
+		queryStr := joinQueryArgs(args)
 		fromStr, _ := cmd.Flags().GetString("from")
 		toStr, _ := cmd.Flags().GetString("to")
 		isRegex, _ := cmd.Flags().GetBool("regex")
 		tags, _ := cmd.Flags().GetStringSlice("tag")
 		eventType, _ := cmd.Flags().GetString("type")
+		sortOrder, _ := cmd.Flags().GetString("sort")
+		scoreOf := func(*model.WipsEvent) float64 { return 0 }
+
+		// Parse the query language unless the query is a regular expression
+		var node query.Node
+		var re *regexp.Regexp
+		var err error
+		if isRegex {
+			if queryStr != "" {
+				re, err = regexp.Compile(queryStr)
+				if err != nil {
+					return fmt.Errorf("invalid regex: %w", err)
+				}
+			}
+		} else {
+			node, err = query.Parse(queryStr)
+			if err != nil {
+				return fmt.Errorf("invalid query: %w", err)
+			}
+		}
+
+		ranked := len(query.TextTerms(node)) > 0
+		if sortOrder == "" {
+			sortOrder = "time"
+			if ranked {
+				sortOrder = "relevance"
+			}
+		}
+		if sortOrder != "time" && sortOrder != "relevance" {
+			return fmt.Errorf("invalid sort order %q (expected relevance or time)", sortOrder)
+		}
 
 		// Initialize app with centralized dependencies
 		a, err := app.New()
@@ -79,63 +137,124 @@ var searchCmd = &cobra.Command{
 			end = parsed.Add(24*time.Hour - time.Nanosecond) // End of that day
 		}
 
-		events, err := a.Store.GetEvents(start, end)
-		if err != nil {
-			return fmt.Errorf("failed to get events: %w", err)
+		// after:/before: in the query narrow the range further
+		qStart, qEnd := query.TimeBounds(node)
+		if !qStart.IsZero() && (fromStr == "" || qStart.After(start)) {
+			start = qStart
+		}
+		if !qEnd.IsZero() && qEnd.Before(end) {
+			end = qEnd
 		}
 
-		// 2. Prepare Regex
-		var re *regexp.Regexp
-		if isRegex && query != "" {
-			re, err = regexp.Compile(query)
+		q := store.Query{Start: start, End: end}
+		if eventType != "" {
+			t, err := query.ParseType(eventType)
 			if err != nil {
-				return fmt.Errorf("invalid regex: %w", err)
+				return err
 			}
+			q.Types = []model.EventType{t}
 		}
 
-		var matchedEvents []model.WipsEvent
+		env := &query.Env{}
+		env.Repos, _ = a.Store.LoadDict("repos")
+		env.Dirs, _ = a.Store.LoadDict("dirs")
+
+		// Text terms are looked up in the full-text index
+		if hasText(node) {
+			ix, err := loadSearchIndex(a.Store)
+			if err != nil {
+				return err
+			}
+			hits := make(map[*query.Text]map[string]float64)
+			var searchErr error
+			lookup := func(t *query.Text) map[string]float64 {
+				if h, ok := hits[t]; ok {
+					return h
+				}
+				h := make(map[string]float64)
+				results, err := ix.Search(indexQuery(t))
+				if err != nil && searchErr == nil {
+					searchErr = err
+				}
+				for _, r := range results {
+					h[r.ID] = r.Score
+				}
+				hits[t] = h
+				return h
+			}
+			env.MatchText = func(e *model.WipsEvent, t *query.Text) bool {
+				_, ok := lookup(t)[e.ID]
+				return ok
+			}
 
-		for _, e := range events {
-			// Filter by Type
-			if eventType != "" {
-				if eventType == "note" && e.Type != model.EventTypeNote {
-					continue
+			// Terms every match must contain bound the range of events to load
+			for _, t := range requiredTexts(node) {
+				first, last := hitSpan(lookup(t), ix)
+				if first.IsZero() {
+					fmt.Println("No events found.")
+					return nil
+				}
+				if first.After(q.Start) {
+					q.Start = first
 				}
-				if eventType == "commit" && e.Type != model.EventTypeGitCommit {
-					continue
+				if last.Before(q.End) {
+					q.End = last
+				}
+			}
+			if searchErr != nil {
+				return fmt.Errorf("invalid query: %w", searchErr)
+			}
+
+			scoreOf = func(e *model.WipsEvent) float64 {
+				var score float64
+				for _, t := range query.TextTerms(node) {
+					score += lookup(t)[e.ID]
 				}
+				return score
+			}
+		}
+
+		// Hide undone events and roll back undone edits
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		var matchedEvents []model.WipsEvent
+		err = a.Store.IterateEvents(q, func(raw *model.WipsEvent) error {
+			e, visible := j.ResolveEvent(*raw)
+			if !visible {
+				return nil
 			}
 
 			// Filter by Content (Query)
-			if query != "" {
-				if isRegex {
-					if !re.MatchString(e.Content) {
-						continue
-					}
-				} else {
-					if !strings.Contains(strings.ToLower(e.Content), strings.ToLower(query)) {
-						continue
-					}
+			if re != nil {
+				if !re.MatchString(e.Content) {
+					return nil
 				}
+			} else if !query.Match(node, &e, env) {
+				return nil
 			}
 
 			// Filter by Tags
 			if len(tags) > 0 {
 				hasTag := false
 				for _, tag := range tags {
-					// Check if content contains #tag
-					tagRef := "#" + tag
-					if strings.Contains(strings.ToLower(e.Content), strings.ToLower(tagRef)) {
+					if e.HasTag(tag) {
 						hasTag = true
 						break
 					}
 				}
 				if !hasTag {
-					continue
+					return nil
 				}
 			}
 
 			matchedEvents = append(matchedEvents, e)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get events: %w", err)
 		}
 
 		if len(matchedEvents) == 0 {
@@ -143,10 +262,24 @@ var searchCmd = &cobra.Command{
 			return nil
 		}
 
-		// Sort Oldest -> Newest
-		sort.Slice(matchedEvents, func(i, j int) bool {
-			return matchedEvents[i].TS.Before(matchedEvents[j].TS)
-		})
+		if sortOrder == "relevance" {
+			// Best match first
+			scores := make(map[string]float64, len(matchedEvents))
+			for i := range matchedEvents {
+				scores[matchedEvents[i].ID] = scoreOf(&matchedEvents[i])
+			}
+			sort.SliceStable(matchedEvents, func(i, j int) bool {
+				if scores[matchedEvents[i].ID] != scores[matchedEvents[j].ID] {
+					return scores[matchedEvents[i].ID] > scores[matchedEvents[j].ID]
+				}
+				return matchedEvents[i].TS.After(matchedEvents[j].TS)
+			})
+		} else {
+			// Sort Oldest -> Newest
+			sort.Slice(matchedEvents, func(i, j int) bool {
+				return matchedEvents[i].TS.Before(matchedEvents[j].TS)
+			})
+		}
 
 		// Render
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -163,3 +296,84 @@ var searchCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// joinQueryArgs builds the query from command line arguments.
+// A single argument is the query itself; when the query is split over several
+// arguments, those containing spaces were quoted in the shell and become phrases.
+func joinQueryArgs(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t") && !strings.Contains(arg, `"`) {
+			if name, value, ok := strings.Cut(arg, ":"); ok && !strings.ContainsAny(name, " \t") {
+				arg = name + `:"` + value + `"`
+			} else {
+				arg = `"` + arg + `"`
+			}
+		}
+		parts[i] = arg
+	}
+	return strings.Join(parts, " ")
+}
+
+// indexQuery converts a text term into a full-text index query.
+func indexQuery(t *query.Text) string {
+	if t.Phrase {
+		return `"` + t.Value + `"`
+	}
+	return t.Value
+}
+
+// hasText reports whether the query contains any text term, negated or not.
+func hasText(n query.Node) bool {
+	switch n := n.(type) {
+	case *query.Text:
+		return true
+	case *query.Not:
+		return hasText(n.Node)
+	case *query.And:
+		for _, c := range n.Nodes {
+			if hasText(c) {
+				return true
+			}
+		}
+	case *query.Or:
+		for _, c := range n.Nodes {
+			if hasText(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requiredTexts returns the text terms every match must contain.
+func requiredTexts(n query.Node) []*query.Text {
+	switch n := n.(type) {
+	case *query.Text:
+		return []*query.Text{n}
+	case *query.And:
+		var texts []*query.Text
+		for _, c := range n.Nodes {
+			texts = append(texts, requiredTexts(c)...)
+		}
+		return texts
+	}
+	return nil
+}
+
+// hitSpan returns the time range covered by the given index hits.
+func hitSpan(hits map[string]float64, ix *index.Index) (first, last time.Time) {
+	for id := range hits {
+		ts := ix.Docs[id].TS
+		if first.IsZero() || ts.Before(first) {
+			first = ts
+		}
+		if ts.After(last) {
+			last = ts
+		}
+	}
+	return first, last
+}
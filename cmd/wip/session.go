@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start <topic>",
+	Short: "Start a work session",
+	Long: `Start a work session on a topic, recorded with the current repository and branch.
+#tags in the topic are recorded too. A running session is stopped first.
+The time worked in sessions is reported by 'wip report time'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %w", err)
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		started, stopped, err := usecase.NewSessionUsecase(a.Store).Start(args[0], cwd)
+		if err != nil {
+			return err
+		}
+		if stopped != nil {
+			printStopped(stopped)
+		}
+		fmt.Printf("▶️  Session started: %s (ID: %s)\n", started.Content, started.ID)
+		return nil
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the work session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		event, err := usecase.NewSessionUsecase(a.Store).Stop()
+		if err != nil {
+			return err
+		}
+		printStopped(event)
+		return nil
+	},
+}
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause the work session",
+	Long:  `Pause the work session. The time until 'wip resume' is not counted.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		event, err := usecase.NewSessionUsecase(a.Store).Pause()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("⏸️  Session paused: %s\n", event.Content)
+		return nil
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume the paused work session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		event, err := usecase.NewSessionUsecase(a.Store).Resume()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("⏯️  Session resumed: %s\n", event.Content)
+		return nil
+	},
+}
+
+// printStopped prints a stop event with the time worked in its session.
+func printStopped(e *model.WipsEvent) {
+	var elapsed time.Duration
+	if s := e.SessionMeta(); s != nil {
+		elapsed = s.Elapsed
+	}
+	fmt.Printf("⏹️  Session stopped: %s (%s)\n", e.Content, ui.FormatWorked(elapsed))
+}
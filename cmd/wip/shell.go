@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/shell"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	shellCmd.AddCommand(shellInitCmd)
+	shellCmd.AddCommand(shellFlushCmd)
+	shellInitCmd.Flags().Bool("no-dirs", false, "Do not record entering repositories")
+	shellInitCmd.Flags().Bool("no-commands", false, "Do not record long-running commands")
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Record directory changes and long-running commands from your shell",
+}
+
+var shellInitCmd = &cobra.Command{
+	Use:   "init <bash|zsh|fish>",
+	Short: "Print the shell integration code",
+	Long: `Print the code that hooks wip into your shell. Add it to your shell's startup file:
+
+  bash (~/.bashrc):                eval "$(wip shell init bash)"
+  zsh (~/.zshrc):                  eval "$(wip shell init zsh)"
+  fish (~/.config/fish/config.fish): wip shell init fish | source
+
+Entering a repository and commands running longer than the threshold
+([shell] command_threshold in the config, default 10 seconds) are recorded.
+The shell only appends them to a file; they are saved in the background in batches.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: shell.Shells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noDirs, _ := cmd.Flags().GetBool("no-dirs")
+		noCommands, _ := cmd.Flags().GetBool("no-commands")
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		snippet, err := shell.Snippet(args[0], shell.Options{
+			Spool:     spoolPath(a),
+			Dirs:      !noDirs,
+			Commands:  !noCommands,
+			Threshold: a.Config.Shell.Threshold(),
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Print(snippet)
+		return nil
+	},
+}
+
+var shellFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Save the events spooled by the shell integration",
+	Long:  `Save the events spooled by the shell integration. The shell runs this in the background; run it to see recent events right away.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		u := usecase.NewShellUsecase(a.Store, a.Config)
+		n, err := u.Flush(spoolPath(a))
+		if err != nil {
+			return fmt.Errorf("failed to flush shell events: %w", err)
+		}
+		fmt.Printf("✅ Recorded %d shell events\n", n)
+		return nil
+	},
+}
+
+// spoolPath returns the spool file of the shell integration, in the data directory.
+func spoolPath(a *app.App) string {
+	return filepath.Join(a.Store.GetRootDir(), shell.SpoolFile)
+}
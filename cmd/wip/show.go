@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	showCmd.Flags().Bool("json", false, "Print the event and its resolved context as JSON")
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show an event in full, with its thread",
+	Long: `Show everything about an event: its full content, time, type, the repository,
+directory, branch, commit and environment it was recorded in, tags and Meta.
+
+Its thread follows: the event it replies to, and all the replies recorded with
+'wip reply'. Events referenced as [[id-prefix]] are listed as links.
+The ID can be shortened to any unambiguous prefix, like a git hash.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEventID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		found, err := findEditable(a.Store, j, args[0])
+		if err != nil {
+			return err
+		}
+
+		thread, err := usecase.NewThreadUsecase(a.Store).Thread(found.ID)
+		if err != nil {
+			return err
+		}
+		node := thread.Node(found.ID)
+		if node == nil {
+			return fmt.Errorf("event %s is missing from its thread", found.ID)
+		}
+		e := node.Event
+		ctx := usecase.ResolveContext(a.Store, e.Ctx)
+
+		if asJSON {
+			return printEventJSON(os.Stdout, e, ctx, node, thread)
+		}
+
+		printEventDetail(os.Stdout, e, ctx)
+
+		// The thread is shown when the event replies or has been replied to
+		if len(thread.Root.Replies) > 0 || len(thread.Links) > 0 || len(thread.Backlinks) > 0 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			if len(thread.Root.Replies) > 0 {
+				fmt.Fprintln(w, "\nThread")
+				printThread(w, thread.Root, e.ID, 0)
+			}
+			if len(thread.Links) > 0 {
+				fmt.Fprintln(w, "\nLinks")
+				for _, linked := range thread.Links {
+					printThreadEvent(w, linked, "  ", false)
+				}
+			}
+			if len(thread.Backlinks) > 0 {
+				fmt.Fprintln(w, "\nReferenced by")
+				for _, linked := range thread.Backlinks {
+					printThreadEvent(w, linked, "  ", false)
+				}
+			}
+			return w.Flush()
+		}
+		return nil
+	},
+}
+
+// printEventDetail prints the fields of an event, its full content and its Meta.
+func printEventDetail(out io.Writer, e model.WipsEvent, ctx usecase.EventContext) {
+	icon, _ := ui.FormatEventWithStyle(e)
+	fmt.Fprintf(out, "%s %s  %s\n", icon, e.Type, ui.HashColor(e.ID))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%s\t%s\n", ui.FaintColor(name), value)
+		}
+	}
+	field("Date", e.TS.Format("2006-01-02 15:04:05 (Mon)"))
+	if r := ctx.Repo; r != nil {
+		name := r.Name
+		if name == "" {
+			name = filepath.Base(r.Root)
+		}
+		field("Repo", strings.TrimSpace(fmt.Sprintf("@%s  %s", name, r.Remote)))
+	}
+	field("Dir", ctx.Dir)
+	field("Branch", ctx.Branch)
+	field("Head", ctx.Head)
+	field("Worktree", ctx.Worktree)
+	if env := ctx.Env; env != nil {
+		field("Env", fmt.Sprintf("%s@%s (%s/%s)", env.User, env.Host, env.OS, env.Arch))
+	}
+	if tags := e.TagList(); len(tags) > 0 {
+		field("Tags", "#"+strings.Join(tags, " #"))
+	}
+	field("Reply to", e.ParentID)
+	field("Links", strings.Join(e.RefList(), " "))
+	w.Flush()
+
+	fmt.Fprintln(out)
+	for _, line := range strings.Split(strings.TrimRight(e.Content, "\n"), "\n") {
+		fmt.Fprintf(out, "    %s\n", line)
+	}
+
+	if len(e.Meta) > 0 {
+		var meta bytes.Buffer
+		if err := json.Indent(&meta, e.Meta, "    ", "  "); err == nil {
+			fmt.Fprintf(out, "\n%s\n    %s\n", ui.FaintColor("Meta"), meta.String())
+		}
+	}
+}
+
+// eventJSON is the output of 'wip show --json': the event as stored, with the
+// undo history applied, its resolved context and thread.
+type eventJSON struct {
+	model.WipsEvent
+	Context   usecase.EventContext `json:"context"`
+	Links     []string             `json:"links,omitempty"`
+	Replies   []string             `json:"replies,omitempty"`
+	Backlinks []string             `json:"backlinks,omitempty"`
+}
+
+func printEventJSON(out io.Writer, e model.WipsEvent, ctx usecase.EventContext, node *usecase.ThreadNode, thread *usecase.Thread) error {
+	v := eventJSON{WipsEvent: e, Context: ctx, Links: e.RefList()}
+	for _, reply := range node.Replies {
+		v.Replies = append(v.Replies, reply.Event.ID)
+	}
+	for _, linked := range thread.Backlinks {
+		v.Backlinks = append(v.Backlinks, linked.ID)
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printThread prints an event of a thread and its replies below it, marking
+// the event with the ID selected.
+func printThread(w io.Writer, node *usecase.ThreadNode, selected string, depth int) {
+	indent := "  "
+	if depth > 0 {
+		indent += strings.Repeat("  ", depth-1) + "↳ "
+	}
+	printThreadEvent(w, node.Event, indent, node.Event.ID == selected)
+	for _, reply := range node.Replies {
+		printThread(w, reply, selected, depth+1)
+	}
+}
+
+// printThreadEvent prints one line of an event: time, icon, summary and ID.
+func printThreadEvent(w io.Writer, e model.WipsEvent, indent string, selected bool) {
+	icon, summary := ui.FormatEventWithStyle(e)
+	id := ui.FaintColor(e.ID)
+	if selected {
+		id = ui.HashColor(e.ID)
+	}
+	fmt.Fprintf(w, "%s%s\t%s  %s\t%s\n", indent, ui.TimeColor(e.TS.Format("2006-01-02 15:04")), icon, summary, id)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rynskrmt/wips-cli/internal/config"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(storeCmd)
+	storeCmd.AddCommand(storeMigrateCmd)
+	storeMigrateCmd.Flags().String("to", "", "Target backend (files, sqlite)")
+	storeMigrateCmd.MarkFlagRequired("to")
+}
+
+var storeCmd = &cobra.Command{
+	Use:   "store",
+	Short: "Manage the data store",
+}
+
+var storeMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Convert the data store to another backend",
+	Long: `Copy all events and dictionaries from the current backend into another one
+and switch the configuration to use it. The old data is kept in a backup directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+
+		var from string
+		switch to {
+		case store.BackendFiles:
+			from = store.BackendSQLite
+		case store.BackendSQLite:
+			from = store.BackendFiles
+		default:
+			return fmt.Errorf("unknown backend %q (expected %s or %s)", to, store.BackendFiles, store.BackendSQLite)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		location := os.Getenv("WIPS_HOME")
+		rootDir, current := store.ParseLocation(location, cfg.Store.Backend)
+		if current == to {
+			return fmt.Errorf("the store already uses the %s backend", to)
+		}
+		if current != from {
+			return fmt.Errorf("the store uses the %s backend, not %s", current, from)
+		}
+
+		src, err := store.Open(rootDir, from)
+		if err != nil {
+			return fmt.Errorf("failed to open %s store: %w", from, err)
+		}
+		dst, err := store.Open(rootDir, to)
+		if err != nil {
+			return fmt.Errorf("failed to open %s store: %w", to, err)
+		}
+		if err := src.Prepare(); err != nil {
+			return fmt.Errorf("failed to prepare %s store: %w", from, err)
+		}
+		if err := dst.Prepare(); err != nil {
+			return fmt.Errorf("failed to prepare %s store: %w", to, err)
+		}
+
+		result, err := store.Migrate(src, dst)
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		fmt.Printf("✅ Migrated %d events and %d dictionary entries from %s to %s.\n", result.Events, result.DictEntries, from, to)
+		fmt.Printf("Previous %s data moved to %s\n", from, result.BackupDir)
+
+		if c, ok := dst.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				return fmt.Errorf("failed to close %s store: %w", to, err)
+			}
+		}
+		cfg.Store.Backend = to
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Printf("Store backend set to %s.\n", to)
+
+		if strings.Contains(location, "://") {
+			fmt.Printf("Note: WIPS_HOME (%s) selects a backend explicitly and overrides the config.\n", location)
+		}
+		return nil
+	},
+}
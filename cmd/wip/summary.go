@@ -24,6 +24,8 @@ func init() {
 	summaryCmd.Flags().StringP("format", "f", "pretty", "Output format (pretty, md, txt)")
 	summaryCmd.Flags().Bool("include-hidden", false, "Include hidden directories in output")
 	summaryCmd.Flags().Bool("hidden-only", false, "Show only hidden directories")
+	summaryCmd.Flags().Bool("stats", false, "Show commits and lines changed per repository and day")
+	summaryCmd.Flags().String("by", "dir", "Group events by dir or tag")
 }
 
 // summaryCmd represents the summary command, which aggregates and displays events.
@@ -42,6 +44,12 @@ var summaryCmd = &cobra.Command{
 		format, _ := cmd.Flags().GetString("format")
 		includeHidden, _ := cmd.Flags().GetBool("include-hidden")
 		hiddenOnly, _ := cmd.Flags().GetBool("hidden-only")
+		stats, _ := cmd.Flags().GetBool("stats")
+		by, _ := cmd.Flags().GetString("by")
+		groupBy, err := usecase.ParseGroupBy(by)
+		if err != nil {
+			return err
+		}
 
 		if outPath != "" && format == "pretty" {
 			format = "md" // Default to markdown if outputting to file
@@ -63,6 +71,7 @@ var summaryCmd = &cobra.Command{
 			IncludeHidden: includeHidden,
 			HiddenOnly:    hiddenOnly,
 			HiddenDirs:    a.HiddenDirs(),
+			GroupBy:       groupBy,
 		}
 
 		result, err := uc.GetSummary(opts)
@@ -86,6 +95,7 @@ var summaryCmd = &cobra.Command{
 		}
 
 		renderer := ui.NewSummaryRenderer(out)
+		renderer.Stats = stats
 
 		if format == "pretty" && outPath == "" {
 			renderer.RenderPretty(result)
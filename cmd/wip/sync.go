@@ -38,8 +38,17 @@ var syncCmd = &cobra.Command{
 		// Register Targets
 		if cfg.Sync.Obsidian != nil {
 			createMissing, _ := cmd.Flags().GetBool("create")
+			by, _ := cmd.Flags().GetString("by")
+			if by == "" {
+				by = cfg.Sync.Obsidian.GroupBy
+			}
+			groupBy, err := usecase.ParseGroupBy(by)
+			if err != nil {
+				return err
+			}
 			opts := obsidian.TargetOptions{
 				CreateMissing: createMissing,
+				GroupBy:       groupBy,
 			}
 			mgr.RegisterTarget(obsidian.NewTarget(cfg.Sync.Obsidian, a.Store, opts))
 		}
@@ -76,19 +85,8 @@ var syncCmd = &cobra.Command{
 		}
 
 		if all {
-			// All time
-			// SummaryUsecase doesn't strictly have "All" but if we don't set Day/Week/etc it defaults to Today usually?
-			// Actually SummaryUsecase defaults to "Day" if nothing set.
-			// We might need to extend SummaryUsecase or just use `store.GetEvents(start, end)` directly?
-			// But specific date querying is handled by logic in SummaryUsecase.
-
-			// If `all` is passed, we probably want to iterate ALL days available in store.
-			// Or just pass a very wide range.
-			// For now, let's limit `all` scope or fetch everything from known start time?
-			// Store likely has `GetEvents(from, to)`.
-			// Let's defer "all" complexity and focus on specific ranges.
-			fmt.Println("Syncing --all is not fully optimized yet, syncing last 365 days...")
-			opts.Days = 365
+			// Events are streamed from the store, so the whole history can be synced at once
+			opts.All = true
 		} else if dateStr != "" {
 			opts.Date = dateStr
 		} else if days > 0 {
@@ -143,4 +141,5 @@ func init() {
 	syncCmd.Flags().Bool("dry-run", false, "Dry run")
 	syncCmd.Flags().Bool("create", false, "Create daily note if missing")
 	syncCmd.Flags().Bool("include-hidden", false, "Include hidden directories in sync")
+	syncCmd.Flags().String("by", "", "Group events by dir or tag (default: group_by in the Obsidian config, or dir)")
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(tagsCmd)
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRmCmd)
+	tagsCmd.Flags().IntP("days", "d", 0, "Only count events of the past N days")
+}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Add or remove tags of an event",
+	Long: `Add or remove tags of an event. Tags written in the content as #tag are
+recorded with the event; these commands tag it without changing the content.
+The ID can be shortened to any unambiguous prefix, like a git hash.`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:               "add <id> <tag>...",
+	Short:             "Add tags to an event",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeEventID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateTags(args[0], args[1:], true)
+	},
+}
+
+var tagRmCmd = &cobra.Command{
+	Use:               "rm <id> <tag>...",
+	Aliases:           []string{"remove"},
+	Short:             "Remove tags from an event",
+	Long:              `Remove tags from an event. Tags written in the content are removed by editing it with 'wip edit'.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeEventID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateTags(args[0], args[1:], false)
+	},
+}
+
+// updateTags adds or removes tags of the event with the given ID or prefix.
+func updateTags(id string, tags []string, add bool) error {
+	a, err := app.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize app: %w", err)
+	}
+	j, err := journal.Load(a.Store)
+	if err != nil {
+		return fmt.Errorf("failed to load undo history: %w", err)
+	}
+
+	e, err := findEditable(a.Store, j, id)
+	if err != nil {
+		return err
+	}
+
+	uc := usecase.NewTagUsecase(a.Store)
+	if add {
+		err = uc.Add(e.ID, tags...)
+	} else {
+		err = uc.Remove(e.ID, tags...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update tags of event %s: %w", e.ID, err)
+	}
+
+	updated, err := a.Store.GetEvent(e.ID)
+	if err != nil {
+		return err
+	}
+	list := "none"
+	if tags := updated.TagList(); len(tags) > 0 {
+		list = "#" + strings.Join(tags, " #")
+	}
+	fmt.Printf("✅ Event %s tags: %s\n", e.ID, list)
+	return nil
+}
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List tags with the number of events having each",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		var q store.Query
+		if days > 0 {
+			now := time.Now()
+			q.Start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -days)
+		}
+
+		counts, err := usecase.NewTagUsecase(a.Store).Counts(q)
+		if err != nil {
+			return fmt.Errorf("failed to count tags: %w", err)
+		}
+		if len(counts) == 0 {
+			fmt.Println("No tags found.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, c := range counts {
+			fmt.Fprintf(w, "%d\t #%s\n", c.Count, c.Tag)
+		}
+		return w.Flush()
+	},
+}
@@ -1,18 +1,17 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
-	"time"
 
 	"github.com/rynskrmt/wips-cli/internal/app"
 	"github.com/rynskrmt/wips-cli/internal/filter"
+	"github.com/rynskrmt/wips-cli/internal/journal"
 	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
 	"github.com/rynskrmt/wips-cli/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -59,61 +58,64 @@ var tailCmd = &cobra.Command{
 			reposDict = make(map[string]interface{})
 		}
 
-		// Read current month file
-		filename := time.Now().Format("2006-01") + ".ndjson"
-		path := filepath.Join(a.Store.GetRootDir(), "events", filename)
-
-		f, err := os.Open(path)
-		if os.IsNotExist(err) {
-			fmt.Println("No events found for this month.")
-			return nil
-		}
+		// Hide undone events and roll back undone edits
+		j, err := journal.Load(a.Store)
 		if err != nil {
-			return fmt.Errorf("failed to open events file: %w", err)
+			return fmt.Errorf("failed to load undo history: %w", err)
 		}
-		defer f.Close()
 
+		// Walk back from the newest event, across months, until n events are found
 		var events []model.WipsEvent
-		scanner := bufio.NewScanner(f)
-		for scanner.Scan() {
-			var e model.WipsEvent
-			if err := json.Unmarshal(scanner.Bytes(), &e); err == nil {
-				// Get dir path for filtering
-				var dirPath string
-				if e.Ctx.CwdID != nil {
-					if dp, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
-						dirPath = dp
-					}
-				}
+		err = a.Store.IterateEvents(store.Query{Reverse: true}, func(raw *model.WipsEvent) error {
+			if len(events) >= n {
+				return store.ErrStop
+			}
+			e, visible := j.ResolveEvent(*raw)
+			if !visible {
+				return nil
+			}
 
-				// Hidden directory filtering using shared filter package
-				if !includeHidden && filter.IsHiddenDir(dirPath, a.HiddenDirs()) {
-					continue
+			// Get dir path for filtering
+			var dirPath string
+			if e.Ctx.CwdID != nil {
+				if dp, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
+					dirPath = dp
 				}
+			}
 
-				// Filter by context if not global
-				if !global {
-					shouldShow := false
+			// Hidden directory filtering using shared filter package
+			if !includeHidden && filter.IsHiddenDir(dirPath, a.HiddenDirs()) {
+				return nil
+			}
 
-					if dirPath != "" && strings.HasPrefix(dirPath, cwd) {
-						shouldShow = true
-					}
+			// Filter by context if not global
+			if !global {
+				shouldShow := false
 
-					if !shouldShow {
-						continue
-					}
+				if dirPath != "" && strings.HasPrefix(dirPath, cwd) {
+					shouldShow = true
+				}
+
+				if !shouldShow {
+					return nil
 				}
-				events = append(events, e)
 			}
+			events = append(events, e)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get events: %w", err)
+		}
+		if len(events) == 0 {
+			fmt.Println("No events found.")
+			return nil
 		}
 
-		// Reverse back and trim
-		// Take last n events
-		start := 0
-		if len(events) > n {
-			start = len(events) - n
+		// Reverse back to chronological order
+		shownEvents := make([]model.WipsEvent, len(events))
+		for i, e := range events {
+			shownEvents[len(events)-1-i] = e
 		}
-		shownEvents := events[start:]
 
 		// Print in chronological order (Oldest -> Newest)
 
@@ -130,19 +132,7 @@ var tailCmd = &cobra.Command{
 			// Build context string for global mode
 			var ctxStr string
 			if global {
-				if e.Ctx.RepoID != nil {
-					if repoData, ok := reposDict[*e.Ctx.RepoID].(map[string]interface{}); ok {
-						if name, ok := repoData["name"].(string); ok {
-							ctxStr = "@" + name
-						}
-					}
-				}
-				if ctxStr == "" && e.Ctx.CwdID != nil {
-					if dirPath, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
-						// Use last path component
-						ctxStr = "📁 " + filepath.Base(dirPath)
-					}
-				}
+				ctxStr = contextName(e, reposDict, dirsDict)
 			}
 
 			if showID {
@@ -164,3 +154,22 @@ var tailCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// contextName returns a short name for where an event was recorded: the
+// repository name, or the last component of its directory.
+func contextName(e model.WipsEvent, reposDict, dirsDict map[string]interface{}) string {
+	if e.Ctx.RepoID != nil {
+		if repoData, ok := reposDict[*e.Ctx.RepoID].(map[string]interface{}); ok {
+			if name, ok := repoData["name"].(string); ok {
+				return "@" + name
+			}
+		}
+	}
+	if e.Ctx.CwdID != nil {
+		if dirPath, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
+			// Use last path component
+			return "📁 " + filepath.Base(dirPath)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(todoCmd)
+	rootCmd.AddCommand(doneCmd)
+	rootCmd.AddCommand(todosCmd)
+	doneCmd.Flags().Bool("reopen", false, "Mark a done task as open again")
+	todosCmd.Flags().BoolP("global", "g", false, "show open tasks of every directory")
+	todosCmd.Flags().Bool("include-hidden", false, "Include hidden directories in output")
+}
+
+var todoCmd = &cobra.Command{
+	Use:   "todo <message>",
+	Short: "Record a task",
+	Long: `Record a task, an event that stays open until it is marked done with 'wip done <id>'.
+Open tasks are listed by 'wip todos'.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current working directory: %w", err)
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		event, err := usecase.NewTaskUsecase(a.Store).RecordTask(args[0], cwd)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			fmt.Println("Ignored by config.")
+			return nil
+		}
+		fmt.Printf("✅ Task recorded: %s (ID: %s)\n", event.Content, event.ID)
+		return nil
+	},
+}
+
+var doneCmd = &cobra.Command{
+	Use:   "done <id>",
+	Short: "Mark a task as done",
+	Long: `Mark a task as done. The ID can be shortened to any unambiguous prefix, like a git hash.
+Use --reopen to mark a done task as open again.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEventID,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		reopen, _ := cmd.Flags().GetBool("reopen")
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		e, err := findEditable(a.Store, j, args[0])
+		if err != nil {
+			return err
+		}
+
+		uc := usecase.NewTaskUsecase(a.Store)
+		if reopen {
+			if err := uc.Reopen(e.ID); err != nil {
+				return err
+			}
+			fmt.Printf("⬜ Task reopened: %s (ID: %s)\n", e.Content, e.ID)
+			return nil
+		}
+		if err := uc.Done(e.ID); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Task done: %s (ID: %s)\n", e.Content, e.ID)
+		return nil
+	},
+}
+
+var todosCmd = &cobra.Command{
+	Use:   "todos",
+	Short: "List open tasks",
+	Long: `List open tasks, oldest first. Inside a git repository the tasks of the
+repository are listed; elsewhere those recorded in the current directory or below.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		global, _ := cmd.Flags().GetBool("global")
+		includeHidden, _ := cmd.Flags().GetBool("include-hidden")
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		tasks, err := usecase.NewTaskUsecase(a.Store).Open(usecase.TaskListOptions{
+			Wd:            cwd,
+			Global:        global,
+			IncludeHidden: includeHidden,
+			HiddenDirs:    a.HiddenDirs(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list tasks: %w", err)
+		}
+		if len(tasks) == 0 {
+			fmt.Println("No open tasks.")
+			return nil
+		}
+
+		dirsDict, err := a.Store.LoadDict("dirs")
+		if err != nil {
+			dirsDict = make(map[string]interface{})
+		}
+		reposDict, err := a.Store.LoadDict("repos")
+		if err != nil {
+			reposDict = make(map[string]interface{})
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, e := range tasks {
+			icon, summary := ui.FormatEventWithStyle(e)
+			if global {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ui.FormatTimeRelative(e.TS), icon, summary, contextName(e, reposDict, dirsDict), e.ID)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ui.FormatTimeRelative(e.TS), icon, summary, e.ID)
+			}
+		}
+		return w.Flush()
+	},
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(restoreCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+	trashPurgeCmd.Flags().String("older-than", "30d", "Only purge events deleted longer ago than this (e.g. 30d, 2w, 12h; 0 for all)")
+}
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted events",
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List events in the trash",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		events, err := usecase.NewTrashUsecase(a.Store).List()
+		if err != nil {
+			return fmt.Errorf("failed to list trash: %w", err)
+		}
+		if len(events) == 0 {
+			fmt.Println("Trash is empty.")
+			return nil
+		}
+
+		j, err := journal.Load(a.Store)
+		if err != nil {
+			return fmt.Errorf("failed to load undo history: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, e := range events {
+			if r, ok := j.ResolveEvent(e); ok {
+				e = r
+			}
+			deleted := "deleted " + ui.FormatTimeRelative(e.Trashed().At)
+			icon, summary := ui.FormatEventWithStyle(e)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", deleted, icon, summary, e.ID)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently delete events from the trash",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		n, err := usecase.NewTrashUsecase(a.Store).Purge(time.Now().Add(-age))
+		if err != nil {
+			return fmt.Errorf("failed to purge trash: %w", err)
+		}
+
+		fmt.Printf("✅ Permanently deleted %d events.\n", n)
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Restore an event from the trash",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		e, err := a.Store.GetEvent(args[0])
+		if err != nil {
+			return err
+		}
+		if err := usecase.NewTrashUsecase(a.Store).Restore(e.ID); err != nil {
+			return fmt.Errorf("failed to restore event %s: %w", e.ID, err)
+		}
+
+		fmt.Printf("✅ Event %s restored.\n", e.ID)
+		return nil
+	},
+}
+
+// parseAge parses a duration that may also be given in days (30d) or weeks (2w).
+func parseAge(s string) (time.Duration, error) {
+	if s == "0" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rynskrmt/wips-cli/internal/app"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last note, edit or delete",
+	Long: `Undo the most recent note, edit or delete.
+
+Nothing is removed from the log: an undo event is recorded instead, and
+summary, tail and search hide what it reverts. Run undo repeatedly to go
+further back, and redo to revert an undo.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		event, err := usecase.NewUndoUsecase(a.Store).Undo()
+		if errors.Is(err, usecase.ErrNothingToUndo) {
+			fmt.Println("Nothing to undo.")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to undo: %w", err)
+		}
+
+		fmt.Printf("↩️  %s\n", event.Content)
+		return nil
+	},
+}
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Redo the last undone action",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := app.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize app: %w", err)
+		}
+
+		event, err := usecase.NewUndoUsecase(a.Store).Redo()
+		if errors.Is(err, usecase.ErrNothingToRedo) {
+			fmt.Println("Nothing to redo.")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to redo: %w", err)
+		}
+
+		fmt.Printf("↪️  %s\n", event.Content)
+		return nil
+	},
+}
@@ -20,7 +20,7 @@ type App struct {
 // New creates a new App instance with initialized dependencies.
 // It performs the following steps:
 // 1. Loads the configuration.
-// 2. Initializes the data store (using WIPS_HOME env var if set, otherwise defaults).
+// 2. Initializes the data store (using WIPS_HOME env var if set, otherwise defaults; backend from config).
 // 3. Prepares the store (creates necessary directories).
 //
 // Returns an error if any initialization step fails.
@@ -30,7 +30,7 @@ func New() (*App, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	s, err := store.NewStore(os.Getenv("WIPS_HOME"))
+	s, err := store.Open(os.Getenv("WIPS_HOME"), cfg.Store.Backend)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init store: %w", err)
 	}
@@ -4,15 +4,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 	"github.com/rynskrmt/wips-cli/internal/filter"
 )
 
 type Config struct {
-	IgnorePatterns    []string   `toml:"ignore_patterns"`
-	HiddenDirectories []string   `toml:"hidden_directories"`
-	Sync              SyncConfig `toml:"sync"`
+	IgnorePatterns    []string    `toml:"ignore_patterns"`
+	HiddenDirectories []string    `toml:"hidden_directories"`
+	Store             StoreConfig `toml:"store"`
+	Sync              SyncConfig  `toml:"sync"`
+	Shell             ShellConfig `toml:"shell"`
+}
+
+type StoreConfig struct {
+	Backend string `toml:"backend"` // "files" (default) or "sqlite"
 }
 
 type SyncConfig struct {
@@ -27,6 +34,22 @@ type ObsidianConfig struct {
 	SectionHeader       string `toml:"section_header"`
 	AppendAt            string `toml:"append_at"` // "top" or "bottom"
 	SummaryFormat       string `toml:"summary_format"`
+	GroupBy             string `toml:"group_by"` // "dir" (default) or "tag"
+}
+
+type ShellConfig struct {
+	CommandThreshold int `toml:"command_threshold"` // Seconds a command must run to be recorded (default 10)
+}
+
+// DefaultCommandThreshold is the command threshold used when none is configured.
+const DefaultCommandThreshold = 10 * time.Second
+
+// Threshold returns how long a command must run for the shell integration to record it.
+func (c ShellConfig) Threshold() time.Duration {
+	if c.CommandThreshold <= 0 {
+		return DefaultCommandThreshold
+	}
+	return time.Duration(c.CommandThreshold) * time.Second
 }
 
 // GetConfigPath returns the path to the config file.
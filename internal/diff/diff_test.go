@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+// render formats a diff compactly, one prefixed line per entry.
+func render(lines []Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Op {
+		case Equal:
+			b.WriteString(" ")
+		case Delete:
+			b.WriteString("-")
+		case Insert:
+			b.WriteString("+")
+		}
+		b.WriteString(l.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"identical", "a\nb", "a\nb", " a\n b\n"},
+		{"from empty", "", "a", "+a\n"},
+		{"to empty", "a\nb", "", "-a\n-b\n"},
+		{"replace line", "a\nb\nc", "a\nB\nc", " a\n-b\n+B\n c\n"},
+		{"insert line", "a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"delete line", "a\nb\nc", "a\nc", " a\n-b\n c\n"},
+		{"trailing newline ignored", "a\n", "a", " a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render(Lines(tt.a, tt.b)); got != tt.want {
+				t.Errorf("Lines(%q, %q) =\n%s\nwant\n%s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
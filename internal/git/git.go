@@ -1,7 +1,10 @@
 package git
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -9,36 +12,106 @@ import (
 type Info struct {
 	Root   string
 	Remote string
+
+	// Worktree is the root of the linked worktree containing the directory,
+	// empty in the main working tree. It is not part of the repository's
+	// entry in the repos dictionary.
+	Worktree string `json:"-"`
 }
 
-// GetInfo returns the repository root path and remote URL.
-// It returns an empty Info if the current directory is not inside a git repository.
-func GetInfo() (Info, error) {
-	// Check if in git repo and get root path
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	out, err := cmd.Output()
+// Layout describes where the parts of a repository are on disk.
+// It covers linked worktrees and submodules, where .git is a file, and bare repositories.
+type Layout struct {
+	Toplevel  string // Working tree containing the directory; empty in a bare repository
+	CommonDir string // Git directory shared by all worktrees
+	HooksDir  string // Directory git runs hooks from, following core.hooksPath
+}
+
+// MainWorktree returns the root of the repository's main working tree,
+// which is shared by all its linked worktrees.
+func (l Layout) MainWorktree() string {
+	if filepath.Base(l.CommonDir) == ".git" {
+		return filepath.Dir(l.CommonDir)
+	}
+	// Submodules keep their git directory in the superproject
+	return l.Toplevel
+}
+
+// GetLayout returns the layout of the repository containing dir, or the current directory if dir is empty.
+func GetLayout(dir string) (Layout, error) {
+	base := dir
+	if base == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return Layout{}, err
+		}
+		base = wd
+	}
+
+	out, err := exec.Command("git", "-C", base, "rev-parse", "--is-bare-repository", "--git-common-dir", "--git-path", "hooks").Output()
 	if err != nil {
-		// Not in a git repo or git not installed
+		return Layout{}, fmt.Errorf("not a git repository: %s", base)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 3 {
+		return Layout{}, fmt.Errorf("unexpected output from git rev-parse: %q", out)
+	}
+
+	// Paths are relative to the directory git ran in unless absolute.
+	// Symlinks are resolved like in --show-toplevel, so that the paths compare.
+	abs := func(path string) string {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			return real
+		}
+		return filepath.Clean(path)
+	}
+	layout := Layout{CommonDir: abs(lines[1]), HooksDir: abs(lines[2])}
+
+	if lines[0] != "true" {
+		out, err := exec.Command("git", "-C", base, "rev-parse", "--show-toplevel").Output()
+		if err == nil {
+			layout.Toplevel = strings.TrimSpace(string(out))
+		}
+	}
+	return layout, nil
+}
+
+// GetInfo returns the repository root path and remote URL.
+// In a linked worktree, Root is the main working tree, so that all worktrees
+// of a repository share it, and Worktree is the linked worktree.
+// It returns an empty Info if dir (or the current directory if empty) is not inside a git working tree.
+func GetInfo(dir string) (Info, error) {
+	layout, err := GetLayout(dir)
+	if err != nil || layout.Toplevel == "" {
+		// Not in a git repo, git not installed, or a bare repository
 		return Info{}, nil
 	}
-	root := strings.TrimSpace(string(out))
+	root := layout.MainWorktree()
 
 	// Get remote URL (origin)
 	// Ignore error if no remote
-	cmdRemote := exec.Command("git", "remote", "get-url", "origin")
+	cmdRemote := command(dir, "remote", "get-url", "origin")
 	outRemote, _ := cmdRemote.Output()
 	remote := strings.TrimSpace(string(outRemote))
 
-	return Info{
+	info := Info{
 		Root:   root,
 		Remote: remote,
-	}, nil
+	}
+	if layout.Toplevel != root {
+		info.Worktree = layout.Toplevel
+	}
+	return info, nil
 }
 
-// GetHead returns the current branch name and HEAD commit hash.
-func GetHead() (string, string, error) {
+// GetHead returns the current branch name and HEAD commit hash of the repository containing dir,
+// or the current directory if empty.
+func GetHead(dir string) (string, string, error) {
 	// Get branch name
-	cmdBranch := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmdBranch := command(dir, "rev-parse", "--abbrev-ref", "HEAD")
 	outBranch, err := cmdBranch.Output()
 	if err != nil {
 		return "", "", err
@@ -46,7 +119,7 @@ func GetHead() (string, string, error) {
 	branch := strings.TrimSpace(string(outBranch))
 
 	// Get HEAD hash (short)
-	cmdHash := exec.Command("git", "rev-parse", "--short", "HEAD")
+	cmdHash := command(dir, "rev-parse", "--short", "HEAD")
 	outHash, err := cmdHash.Output()
 	if err != nil {
 		return "", "", err
@@ -55,3 +128,11 @@ func GetHead() (string, string, error) {
 
 	return branch, hash, nil
 }
+
+// command returns a git command run in dir, or the current directory if empty.
+func command(dir string, args ...string) *exec.Cmd {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	return exec.Command("git", args...)
+}
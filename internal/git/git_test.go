@@ -0,0 +1,81 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=wip", "GIT_AUTHOR_EMAIL=wip@example.com", "GIT_COMMITTER_NAME=wip", "GIT_COMMITTER_EMAIL=wip@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestGetLayout(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	main := filepath.Join(tmp, "main")
+	if err := os.MkdirAll(filepath.Join(main, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	run(t, main, "init", "-q")
+	run(t, main, "commit", "-q", "--allow-empty", "-m", "init")
+	run(t, main, "worktree", "add", "-q", filepath.Join(tmp, "wt"))
+	run(t, tmp, "init", "-q", "--bare", "bare.git")
+
+	gitDir := filepath.Join(main, ".git")
+	tests := []struct {
+		name         string
+		dir          string
+		want         Layout
+		wantWorktree string // MainWorktree()
+	}{
+		{"Main worktree", main, Layout{main, gitDir, filepath.Join(gitDir, "hooks")}, main},
+		{"Subdirectory", filepath.Join(main, "sub"), Layout{main, gitDir, filepath.Join(gitDir, "hooks")}, main},
+		{"Linked worktree", filepath.Join(tmp, "wt"), Layout{filepath.Join(tmp, "wt"), gitDir, filepath.Join(gitDir, "hooks")}, main},
+		{"Bare", filepath.Join(tmp, "bare.git"), Layout{"", filepath.Join(tmp, "bare.git"), filepath.Join(tmp, "bare.git", "hooks")}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetLayout(tt.dir)
+			if err != nil {
+				t.Fatalf("GetLayout() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetLayout() = %+v, want %+v", got, tt.want)
+			}
+			if wt := got.MainWorktree(); wt != tt.wantWorktree {
+				t.Errorf("MainWorktree() = %q, want %q", wt, tt.wantWorktree)
+			}
+		})
+	}
+
+	if _, err := GetLayout(tmp); err == nil {
+		t.Errorf("GetLayout() outside a repository should fail")
+	}
+
+	// Repository information is read from the given directory, not the current one
+	wt := filepath.Join(tmp, "wt")
+	if info, _ := GetInfo(wt); info.Root != main || info.Worktree != wt {
+		t.Errorf("GetInfo() = %+v, want root %q in worktree %q", info, main, wt)
+	}
+	if info, _ := GetInfo(main); info.Worktree != "" {
+		t.Errorf("GetInfo() in the main working tree = %+v, want no worktree", info)
+	}
+	if info, _ := GetInfo(tmp); info.Root != "" {
+		t.Errorf("GetInfo() outside a repository = %+v, want empty", info)
+	}
+}
@@ -0,0 +1,213 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitHooks are the hooks the global hooks directory forwards to the
+// repository's own hook. Only client-side hooks that git merely runs are
+// forwarded: server-side hooks, hooks whose presence alone changes what git
+// does (push-to-checkout, proc-receive, fsmonitor-watchman), and hooks run on
+// every ref or index update (reference-transaction, post-index-change) are not.
+var gitHooks = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch",
+	"pre-commit", "pre-merge-commit", "prepare-commit-msg", "commit-msg", "post-commit",
+	"pre-rebase", "post-checkout", "post-merge", "pre-push", "post-rewrite",
+	"pre-auto-gc", "sendemail-validate",
+}
+
+// previousFile records the core.hooksPath that was set before InstallGlobal.
+const previousFile = ".previous-hooks-path"
+
+// repoHook is the shell expression for a hook in the repository's own hooks directory.
+// It ignores core.hooksPath, which points at the global directory.
+func repoHook(name string) string {
+	return `"$(git rev-parse --git-common-dir 2>/dev/null)/hooks/` + name + `"`
+}
+
+// globalScript returns the hook script written to the global hooks directory.
+// It runs the repository's own hook and, for hooks wip manages, records the
+// event unless the repository's hook already does.
+func globalScript(name string) string {
+	var h *Hook
+	for i := range Managed {
+		if Managed[i].Name == name {
+			h = &Managed[i]
+		}
+	}
+
+	if h == nil {
+		return shebang + BeginMarker + `
+# Managed by wip; remove with 'wip hooks uninstall --global'.
+hook=` + repoHook(name) + `
+if [ -x "$hook" ]; then
+  exec "$hook" "$@"
+fi
+` + EndMarker + "\n"
+	}
+
+	return shebang + BeginMarker + `
+# Managed by wip; remove with 'wip hooks uninstall --global'.
+hook=` + repoHook(name) + "\n" + h.runFirst(`"$hook"`, true) + EndMarker + "\n"
+}
+
+// globalHooksPath returns the global core.hooksPath, and whether it is set.
+func globalHooksPath() (string, bool, error) {
+	out, err := exec.Command("git", "config", "--global", "--get", "core.hooksPath").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", false, nil // Not set
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(out)), true, nil
+}
+
+func setGlobalHooksPath(path string) error {
+	return exec.Command("git", "config", "--global", "core.hooksPath", path).Run()
+}
+
+func unsetGlobalHooksPath() error {
+	return exec.Command("git", "config", "--global", "--unset", "core.hooksPath").Run()
+}
+
+// InstallGlobal writes hooks forwarding to each repository's own hooks into dir
+// and points the global core.hooksPath at it. The previous core.hooksPath is
+// returned, and kept to be restored by UninstallGlobal.
+func InstallGlobal(dir string) (string, error) {
+	current, set, err := globalHooksPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	previous := ""
+	if set && current != dir {
+		previous = current
+		if err := os.WriteFile(filepath.Join(dir, previousFile), []byte(current+"\n"), 0644); err != nil {
+			return "", err
+		}
+	}
+
+	// Hooks forwarded by earlier versions would still be run by git
+	if _, err := removeScripts(dir); err != nil {
+		return "", err
+	}
+	for _, name := range gitHooks {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(globalScript(name)), 0755); err != nil {
+			return "", err
+		}
+		if err := os.Chmod(path, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	if current != dir {
+		if err := setGlobalHooksPath(dir); err != nil {
+			return "", err
+		}
+	}
+	return previous, nil
+}
+
+// UninstallGlobal removes the hooks written by InstallGlobal and restores the
+// previous global core.hooksPath, which is returned ("" if it was unset).
+// It reports false if the global hooks were not installed.
+func UninstallGlobal(dir string) (string, bool, error) {
+	current, set, err := globalHooksPath()
+	if err != nil {
+		return "", false, err
+	}
+
+	removed, err := removeScripts(dir)
+	if err != nil {
+		return "", false, err
+	}
+
+	previous := ""
+	data, err := os.ReadFile(filepath.Join(dir, previousFile))
+	if err == nil {
+		previous = strings.TrimSpace(string(data))
+		if err := os.Remove(filepath.Join(dir, previousFile)); err != nil {
+			return "", false, err
+		}
+	}
+	os.Remove(dir) // Only if nothing else is left
+
+	if !set || current != dir {
+		return previous, removed, nil
+	}
+	if previous != "" {
+		err = setGlobalHooksPath(previous)
+	} else {
+		err = unsetGlobalHooksPath()
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return previous, true, nil
+}
+
+// removeScripts removes the hook scripts written by wip in dir, and reports whether there were any.
+func removeScripts(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	removed := false
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil || !hasBlock(string(data)) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = true
+	}
+	return removed, nil
+}
+
+// InspectGlobal reports the state of the managed hooks in the global hooks directory.
+func InspectGlobal(dir string) []Status {
+	current, _, _ := globalHooksPath()
+
+	statuses := make([]Status, 0, len(Managed))
+	for _, h := range Managed {
+		path := filepath.Join(dir, h.Name)
+		st := Status{Hook: h, Path: path, State: StateMissing}
+
+		data, err := os.ReadFile(path)
+		switch {
+		case err != nil:
+		case current != dir:
+			st.State, st.Detail = StateStale, "core.hooksPath is "+quoteOrUnset(current)
+		case string(data) != globalScript(h.Name):
+			st.State, st.Detail = StateStale, "outdated wip block"
+		default:
+			st.State = StateInstalled
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+func quoteOrUnset(s string) string {
+	if s == "" {
+		return "unset"
+	}
+	return `"` + s + `"`
+}
@@ -0,0 +1,286 @@
+// Package hooks installs, removes and inspects the git hooks that record events in wip.
+//
+// wip only owns a marked block inside each hook script (or hook manager config),
+// so that the rest of the file is left alone and the block can be found again
+// to update or remove it. Hooks managed by husky or lefthook are installed
+// through their own configuration so that those tools keep working.
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Markers delimiting the wip-managed block in a hook script.
+const (
+	BeginMarker = "# >>> wip >>>"
+	EndMarker   = "# <<< wip <<<"
+)
+
+const shebang = "#!/bin/sh\n"
+
+// LocalSuffix is appended to a hook that wip moved aside to chain to it.
+const LocalSuffix = ".local"
+
+// legacyScript is the whole-file hook written by earlier versions, before blocks were marked.
+const legacyScript = `#!/bin/sh
+# wip hook: fail silently if wip is not found or fails
+if command -v wip >/dev/null 2>&1; then
+  wip capture git-commit || true
+fi
+exit 0
+`
+
+// Hook is a git hook that wip installs.
+type Hook struct {
+	Name    string // Hook file name, e.g. "post-commit"
+	Capture string // Event passed to 'wip capture'
+	Stdin   bool   // Git writes input to the hook, which wip and the rest of the hook both read
+	Gate    bool   // The hook can abort the git command, so the event is only recorded if it passes
+}
+
+// Managed lists the hooks installed by wip.
+var Managed = []Hook{
+	{Name: "post-commit", Capture: "git-commit"},
+	{Name: "post-checkout", Capture: "git-checkout"},
+	{Name: "post-merge", Capture: "git-merge"},
+	{Name: "post-rewrite", Capture: "git-rewrite", Stdin: true},
+	{Name: "pre-push", Capture: "git-push", Stdin: true, Gate: true},
+}
+
+// command is the shell command recording the hook's event, given the hook's arguments.
+func (h Hook) command() string {
+	return "wip capture " + h.Capture + ` "$@"`
+}
+
+// Block returns the marked block wip inserts into a hook script.
+// It runs before the rest of the script, which still gets the hook's input,
+// except in gated hooks, where it goes at the end (see installScripts).
+func (h Hook) Block() string {
+	header := BeginMarker + `
+# Managed by wip; remove with 'wip hooks uninstall'. Fails silently if wip is missing.
+`
+	if !h.Stdin {
+		return header + `if command -v wip >/dev/null 2>&1; then
+  ` + h.command() + ` || true
+fi
+` + EndMarker + "\n"
+	}
+	return header + `if command -v wip >/dev/null 2>&1 && wip_input=$(mktemp 2>/dev/null); then
+  cat >"$wip_input"
+  ` + h.command() + ` <"$wip_input" || true
+  # The rest of the hook reads the same input
+  exec <"$wip_input"
+  rm -f "$wip_input"
+fi
+` + EndMarker + "\n"
+}
+
+// chainBlock returns the block of a hook script written by wip in place of a
+// hook it moved to <name>.local. The original hook runs first and its exit
+// status is kept.
+func (h Hook) chainBlock() string {
+	return BeginMarker + `
+# Managed by wip; remove with 'wip hooks uninstall', which restores ` + h.Name + LocalSuffix + `.
+` + h.runFirst(`"$0`+LocalSuffix+`"`, false) + EndMarker + "\n"
+}
+
+// runFirst returns the shell code running hook, an expression for the path
+// of another hook script, before recording the event, and exiting with the
+// hook's status. With skipIfRecorded, no event is recorded when that hook
+// records it itself.
+func (h Hook) runFirst(hook string, skipIfRecorded bool) string {
+	input, cleanup := "", ""
+	if h.Stdin {
+		// Both the hook and wip read the input. Without a temporary file, only the hook gets it.
+		input = ` <"${wip_input:-/dev/stdin}"`
+		cleanup = `[ -z "$wip_input" ] || rm -f "$wip_input"` + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("status=0\n")
+	if h.Stdin {
+		b.WriteString(`wip_input=$(mktemp 2>/dev/null) && cat >"$wip_input" || wip_input=` + "\n")
+	}
+	b.WriteString("if [ -x " + hook + " ]; then\n")
+	b.WriteString("  " + hook + ` "$@"` + input + " || status=$?\n")
+	if skipIfRecorded {
+		b.WriteString("  # The hook records the event itself\n")
+		b.WriteString("  if grep -qF 'wip capture " + h.Capture + "' " + hook + "; then\n")
+		if cleanup != "" {
+			b.WriteString("    " + cleanup)
+		}
+		b.WriteString("    exit $status\n")
+		b.WriteString("  fi\n")
+	}
+	b.WriteString("fi\n")
+
+	cond := "command -v wip >/dev/null 2>&1"
+	if h.Gate {
+		cond = "[ $status -eq 0 ] && " + cond
+	}
+	b.WriteString("if " + cond + "; then\n")
+	b.WriteString("  " + h.command() + input + " || true\n")
+	b.WriteString("fi\n")
+	b.WriteString(cleanup)
+	b.WriteString("exit $status\n")
+	return b.String()
+}
+
+// HooksDir returns the default hooks directory of the repository at root.
+// Use git.GetLayout to find the one git actually runs.
+func HooksDir(root string) string {
+	return filepath.Join(root, ".git", "hooks")
+}
+
+// Manager is the tool running the hooks of a repository.
+type Manager string
+
+const (
+	ManagerGit      Manager = "git"      // Plain scripts in the hooks directory
+	ManagerHusky    Manager = "husky"    // Scripts in .husky/
+	ManagerLefthook Manager = "lefthook" // Commands in lefthook's local config
+)
+
+// Repo is a repository to install hooks into.
+type Repo struct {
+	Root     string // Working tree; empty in a bare repository
+	HooksDir string // Directory git runs hooks from
+	Manager  Manager
+	// Config is the file or directory holding the hooks:
+	// the hooks directory, .husky/ or lefthook's local config file.
+	Config string
+}
+
+// Open detects how the hooks of the repository with the working tree root
+// and the hooks directory hooksDir are managed.
+func Open(root, hooksDir string) Repo {
+	r := Repo{Root: root, HooksDir: hooksDir, Manager: ManagerGit}
+	r.Config = r.HooksDir
+	if root == "" {
+		return r
+	}
+
+	if config := lefthookConfig(root); config != "" {
+		r.Manager = ManagerLefthook
+		r.Config = lefthookLocalConfig(config)
+	} else if fi, err := os.Stat(filepath.Join(root, ".husky")); err == nil && fi.IsDir() {
+		r.Manager = ManagerHusky
+		r.Config = filepath.Join(root, ".husky")
+	}
+	return r
+}
+
+// State describes how a hook is installed.
+type State string
+
+const (
+	StateMissing   State = "missing"   // No wip block in the hook
+	StateInstalled State = "installed" // The current block, where it will run
+	StateStale     State = "stale"     // A wip block that would not run as installed today
+)
+
+// Status is the state of one hook in a repository.
+type Status struct {
+	Hook   Hook
+	Path   string
+	State  State
+	Detail string // Why a hook is stale
+}
+
+// ConflictError is returned by Install when a hook cannot be chained safely.
+type ConflictError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// Install writes or updates the wip block of every managed hook and returns the paths written.
+// Existing hooks are kept: wip's block is inserted into shell scripts, and other
+// hooks are renamed to <name>.local and run from a wip script.
+func (r Repo) Install() ([]string, error) {
+	switch r.Manager {
+	case ManagerLefthook:
+		return installLefthook(r.Config)
+	case ManagerHusky:
+		return installScripts(r.Config, false)
+	default:
+		return installScripts(r.Config, true)
+	}
+}
+
+// Uninstall removes the wip block of every managed hook and returns the paths changed.
+// Files left with nothing else are deleted, and hooks moved aside by Install are put back.
+func (r Repo) Uninstall() ([]string, error) {
+	if r.Manager == ManagerLefthook {
+		return uninstallLefthook(r.Config)
+	}
+	return uninstallScripts(r.Config)
+}
+
+// Inspect reports the state of every managed hook.
+func (r Repo) Inspect() []Status {
+	statuses := make([]Status, 0, len(Managed))
+	for _, h := range Managed {
+		switch r.Manager {
+		case ManagerLefthook:
+			statuses = append(statuses, inspectLefthook(r.Config, h))
+		case ManagerHusky:
+			statuses = append(statuses, inspectScript(r.Config, h, false))
+		default:
+			statuses = append(statuses, inspectScript(r.Config, h, true))
+		}
+	}
+	return statuses
+}
+
+// findBlock returns the marked block in content, markers included.
+func findBlock(content string) (string, bool) {
+	start, end, ok := blockBounds(content)
+	if !ok {
+		return "", false
+	}
+	return content[start:end], true
+}
+
+// blockBounds locates the marked block, including the newline after the end marker.
+func blockBounds(content string) (int, int, bool) {
+	start := strings.Index(content, BeginMarker)
+	if start < 0 {
+		return 0, 0, false
+	}
+	n := strings.Index(content[start:], EndMarker)
+	if n < 0 {
+		return 0, 0, false
+	}
+	end := start + n + len(EndMarker)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return start, end, true
+}
+
+// replaceBlock swaps the marked block in content for block.
+func replaceBlock(content, block string) string {
+	start, end, ok := blockBounds(content)
+	if !ok {
+		return content
+	}
+	return content[:start] + block + content[end:]
+}
+
+// isEmptyScript reports whether a file has nothing left but comments and blank lines.
+func isEmptyScript(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,464 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a repository root with an empty hooks directory.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	root, err := os.MkdirTemp("", "wips_test_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(HooksDir(root), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestInstallUninstall(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  string // Empty for no hook script
+		wantLocal bool   // Whether the existing hook is moved to post-commit.local
+		wantAfter string // Script left after uninstall, empty if removed
+	}{
+		{name: "No hook"},
+		{name: "Legacy hook", existing: legacyScript},
+		{name: "Shell hook", existing: "#!/bin/sh\nmake lint\n", wantAfter: "#!/bin/sh\nmake lint\n"},
+		{name: "Bash hook via env", existing: "#!/usr/bin/env bash\nmake lint\n", wantAfter: "#!/usr/bin/env bash\nmake lint\n"},
+		{name: "Python hook is chained", existing: "#!/usr/bin/env python3\nprint('hi')\n", wantLocal: true, wantAfter: "#!/usr/bin/env python3\nprint('hi')\n"},
+		{
+			name:      "Outdated block is updated in place",
+			existing:  "#!/bin/sh\nmake lint\n" + BeginMarker + "\nwip old\n" + EndMarker + "\necho done\n",
+			wantAfter: "#!/bin/sh\nmake lint\necho done\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRepo(t)
+			defer os.RemoveAll(root)
+			repo := Open(root, HooksDir(root))
+
+			path := filepath.Join(repo.HooksDir, "post-commit")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Installing twice must not add a second block
+			for i := 0; i < 2; i++ {
+				if _, err := repo.Install(); err != nil {
+					t.Fatalf("Install() error = %v", err)
+				}
+			}
+			if got := repo.Inspect()[0].State; got != StateInstalled {
+				t.Errorf("state after Install() = %s, want installed", got)
+			}
+			data, _ := os.ReadFile(path)
+			if n := strings.Count(string(data), BeginMarker); n != 1 {
+				t.Errorf("hook has %d wip blocks, want 1", n)
+			}
+			if _, err := os.Stat(path + LocalSuffix); (err == nil) != tt.wantLocal {
+				t.Errorf("%s exists = %v, want %v", LocalSuffix, err == nil, tt.wantLocal)
+			}
+
+			if _, err := repo.Uninstall(); err != nil {
+				t.Fatalf("Uninstall() error = %v", err)
+			}
+			if _, err := os.Stat(path + LocalSuffix); err == nil {
+				t.Errorf("%s should be restored", LocalSuffix)
+			}
+			data, err := os.ReadFile(path)
+			if tt.wantAfter == "" {
+				if !os.IsNotExist(err) {
+					t.Errorf("hook should be removed, got %q", data)
+				}
+				return
+			}
+			if string(data) != tt.wantAfter {
+				t.Errorf("hook after Uninstall() = %q, want %q", data, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestInstall_LocalConflict(t *testing.T) {
+	root := newTestRepo(t)
+	defer os.RemoveAll(root)
+	repo := Open(root, HooksDir(root))
+
+	path := filepath.Join(repo.HooksDir, "post-commit")
+	if err := os.WriteFile(path, []byte("#!/usr/bin/env python3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+LocalSuffix, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := repo.Install()
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Install() error = %v, want ConflictError", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "#!/usr/bin/env python3\n" {
+		t.Errorf("hook was changed: %q", data)
+	}
+}
+
+func TestInstall_GatedHook(t *testing.T) {
+	const existing = "#!/bin/sh\nmake test\n"
+	pushHook := Managed[len(Managed)-1]
+
+	tests := []struct {
+		name      string
+		husky     bool
+		script    string
+		wantLocal bool
+	}{
+		{name: "Shell hook is chained", script: existing, wantLocal: true},
+		{name: "Block inserted before the hook is chained", script: insertBlock(existing, pushHook.Block()), wantLocal: true},
+		{name: "Husky hook gets the block at the end", husky: true, script: existing},
+		{name: "Husky block inserted before the hook is moved", husky: true, script: insertBlock(existing, pushHook.Block())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRepo(t)
+			defer os.RemoveAll(root)
+			dir := HooksDir(root)
+			if tt.husky {
+				dir = filepath.Join(root, ".husky")
+				if err := os.Mkdir(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			path := filepath.Join(dir, pushHook.Name)
+			if err := os.WriteFile(path, []byte(tt.script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			repo := Open(root, HooksDir(root))
+
+			if _, err := repo.Install(); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if got := repo.Inspect()[len(Managed)-1].State; got != StateInstalled {
+				t.Errorf("state after Install() = %s, want installed", got)
+			}
+			data, _ := os.ReadFile(path)
+			local, err := os.ReadFile(path + LocalSuffix)
+			if tt.wantLocal {
+				if err != nil || string(local) != existing {
+					t.Errorf("%s = %q, %v, want %q", LocalSuffix, local, err, existing)
+				}
+				if string(data) != shebang+pushHook.chainBlock() {
+					t.Errorf("hook = %q, want the chain block", data)
+				}
+			} else if string(data) != existing+pushHook.Block() {
+				t.Errorf("hook = %q, want the block at the end", data)
+			}
+
+			if _, err := repo.Uninstall(); err != nil {
+				t.Fatalf("Uninstall() error = %v", err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != existing {
+				t.Errorf("hook after Uninstall() = %q, want %q", data, existing)
+			}
+		})
+	}
+}
+
+func TestInspect_Stale(t *testing.T) {
+	commit, push := Managed[0], Managed[len(Managed)-1]
+
+	tests := []struct {
+		name   string
+		hook   Hook
+		script string
+		mode   os.FileMode
+		detail string
+	}{
+		{"Legacy", commit, legacyScript, 0755, "older version"},
+		{"Outdated", commit, "#!/bin/sh\n" + BeginMarker + "\nwip capture something-else\n" + EndMarker + "\n", 0755, "outdated"},
+		{"Not executable", commit, "#!/bin/sh\n" + commit.Block(), 0644, "not executable"},
+		{"Chained hook missing", commit, "#!/bin/sh\n" + commit.chainBlock(), 0755, "post-commit.local is missing"},
+		{"Gated hook records first", push, insertBlock("#!/bin/sh\nmake test\n", push.Block()), 0755, "before the rest of the hook"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRepo(t)
+			defer os.RemoveAll(root)
+
+			if err := os.WriteFile(filepath.Join(HooksDir(root), tt.hook.Name), []byte(tt.script), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			st := inspectScript(HooksDir(root), tt.hook, true)
+			if st.State != StateStale || !strings.Contains(st.Detail, tt.detail) {
+				t.Errorf("Inspect() = %s (%s), want stale (%s)", st.State, st.Detail, tt.detail)
+			}
+		})
+	}
+}
+
+func TestOpen_HookManagers(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantManager Manager
+		wantConfig  string // Relative to the root
+		wantErr     bool
+		wantAfter   map[string]string // Files left after uninstall; "" means removed
+	}{
+		{
+			name:        "husky",
+			files:       map[string]string{".husky/post-commit": "npx lint-staged\n"},
+			wantManager: ManagerHusky,
+			wantConfig:  ".husky",
+			wantAfter:   map[string]string{".husky/post-commit": "npx lint-staged\n"},
+		},
+		{
+			name:        "lefthook",
+			files:       map[string]string{"lefthook.yml": "pre-commit:\n", ".husky/pre-commit": ""},
+			wantManager: ManagerLefthook,
+			wantConfig:  "lefthook-local.yml",
+			wantAfter:   map[string]string{"lefthook-local.yml": ""},
+		},
+		{
+			name:        "lefthook with local config",
+			files:       map[string]string{".lefthook.yml": "", ".lefthook-local.yml": "pre-commit:\n  commands: {}\n"},
+			wantManager: ManagerLefthook,
+			wantConfig:  ".lefthook-local.yml",
+			wantAfter:   map[string]string{".lefthook-local.yml": "pre-commit:\n  commands: {}\n"},
+		},
+		{
+			name:        "lefthook hook already configured",
+			files:       map[string]string{"lefthook.yml": "", "lefthook-local.yml": "post-commit:\n  commands: {}\n"},
+			wantManager: ManagerLefthook,
+			wantConfig:  "lefthook-local.yml",
+			wantErr:     true,
+			wantAfter:   map[string]string{"lefthook-local.yml": "post-commit:\n  commands: {}\n"},
+		},
+		{
+			name:        "lefthook pre-push piped",
+			files:       map[string]string{"lefthook.yml": "pre-push:\n  piped: true\n  commands:\n    test:\n      run: make test\n"},
+			wantManager: ManagerLefthook,
+			wantConfig:  "lefthook-local.yml",
+			wantAfter:   map[string]string{"lefthook-local.yml": ""},
+		},
+		{
+			name:        "lefthook pre-push not piped",
+			files:       map[string]string{"lefthook.yml": "pre-push:\n  commands:\n    test:\n      run: make test\npre-commit:\n  piped: true\n"},
+			wantManager: ManagerLefthook,
+			wantConfig:  "lefthook-local.yml",
+			wantErr:     true,
+			wantAfter:   map[string]string{"lefthook-local.yml": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newTestRepo(t)
+			defer os.RemoveAll(root)
+			for name, content := range tt.files {
+				path := filepath.Join(root, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			repo := Open(root, HooksDir(root))
+			if repo.Manager != tt.wantManager || repo.Config != filepath.Join(root, tt.wantConfig) {
+				t.Fatalf("Open() = %s %s, want %s %s", repo.Manager, repo.Config, tt.wantManager, tt.wantConfig)
+			}
+
+			_, err := repo.Install()
+			var conflict *ConflictError
+			if tt.wantErr != errors.As(err, &conflict) {
+				t.Fatalf("Install() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if got := repo.Inspect()[0].State; got != StateInstalled {
+					t.Errorf("state after Install() = %s, want installed", got)
+				}
+			}
+			// Git's own hooks are left to the hook manager
+			if _, err := os.Stat(filepath.Join(repo.HooksDir, "post-commit")); err == nil {
+				t.Errorf("hook written to %s", repo.HooksDir)
+			}
+
+			if _, err := repo.Uninstall(); err != nil {
+				t.Fatalf("Uninstall() error = %v", err)
+			}
+			for name, want := range tt.wantAfter {
+				data, err := os.ReadFile(filepath.Join(root, name))
+				if want == "" {
+					if !os.IsNotExist(err) {
+						t.Errorf("%s should be removed, got %q", name, data)
+					}
+					continue
+				}
+				if string(data) != want {
+					t.Errorf("%s after Uninstall() = %q, want %q", name, data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInstallUninstallGlobal(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous string // Empty if core.hooksPath is unset
+	}{
+		{name: "Unset"},
+		{name: "Previous value restored", previous: "/opt/team-hooks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, err := os.MkdirTemp("", "wips_test_hooks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(home)
+			t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+			if tt.previous != "" {
+				if err := setGlobalHooksPath(tt.previous); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dir := filepath.Join(home, ".wip", "hooks")
+
+			// Forwarded by an earlier version, its mere presence changes what git does
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			stale := filepath.Join(dir, "push-to-checkout")
+			if err := os.WriteFile(stale, []byte(globalScript("push-to-checkout")), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			// Installing twice must keep the original previous value
+			for i := 0; i < 2; i++ {
+				previous, err := InstallGlobal(dir)
+				if err != nil {
+					t.Fatalf("InstallGlobal() error = %v", err)
+				}
+				if i == 0 && previous != tt.previous {
+					t.Errorf("InstallGlobal() previous = %q, want %q", previous, tt.previous)
+				}
+			}
+			if got, _, _ := globalHooksPath(); got != dir {
+				t.Errorf("core.hooksPath = %q, want %q", got, dir)
+			}
+			if got := InspectGlobal(dir)[0].State; got != StateInstalled {
+				t.Errorf("state after InstallGlobal() = %s, want installed", got)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "pre-commit")); err != nil {
+				t.Errorf("unmanaged hooks should be forwarded: %v", err)
+			}
+			if _, err := os.Stat(stale); !os.IsNotExist(err) {
+				t.Errorf("push-to-checkout should not be forwarded")
+			}
+
+			restored, removed, err := UninstallGlobal(dir)
+			if err != nil || !removed {
+				t.Fatalf("UninstallGlobal() = %v, %v", removed, err)
+			}
+			got, set, _ := globalHooksPath()
+			if restored != tt.previous || got != tt.previous || set != (tt.previous != "") {
+				t.Errorf("core.hooksPath after UninstallGlobal() = %q (set %v), want %q", got, set, tt.previous)
+			}
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("global hooks directory should be removed")
+			}
+		})
+	}
+}
+
+// TestBlocks_PassInput runs the blocks with sh and a fake wip, checking that
+// both wip and the hook it shares the script with get the hook's arguments and input.
+func TestBlocks_PassInput(t *testing.T) {
+	h := Hook{Name: "pre-push", Capture: "git-push", Stdin: true, Gate: true}
+	const input = "refs/heads/main abc refs/heads/main def\n"
+
+	tests := []struct {
+		name      string
+		script    string
+		local     string // pre-push.local, empty for none
+		hookFails bool
+		wantWip   bool
+	}{
+		{name: "Inserted block", script: "#!/bin/sh\n" + h.Block() + "cat >\"$OUT/hook\"\n", wantWip: true},
+		{name: "Chained hook", script: "#!/bin/sh\n" + h.chainBlock(), local: "#!/bin/sh\ncat >\"$OUT/hook\"\n", wantWip: true},
+		{name: "Chained hook fails", script: "#!/bin/sh\n" + h.chainBlock(), local: "#!/bin/sh\ncat >\"$OUT/hook\"\nexit 1\n", hookFails: true},
+		// Husky runs hooks with sh -e
+		{name: "Appended block", script: "#!/bin/sh -e\ntrue\n" + h.Block(), wantWip: true},
+		{name: "Appended block after a failure", script: "#!/bin/sh -e\nfalse\n" + h.Block(), hookFails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := os.MkdirTemp("", "wips_test_hooks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			bin := filepath.Join(dir, "bin")
+			if err := os.Mkdir(bin, 0755); err != nil {
+				t.Fatal(err)
+			}
+			fakeWip := "#!/bin/sh\necho \"$@\" >\"$OUT/wip-args\"\ncat >\"$OUT/wip\"\n"
+			if err := os.WriteFile(filepath.Join(bin, "wip"), []byte(fakeWip), 0755); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, h.Name)
+			if err := os.WriteFile(path, []byte(tt.script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(path+LocalSuffix, []byte(tt.local), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := exec.Command(path, "origin", "https://example.com/repo.git")
+			cmd.Stdin = strings.NewReader(input)
+			cmd.Env = append(os.Environ(), "OUT="+dir, "PATH="+bin+":"+os.Getenv("PATH"))
+			err = cmd.Run()
+			if tt.hookFails != (err != nil) {
+				t.Fatalf("hook error = %v, want failure %v", err, tt.hookFails)
+			}
+
+			if data, _ := os.ReadFile(filepath.Join(dir, "hook")); strings.Contains(tt.script+tt.local, "$OUT/hook") && string(data) != input {
+				t.Errorf("hook input = %q, want %q", data, input)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "wip"))
+			if !tt.wantWip {
+				if err == nil {
+					t.Errorf("wip should not run when the hook fails")
+				}
+				return
+			}
+			if string(data) != input {
+				t.Errorf("wip input = %q, want %q", data, input)
+			}
+			if args, _ := os.ReadFile(filepath.Join(dir, "wip-args")); string(args) != "capture git-push origin https://example.com/repo.git\n" {
+				t.Errorf("wip args = %q", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lefthookConfigs are the main config file names lefthook looks for, in order.
+var lefthookConfigs = []string{"lefthook.yml", ".lefthook.yml", "lefthook.yaml", ".lefthook.yaml"}
+
+// lefthookConfig returns the path of the lefthook config at root, or "" if lefthook is not used.
+func lefthookConfig(root string) string {
+	for _, name := range lefthookConfigs {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// lefthookLocalConfig returns the untracked local config lefthook merges into config.
+func lefthookLocalConfig(config string) string {
+	name := strings.Replace(filepath.Base(config), "lefthook", "lefthook-local", 1)
+	return filepath.Join(filepath.Dir(config), name)
+}
+
+// lefthookBlock returns the marked block of lefthook config running the managed hooks.
+// Failures are ignored so that a missing wip never fails a git command.
+//
+// In gated hooks, wip's command is named to run last: lefthook runs commands
+// without a priority after the others, in name order. It is only skipped when
+// another command fails if the user's hook is piped (see lefthookGateError).
+func lefthookBlock() string {
+	var b strings.Builder
+	b.WriteString(BeginMarker + "\n")
+	b.WriteString("# Managed by wip; remove with 'wip hooks uninstall'.\n")
+	for _, h := range Managed {
+		name := "wip-" + h.Capture
+		b.WriteString(h.Name + ":\n")
+		if h.Gate {
+			name = "zz-" + name
+		}
+		b.WriteString("  commands:\n")
+		b.WriteString("    " + name + ":\n")
+		// {0} passes the hook's arguments
+		b.WriteString("      run: wip capture " + h.Capture + " {0} || true\n")
+		if h.Stdin {
+			b.WriteString("      use_stdin: true\n")
+		}
+	}
+	b.WriteString(EndMarker + "\n")
+	return b.String()
+}
+
+// installLefthook writes the wip block into lefthook's local config at path.
+func installLefthook(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	content := string(data)
+
+	// Top-level keys are not merged within a file, so a hook the user
+	// already configured here cannot get a second definition.
+	outside := replaceBlock(content, "")
+	for _, h := range Managed {
+		for _, line := range strings.Split(outside, "\n") {
+			if strings.HasPrefix(line, h.Name+":") {
+				return nil, &ConflictError{Path: path, Reason: h.Name + " is already configured; add 'wip capture " + h.Capture + " {0} || true' to it"}
+			}
+		}
+	}
+
+	if err := lefthookGateError(path); err != nil {
+		return nil, err
+	}
+
+	if hasBlock(content) {
+		content = replaceBlock(content, lefthookBlock())
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += lefthookBlock()
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// lefthookGateError returns a ConflictError if a gated hook configured in the
+// main lefthook config next to the local config at path is not piped: its
+// commands would all run even when one fails, and wip would record the event.
+// Piping the hook from the local config would change how the user's commands
+// run, so it is left to the user.
+func lefthookGateError(path string) error {
+	config := lefthookConfig(filepath.Dir(path))
+	if config == "" {
+		return nil
+	}
+	data, err := os.ReadFile(config)
+	if err != nil {
+		return err
+	}
+	for _, h := range Managed {
+		if !h.Gate {
+			continue
+		}
+		if lines, ok := lefthookHook(string(data), h.Name); ok && !containsLine(lines, "piped: true") {
+			return &ConflictError{Path: config, Reason: h.Name + " is not piped, so the event would be recorded even if a command fails; add 'piped: true' to it"}
+		}
+	}
+	return nil
+}
+
+// lefthookHook returns the lines of the top-level key hook of a lefthook
+// config, and false if the hook is not configured.
+func lefthookHook(content, hook string) ([]string, bool) {
+	var lines []string
+	found := false
+	for _, line := range strings.Split(content, "\n") {
+		if found {
+			if line != "" && line[0] != ' ' && line[0] != '\t' && line[0] != '#' {
+				break
+			}
+			lines = append(lines, line)
+		} else if strings.HasPrefix(line, hook+":") {
+			found = true
+		}
+	}
+	return lines, found
+}
+
+// containsLine reports whether one of lines is want, ignoring surrounding spaces.
+func containsLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// uninstallLefthook removes the wip block from lefthook's local config at path,
+// deleting the file if nothing else is left.
+func uninstallLefthook(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	if !hasBlock(content) {
+		return nil, nil
+	}
+
+	content = replaceBlock(content, "")
+	if isEmptyScript(content) {
+		err = os.Remove(path)
+	} else {
+		err = os.WriteFile(path, []byte(content), 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// inspectLefthook reports whether lefthook's local config at path runs h.
+func inspectLefthook(path string, h Hook) Status {
+	st := Status{Hook: h, Path: path, State: StateMissing}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	block, ok := findBlock(string(data))
+	if !ok {
+		return st
+	}
+	if block != lefthookBlock() {
+		st.State, st.Detail = StateStale, "outdated wip block"
+		return st
+	}
+	if h.Gate {
+		if err := lefthookGateError(path); err != nil {
+			st.State, st.Detail = StateStale, err.Error()
+			return st
+		}
+	}
+	st.State = StateInstalled
+	return st
+}
@@ -0,0 +1,240 @@
+package hooks
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shells are the interpreters whose scripts wip inserts its block into.
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "ash": true}
+
+// installScripts writes the wip block into the hook scripts in dir.
+// With chain set, hooks that are not shell scripts are moved to <name>.local
+// and run from a wip script; otherwise they are reported as conflicts.
+//
+// A gated hook must only record its event once the rest of the hook passed.
+// With chain set, such a hook is always moved aside and chained. Husky runs
+// its scripts with sh -e, so there the block goes at the end of the script,
+// which is only reached if every command before it succeeded.
+func installScripts(dir string, chain bool) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, h := range Managed {
+		path := filepath.Join(dir, h.Name)
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return written, err
+		}
+		content := string(data)
+		block, found := findBlock(content)
+		// The hook's own commands, when wip's block has to wait for them
+		gated := h.Gate && !isEmptyScript(replaceBlock(content, ""))
+
+		switch {
+		case strings.TrimSpace(content) == "" || content == legacyScript:
+			content = shebang + h.Block()
+		case found && isChainBlock(block):
+			content = replaceBlock(content, h.chainBlock())
+		case found && !gated:
+			content = replaceBlock(content, h.Block())
+		case isShellScript(data) && !gated:
+			content = insertBlock(content, h.Block())
+		case isShellScript(data) && !chain:
+			content = appendBlock(replaceBlock(content, ""), h.Block())
+		case chain:
+			local := path + LocalSuffix
+			if _, err := os.Stat(local); err == nil {
+				reason := filepath.Base(local) + " already exists"
+				if !isShellScript(data) {
+					reason = "not a shell script, and " + reason
+				}
+				return written, &ConflictError{Path: path, Reason: reason}
+			}
+			if err := moveAside(path, local, content, found); err != nil {
+				return written, err
+			}
+			content = shebang + h.chainBlock()
+		default:
+			return written, &ConflictError{Path: path, Reason: "not a shell script"}
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			return written, err
+		}
+		// WriteFile keeps the mode of an existing file
+		if err := os.Chmod(path, 0755); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// moveAside moves the hook at path to local so that a wip script can chain to it.
+// A block wip inserted earlier is left out.
+func moveAside(path, local, content string, hasBlock bool) error {
+	if !hasBlock {
+		return os.Rename(path, local)
+	}
+	if err := os.WriteFile(local, []byte(replaceBlock(content, "")), 0755); err != nil {
+		return err
+	}
+	return os.Chmod(local, 0755)
+}
+
+// uninstallScripts removes the wip block from the hook scripts in dir,
+// putting back hooks that were moved to <name>.local.
+func uninstallScripts(dir string) ([]string, error) {
+	var changed []string
+	for _, h := range Managed {
+		path := filepath.Join(dir, h.Name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+		content := string(data)
+
+		if content == legacyScript {
+			if err := os.Remove(path); err != nil {
+				return changed, err
+			}
+			changed = append(changed, path)
+			continue
+		}
+		block, ok := findBlock(content)
+		if !ok {
+			continue
+		}
+
+		content = replaceBlock(content, "")
+		if !isEmptyScript(content) {
+			if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+				return changed, err
+			}
+			changed = append(changed, path)
+			continue
+		}
+
+		local := path + LocalSuffix
+		if _, err := os.Stat(local); err == nil && isChainBlock(block) {
+			err = os.Rename(local, path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return changed, err
+		}
+		changed = append(changed, path)
+	}
+	return changed, nil
+}
+
+// inspectScript reports the state of the hook script for h in dir.
+// chain is set as for installScripts. Scripts run by husky need not be
+// executable, so that is only checked with chain.
+func inspectScript(dir string, h Hook, chain bool) Status {
+	path := filepath.Join(dir, h.Name)
+	st := Status{Hook: h, Path: path, State: StateMissing}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	content := string(data)
+	if content == legacyScript {
+		st.State, st.Detail = StateStale, "installed by an older version"
+		return st
+	}
+	block, ok := findBlock(content)
+	if !ok {
+		return st
+	}
+
+	switch block {
+	case h.Block():
+		// A gated hook must not record its event before its own commands ran
+		if h.Gate && !isEmptyScript(replaceBlock(content, "")) && (chain || !strings.HasSuffix(content, block)) {
+			st.State, st.Detail = StateStale, "records "+h.Capture+" before the rest of the hook ran"
+			return st
+		}
+	case h.chainBlock():
+		if _, err := os.Stat(path + LocalSuffix); err != nil {
+			st.State, st.Detail = StateStale, h.Name+LocalSuffix+" is missing"
+			return st
+		}
+	default:
+		st.State, st.Detail = StateStale, "outdated wip block"
+		return st
+	}
+
+	if chain {
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&0111 == 0 {
+			st.State, st.Detail = StateStale, "not executable"
+			return st
+		}
+	}
+	st.State = StateInstalled
+	return st
+}
+
+func hasBlock(content string) bool {
+	_, ok := findBlock(content)
+	return ok
+}
+
+// isChainBlock reports whether block runs a hook moved to <name>.local.
+func isChainBlock(block string) bool {
+	return strings.Contains(block, `"$0`+LocalSuffix+`"`)
+}
+
+// isShellScript reports whether a hook is a script wip can insert its block into:
+// one run by a POSIX-like shell, or one without a shebang, which git runs with sh.
+func isShellScript(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return bytes.IndexByte(data, 0) < 0
+	}
+	line, _, _ := strings.Cut(string(data[2:]), "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	interp := filepath.Base(fields[0])
+	if interp == "env" {
+		// Skip env's options, e.g. "#!/usr/bin/env -S bash -e"
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interp = filepath.Base(f)
+				break
+			}
+		}
+	}
+	return shells[interp]
+}
+
+// insertBlock inserts block after the shebang line of a script, or at its top.
+func insertBlock(content, block string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return block + content
+	}
+	i := strings.IndexByte(content, '\n')
+	if i < 0 {
+		return content + "\n" + block
+	}
+	return content[:i+1] + block + content[i+1:]
+}
+
+// appendBlock appends block at the end of a script.
+func appendBlock(content, block string) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + block
+}
@@ -12,11 +12,25 @@ import (
 // GenerateULID generates a new ULID string.
 // It uses crypto/rand for entropy.
 func GenerateULID() string {
-	t := time.Now()
+	return GenerateULIDAt(time.Now())
+}
+
+// GenerateULIDAt generates a ULID for an event that happened at t, such as an imported commit,
+// so that IDs keep sorting by time.
+func GenerateULIDAt(t time.Time) string {
 	entropy := ulid.Monotonic(rand.Reader, 0)
 	return ulid.MustNew(ulid.Timestamp(t), entropy).String()
 }
 
+// Time returns the time encoded in a ULID.
+func Time(id string) (time.Time, error) {
+	u, err := ulid.ParseStrict(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ulid.Time(u.Time()), nil
+}
+
 // GetHashID returns the first 8 characters of the SHA-256 hash of the input string.
 func GetHashID(content string) string {
 	hash := sha256.Sum256([]byte(content))
@@ -2,6 +2,9 @@ package id
 
 import (
 	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
 )
 
 func TestGenerateULID(t *testing.T) {
@@ -33,3 +36,17 @@ func TestGetHashID(t *testing.T) {
 		t.Error("Hash generation is not idempotent")
 	}
 }
+
+func TestGenerateULIDAt(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	u, err := ulid.Parse(GenerateULIDAt(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ulid.Time(u.Time()); !got.Equal(at) {
+		t.Errorf("ULID time = %v, want %v", got, at)
+	}
+	if got, err := Time(u.String()); err != nil || !got.Equal(at) {
+		t.Errorf("Time() = %v, %v, want %v", got, err, at)
+	}
+}
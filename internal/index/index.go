@@ -0,0 +1,353 @@
+// Package index provides a persistent inverted index for full-text search over events.
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// ErrNotBuilt is returned by Load when no index has been built yet.
+var ErrNotBuilt = errors.New("search index has not been built")
+
+// Doc holds per-event information needed for ranking and cleanup.
+type Doc struct {
+	TS     time.Time
+	Length int      // Number of tokens in the document
+	Terms  []string // Distinct terms, used to drop postings on removal
+}
+
+// maxLogSize is the size above which Load folds the change log into the index file.
+const maxLogSize = 1 << 20
+
+// Index maps terms to the events and positions they occur at.
+// It is stored as a gob file under <root>/index/. Changes made since it was
+// written are appended to a log next to it, so that recording an event does
+// not rewrite the whole index; Load applies them and compacts the log once it
+// grows large.
+type Index struct {
+	Docs     map[string]Doc
+	Postings map[string]map[string][]int // term -> event ID -> positions
+
+	path string
+}
+
+// Change is an event added, updated or removed since the index file was written.
+type Change struct {
+	ID      string    `json:"id"`
+	TS      time.Time `json:"ts,omitempty"`
+	Content string    `json:"content,omitempty"`
+	Removed bool      `json:"removed,omitempty"`
+}
+
+// Path returns the location of the index file for a store root.
+func Path(rootDir string) string {
+	return filepath.Join(rootDir, "index", "index.gob")
+}
+
+// logPath returns the location of the change log of the index file at path.
+func logPath(path string) string {
+	return strings.TrimSuffix(path, ".gob") + ".log"
+}
+
+func newIndex(rootDir string) *Index {
+	return &Index{
+		Docs:     make(map[string]Doc),
+		Postings: make(map[string]map[string][]int),
+		path:     Path(rootDir),
+	}
+}
+
+// Load reads the index of the given store root.
+// It returns ErrNotBuilt if the index does not exist.
+func Load(rootDir string) (*Index, error) {
+	path := Path(rootDir)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, ErrNotBuilt
+	}
+
+	fileLock := flock.New(path + ".lock")
+	fi, err := os.Stat(logPath(path))
+	if err != nil || fi.Size() <= maxLogSize {
+		if err := fileLock.RLock(); err != nil {
+			return nil, fmt.Errorf("failed to lock index: %w", err)
+		}
+		defer fileLock.Unlock()
+		return load(path)
+	}
+
+	// Fold the log into the index file so that it does not keep growing
+	if err := fileLock.Lock(); err != nil {
+		return nil, fmt.Errorf("failed to lock index: %w", err)
+	}
+	defer fileLock.Unlock()
+	ix, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ix.save(); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+func load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotBuilt
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	defer f.Close()
+
+	ix := &Index{}
+	if err := gob.NewDecoder(f).Decode(ix); err != nil {
+		return nil, fmt.Errorf("failed to decode index (run 'wip index rebuild'): %w", err)
+	}
+	if ix.Docs == nil {
+		ix.Docs = make(map[string]Doc)
+	}
+	if ix.Postings == nil {
+		ix.Postings = make(map[string]map[string][]int)
+	}
+	ix.path = path
+	if err := ix.replay(); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// replay applies the changes in the log.
+func (ix *Index) replay() error {
+	data, err := os.ReadFile(logPath(ix.path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read index log: %w", err)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var c Change
+		if err := json.Unmarshal(line, &c); err != nil {
+			if i == len(lines)-1 {
+				break // Cut short by a crash; the event itself is in the store
+			}
+			return fmt.Errorf("failed to decode index log (run 'wip index rebuild'): %w", err)
+		}
+		ix.Apply(c)
+	}
+	return nil
+}
+
+// Build creates a fresh index from the given events and writes it to disk,
+// replacing any existing index.
+func Build(rootDir string, events []model.WipsEvent) (*Index, error) {
+	ix := newIndex(rootDir)
+	for i := range events {
+		ix.Add(&events[i])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ix.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index dir: %w", err)
+	}
+	fileLock := flock.New(ix.path + ".lock")
+	if err := fileLock.Lock(); err != nil {
+		return nil, fmt.Errorf("failed to lock index: %w", err)
+	}
+	defer fileLock.Unlock()
+
+	if err := ix.save(); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// Log appends changes to the log of the index under an exclusive lock.
+// It is a no-op when the index has not been built yet.
+func Log(rootDir string, changes ...Change) error {
+	path := Path(rootDir)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	fileLock := flock.New(path + ".lock")
+	if err := fileLock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock index: %w", err)
+	}
+	defer fileLock.Unlock()
+
+	// The index may have been dropped while waiting for the lock
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := trimLog(logPath(path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(logPath(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open index log: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, c := range changes {
+		line, err := json.Marshal(c)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to encode index change: %w", err)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write index log: %w", err)
+	}
+	return f.Close()
+}
+
+// trimLog removes a last line cut short by a crash, so that the next change
+// starts on a line of its own.
+func trimLog(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index log: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || fi.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, fi.Size()-1); err != nil {
+		return fmt.Errorf("failed to read index log: %w", err)
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read index log: %w", err)
+	}
+	if err := f.Truncate(int64(bytes.LastIndexByte(data, '\n') + 1)); err != nil {
+		return fmt.Errorf("failed to trim index log: %w", err)
+	}
+	return nil
+}
+
+// Drop removes the index from disk so that it is rebuilt on next use.
+// It takes the exclusive lock, so that a Build or a compaction by Load in
+// progress cannot write the index back afterwards.
+func Drop(rootDir string) error {
+	path := Path(rootDir)
+	fileLock := flock.New(path + ".lock")
+	if err := fileLock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock index: %w", err)
+	}
+	defer fileLock.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(logPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// save writes the index to a temporary file and renames it into place, then
+// empties the log, whose changes it includes. Replaying them again after a
+// crash in between is harmless. The caller must hold the exclusive lock.
+func (ix *Index) save() error {
+	tmp, err := os.CreateTemp(filepath.Dir(ix.path), "index-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(ix); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), ix.path); err != nil {
+		return fmt.Errorf("failed to replace index: %w", err)
+	}
+	if err := os.Remove(logPath(ix.path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to reset index log: %w", err)
+	}
+	return nil
+}
+
+// Apply applies a change to the index in memory.
+func (ix *Index) Apply(c Change) {
+	if c.Removed {
+		ix.Remove(c.ID)
+		return
+	}
+	ix.Add(&model.WipsEvent{ID: c.ID, TS: c.TS, Content: c.Content})
+}
+
+// Add indexes an event, replacing any previous version of it.
+func (ix *Index) Add(e *model.WipsEvent) {
+	ix.Remove(e.ID)
+
+	tokens := Tokenize(e.Content)
+	var terms []string
+	for _, tok := range tokens {
+		docs, ok := ix.Postings[tok.Term]
+		if !ok {
+			docs = make(map[string][]int)
+			ix.Postings[tok.Term] = docs
+		}
+		if _, seen := docs[e.ID]; !seen {
+			terms = append(terms, tok.Term)
+		}
+		docs[e.ID] = append(docs[e.ID], tok.Pos)
+	}
+
+	ix.Docs[e.ID] = Doc{TS: e.TS, Length: len(tokens), Terms: terms}
+}
+
+// Remove drops an event from the index.
+func (ix *Index) Remove(id string) {
+	doc, ok := ix.Docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.Terms {
+		if docs, ok := ix.Postings[term]; ok {
+			delete(docs, id)
+			if len(docs) == 0 {
+				delete(ix.Postings, term)
+			}
+		}
+	}
+	delete(ix.Docs, id)
+}
+
+// Len returns the number of indexed events.
+func (ix *Index) Len() int {
+	return len(ix.Docs)
+}
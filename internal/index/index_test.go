@@ -0,0 +1,153 @@
+package index
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func testEvents() []model.WipsEvent {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return []model.WipsEvent{
+		{ID: "e1", TS: base, Content: "Investigating the auth token refresh bug"},
+		{ID: "e2", TS: base.Add(time.Hour), Content: "auth bug fixed: token refresh was racing, auth auth"},
+		{ID: "e3", TS: base.Add(2 * time.Hour), Content: "Refresh the dashboard"},
+		{ID: "e4", TS: base.Add(3 * time.Hour), Content: "キャッシュの問題だった"},
+		{ID: "e5", TS: base.Add(4 * time.Hour), Content: "東京都の天気"},
+	}
+}
+
+func ids(results []Result) []string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+func TestIndex_Search(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ix, err := Build(tempDir, testEvents())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "Multi-term ranked by frequency", query: "auth bug", want: []string{"e2", "e1"}},
+		{name: "Phrase prefers shorter notes", query: `"token refresh"`, want: []string{"e1", "e2"}},
+		{name: "Phrase order matters", query: `"refresh token"`, want: nil},
+		{name: "Prefix", query: "dash*", want: []string{"e3"}},
+		{name: "Prefix and term", query: "refre* dashboard", want: []string{"e3"}},
+		{name: "Japanese word", query: "キャッシュ", want: []string{"e4"}},
+		{name: "Japanese single character", query: "都", want: []string{"e5"}},
+		{name: "No match", query: "kubernetes", want: nil},
+		{name: "Case insensitive", query: "AUTH", want: []string{"e2", "e1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := ix.Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ids(results)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	if _, err := ix.Search(`"unterminated`); err == nil {
+		t.Error("Expected error for unterminated quote")
+	}
+}
+
+func TestIndex_LogPersists(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_index_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Logging before the index exists is a no-op
+	if err := Log(tempDir, Change{ID: "e1", Content: "dashboard"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(tempDir); err != ErrNotBuilt {
+		t.Fatalf("Load() error = %v, want ErrNotBuilt", err)
+	}
+
+	if _, err := Build(tempDir, testEvents()); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Log(tempDir,
+		Change{ID: "e3", Removed: true},
+		Change{ID: "e1", TS: time.Now(), Content: "rewritten dashboard note"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func() {
+		t.Helper()
+		ix, err := Load(tempDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(mustSearch(t, ix, "dashboard")); len(got) != 1 || got[0] != "e1" {
+			t.Errorf("Search(dashboard) = %v, want [e1]", got)
+		}
+		if got := ids(mustSearch(t, ix, "investigating")); len(got) != 0 {
+			t.Errorf("Search(investigating) = %v, want none after rewrite", got)
+		}
+	}
+	check()
+
+	// A line cut short by a crash is ignored
+	f, err := os.OpenFile(logPath(Path(tempDir)), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"e4","rem`)
+	f.Close()
+	check()
+
+	// A large log is folded into the index file
+	padding := strings.Repeat("x", maxLogSize)
+	if err := Log(tempDir, Change{ID: "e6", Content: padding}, Change{ID: "e6", Removed: true}); err != nil {
+		t.Fatal(err)
+	}
+	check()
+	if _, err := os.Stat(logPath(Path(tempDir))); !os.IsNotExist(err) {
+		t.Errorf("log still exists after compaction: %v", err)
+	}
+	check()
+}
+
+func mustSearch(t *testing.T, ix *Index, q string) []Result {
+	t.Helper()
+	results, err := ix.Search(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
@@ -0,0 +1,203 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// BM25 ranking parameters.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Result is a matching event with its relevance score.
+type Result struct {
+	ID    string
+	TS    time.Time
+	Score float64
+}
+
+// clause is a single required part of a query: one term, or a phrase of
+// consecutive terms. If prefix is set, the last term matches any term starting with it.
+type clause struct {
+	terms  []string
+	prefix bool
+}
+
+// parseQuery splits a query into clauses.
+// Words separated by spaces are independent clauses, "quoted text" is a phrase
+// and a trailing * turns the last word into a prefix.
+// A word that tokenizes into several terms (e.g. Japanese text) is matched as a phrase.
+func parseQuery(query string) ([]clause, error) {
+	var clauses []clause
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var text string
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quote at position %d", i+1)
+			}
+			text = string(runes[i+1 : end])
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+		}
+
+		prefix := strings.HasSuffix(text, "*")
+		tokens := Tokenize(strings.TrimSuffix(text, "*"))
+		if len(tokens) == 0 {
+			continue
+		}
+		c := clause{prefix: prefix}
+		for _, tok := range tokens {
+			c.terms = append(c.terms, tok.Term)
+		}
+		clauses = append(clauses, c)
+	}
+
+	return clauses, nil
+}
+
+// Search returns events matching every clause of the query, best match first.
+// Ties are broken by recency.
+func (ix *Index) Search(query string) ([]Result, error) {
+	clauses, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(clauses) == 0 {
+		return nil, nil
+	}
+
+	var avgLen float64
+	for _, doc := range ix.Docs {
+		avgLen += float64(doc.Length)
+	}
+	if len(ix.Docs) > 0 {
+		avgLen /= float64(len(ix.Docs))
+	}
+
+	scores := make(map[string]float64)
+	for i, c := range clauses {
+		freqs := ix.match(c)
+
+		idf := math.Log(1 + (float64(len(ix.Docs))-float64(len(freqs))+0.5)/(float64(len(freqs))+0.5))
+		next := make(map[string]float64)
+		for id, tf := range freqs {
+			if i > 0 {
+				if _, ok := scores[id]; !ok {
+					continue // Every clause is required
+				}
+			}
+			norm := 1.0
+			if avgLen > 0 {
+				norm = 1 - bm25B + bm25B*float64(ix.Docs[id].Length)/avgLen
+			}
+			next[id] = scores[id] + idf*(float64(tf)*(bm25K1+1))/(float64(tf)+bm25K1*norm)
+		}
+		scores = next
+		if len(scores) == 0 {
+			return nil, nil
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, Result{ID: id, TS: ix.Docs[id].TS, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].TS.After(results[j].TS)
+	})
+	return results, nil
+}
+
+// match returns the number of occurrences of the clause per event.
+func (ix *Index) match(c clause) map[string]int {
+	// Positions of each clause term per event (merged over prefix expansions)
+	positions := make([]map[string][]int, len(c.terms))
+	for i, term := range c.terms {
+		last := i == len(c.terms)-1
+		positions[i] = ix.lookup(term, last && c.prefix, len(c.terms) == 1)
+	}
+
+	freqs := make(map[string]int)
+	for id, starts := range positions[0] {
+		if len(c.terms) == 1 {
+			freqs[id] = len(starts)
+			continue
+		}
+		count := 0
+		for _, p := range starts {
+			matched := true
+			for i := 1; i < len(c.terms); i++ {
+				if !containsInt(positions[i][id], p+i) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				count++
+			}
+		}
+		if count > 0 {
+			freqs[id] = count
+		}
+	}
+	return freqs
+}
+
+// lookup collects the postings of a term.
+// With prefix set, all terms starting with it are included. A lone CJK character
+// is matched against every bigram containing it, since text is indexed as bigrams.
+func (ix *Index) lookup(term string, prefix bool, standalone bool) map[string][]int {
+	if !prefix && !(standalone && isSingleCJK(term)) {
+		return ix.Postings[term]
+	}
+
+	merged := make(map[string][]int)
+	for t, docs := range ix.Postings {
+		if prefix && !strings.HasPrefix(t, term) {
+			if !(standalone && isSingleCJK(term) && strings.Contains(t, term)) {
+				continue
+			}
+		} else if !prefix && !strings.Contains(t, term) {
+			continue
+		}
+		for id, pos := range docs {
+			merged[id] = append(merged[id], pos...)
+		}
+	}
+	return merged
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
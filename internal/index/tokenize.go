@@ -0,0 +1,75 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a normalized term and its position within a document.
+type Token struct {
+	Term string
+	Pos  int
+}
+
+// Tokenize splits text into searchable terms.
+// Runs of letters and digits become lowercase words. Runs of CJK characters
+// (kanji, hiragana, katakana) have no word boundaries, so they are split into
+// overlapping bigrams instead; a run of a single character is kept as is.
+// Positions are consecutive across both kinds so phrases can be matched.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	pos := 0
+	emit := func(term string) {
+		tokens = append(tokens, Token{Term: term, Pos: pos})
+		pos++
+	}
+
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			emit(strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			emit(string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				emit(string(cjk[i : i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+// isCJK reports whether r belongs to a script written without spaces.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー' || r == '々'
+}
+
+// isSingleCJK reports whether term is a single CJK character.
+func isSingleCJK(term string) bool {
+	runes := []rune(term)
+	return len(runes) == 1 && isCJK(runes[0])
+}
@@ -0,0 +1,55 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "Latin words are lowercased",
+			text: "Fix Auth-Token bug #123",
+			want: []string{"fix", "auth", "token", "bug", "123"},
+		},
+		{
+			name: "Japanese is split into bigrams",
+			text: "東京都",
+			want: []string{"東京", "京都"},
+		},
+		{
+			name: "Mixed scripts",
+			text: "キャッシュのbug",
+			want: []string{"キャ", "ャッ", "ッシ", "シュ", "ュの", "bug"},
+		},
+		{
+			name: "Single CJK character",
+			text: "a 字 b",
+			want: []string{"a", "字", "b"},
+		},
+		{
+			name: "Empty",
+			text: "  ... ",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for i, tok := range Tokenize(tt.text) {
+				if tok.Pos != i {
+					t.Errorf("Token %q has position %d, want %d", tok.Term, tok.Pos, i)
+				}
+				got = append(got, tok.Term)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
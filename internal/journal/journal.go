@@ -0,0 +1,226 @@
+// Package journal resolves undo events into the effective view of the event log.
+//
+// Events are never rewritten to undo an action. Instead, an undo event is
+// appended whose Meta references the action it reverts (a note, an edit or a
+// deletion). Undo events can themselves be undone, which is how redo works.
+// Readers apply the journal to hide undone notes and roll back undone edits.
+//
+// The references of undo events are also kept in the undo dictionary, and
+// edits and deletions in the changes dictionary, so that neither readers nor
+// undo have to scan the whole log to find them. Readers rely on the
+// dictionaries alone: they are filled from the log once, when first used, and
+// kept up to date as actions are recorded.
+package journal
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// MetaUndo is the Meta key holding the Ref an undo event reverts.
+const MetaUndo = "undo"
+
+// UndoDict is the dictionary mapping undo event IDs to the Ref they revert.
+const UndoDict = "undo"
+
+// Action is a kind of undoable action.
+type Action string
+
+const (
+	ActionNote   Action = "note"   // Recording a note or a task
+	ActionEdit   Action = "edit"   // Editing an event's content
+	ActionDelete Action = "delete" // Deleting an event
+	ActionUndo   Action = "undo"   // Undoing an action (undoing it is a redo)
+)
+
+// Ref identifies an undoable action.
+type Ref struct {
+	Action   Action `json:"action"`
+	Target   string `json:"target"`             // Event ID, or undo event ID
+	Rev      int    `json:"rev,omitempty"`      // Version created by an edit
+	Deletion string `json:"deletion,omitempty"` // Trash.ID of a deletion
+}
+
+// Journal knows which actions are currently undone.
+type Journal struct {
+	ids      []string         // Undo event IDs, oldest first
+	refs     map[string]Ref   // Undo event ID -> the action it reverts
+	byTarget map[Ref][]string // Action -> undo events reverting it
+	undone   map[Ref]bool     // Memoized IsUndone results
+}
+
+// Load reads the references of every undo event from the undo dictionary,
+// filling it with New from the undo events of the log the first time.
+func Load(s store.Store) (*Journal, error) {
+	dict, err := s.LoadDict(UndoDict)
+	if err != nil {
+		return nil, err
+	}
+	if dict == nil {
+		dict = make(map[string]interface{})
+	}
+	err = store.FillDict(s, UndoDict, func() error {
+		q := store.Query{Types: []model.EventType{model.EventTypeUndo}, IncludeTrashed: true}
+		events, err := s.QueryEvents(q)
+		if err != nil {
+			return fmt.Errorf("failed to get undo events: %w", err)
+		}
+		filled := make(map[string]interface{})
+		for id, ref := range New(events).refs {
+			filled[id] = ref
+			dict[id] = ref
+		}
+		return store.SaveDictEntries(s, UndoDict, filled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	j := newJournal()
+	for id, value := range dict {
+		var ref Ref
+		if err := decodeDictValue(value, &ref); err != nil {
+			return nil, fmt.Errorf("failed to decode undo %s: %w", id, err)
+		}
+		j.add(id, ref)
+	}
+	sort.Strings(j.ids)
+	return j, nil
+}
+
+// New builds a journal from undo events. Other event types are ignored.
+func New(events []model.WipsEvent) *Journal {
+	j := newJournal()
+	for i := range events {
+		e := &events[i]
+		if e.Type != model.EventTypeUndo {
+			continue
+		}
+		var ref Ref
+		if found, err := e.GetMeta(MetaUndo, &ref); !found || err != nil {
+			continue
+		}
+		j.add(e.ID, ref)
+	}
+	sort.Strings(j.ids)
+	return j
+}
+
+func newJournal() *Journal {
+	return &Journal{
+		refs:     make(map[string]Ref),
+		byTarget: make(map[Ref][]string),
+		undone:   make(map[Ref]bool),
+	}
+}
+
+func (j *Journal) add(id string, ref Ref) {
+	j.ids = append(j.ids, id)
+	j.refs[id] = ref
+	j.byTarget[ref] = append(j.byTarget[ref], id)
+}
+
+// IsUndone reports whether the action is reverted by an undo that is itself in effect.
+func (j *Journal) IsUndone(ref Ref) bool {
+	if v, ok := j.undone[ref]; ok {
+		return v
+	}
+	undone := false
+	for _, id := range j.byTarget[ref] {
+		// An undo always references an earlier event, so this terminates
+		if !j.IsUndone(Ref{Action: ActionUndo, Target: id}) {
+			undone = true
+			break
+		}
+	}
+	j.undone[ref] = undone
+	return undone
+}
+
+// Undos returns the IDs of the undo events, most recent first.
+func (j *Journal) Undos() []string {
+	ids := make([]string, len(j.ids))
+	for i, id := range j.ids {
+		ids[len(ids)-1-i] = id
+	}
+	return ids
+}
+
+// RefOf returns the action reverted by an undo event.
+func (j *Journal) RefOf(undoID string) (Ref, bool) {
+	ref, ok := j.refs[undoID]
+	return ref, ok
+}
+
+// ResolveEvent applies the journal to a single event.
+// It returns false if the event should not be shown: undo events, undone notes
+// and tasks, and commits superseded after a rewrite.
+// Undone edits are rolled back to the latest version still in effect.
+func (j *Journal) ResolveEvent(e model.WipsEvent) (model.WipsEvent, bool) {
+	if e.Type == model.EventTypeUndo || e.Superseded() != nil {
+		return e, false
+	}
+	if (e.Type == model.EventTypeNote || e.Type == model.EventTypeTask) && j.IsUndone(Ref{Action: ActionNote, Target: e.ID}) {
+		return e, false
+	}
+
+	revs := Revisions(&e)
+	if v := j.EffectiveRev(&e); v <= len(revs) {
+		e.SetContent(revs[v-1].Content)
+	}
+	return e, true
+}
+
+// EffectiveRev returns the version of an event's content in effect:
+// the latest version whose edit has not been undone.
+func (j *Journal) EffectiveRev(e *model.WipsEvent) int {
+	v := len(Revisions(e)) + 1
+	for v > 1 && j.IsUndone(Ref{Action: ActionEdit, Target: e.ID, Rev: v}) {
+		v--
+	}
+	return v
+}
+
+// Resolve applies the journal to a list of events, dropping hidden ones.
+func (j *Journal) Resolve(events []model.WipsEvent) []model.WipsEvent {
+	resolved := make([]model.WipsEvent, 0, len(events))
+	for _, e := range events {
+		if r, ok := j.ResolveEvent(e); ok {
+			resolved = append(resolved, r)
+		}
+	}
+	return resolved
+}
+
+// Revisions returns the previous versions of an event, oldest first.
+func Revisions(e *model.WipsEvent) []model.Revision {
+	var revs []model.Revision
+	if _, err := e.GetMeta(model.MetaRevisions, &revs); err != nil {
+		return nil
+	}
+	return revs
+}
+
+// Version is one version of an event's content.
+type Version struct {
+	Rev     int
+	TS      time.Time // When this version was saved
+	Content string
+}
+
+// Versions returns every version of an event's content, oldest first.
+// The last one is the stored content.
+func Versions(e *model.WipsEvent) []Version {
+	revs := Revisions(e)
+	versions := make([]Version, 0, len(revs)+1)
+	ts := e.TS
+	for _, r := range revs {
+		versions = append(versions, Version{Rev: r.Rev, TS: ts, Content: r.Content})
+		ts = r.ReplacedAt
+	}
+	return append(versions, Version{Rev: len(revs) + 1, TS: ts, Content: e.Content})
+}
@@ -0,0 +1,203 @@
+package journal
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func undoEvent(t *testing.T, id string, ref Ref) model.WipsEvent {
+	t.Helper()
+	e := model.WipsEvent{ID: id, Type: model.EventTypeUndo}
+	if err := e.SetMeta(MetaUndo, ref); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestJournal_IsUndone(t *testing.T) {
+	note := Ref{Action: ActionNote, Target: "N1"}
+
+	tests := []struct {
+		name  string
+		undos []model.WipsEvent
+		want  bool
+	}{
+		{"no undo", nil, false},
+		{"undone", []model.WipsEvent{undoEvent(t, "U1", note)}, true},
+		{
+			"redone",
+			[]model.WipsEvent{
+				undoEvent(t, "U1", note),
+				undoEvent(t, "U2", Ref{Action: ActionUndo, Target: "U1"}),
+			},
+			false,
+		},
+		{
+			"undone again after redo",
+			[]model.WipsEvent{
+				undoEvent(t, "U1", note),
+				undoEvent(t, "U2", Ref{Action: ActionUndo, Target: "U1"}),
+				undoEvent(t, "U3", note),
+			},
+			true,
+		},
+		{
+			"redo undone",
+			[]model.WipsEvent{
+				undoEvent(t, "U1", note),
+				undoEvent(t, "U2", Ref{Action: ActionUndo, Target: "U1"}),
+				undoEvent(t, "U3", Ref{Action: ActionUndo, Target: "U2"}),
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New(tt.undos).IsUndone(note); got != tt.want {
+				t.Errorf("IsUndone() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJournal_Resolve(t *testing.T) {
+	now := time.Now()
+
+	edited := model.WipsEvent{ID: "N2", Type: model.EventTypeNote, Content: "v1"}
+	if err := RecordEdit(&edited, "v2", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordEdit(&edited, "v3", now); err != nil {
+		t.Fatal(err)
+	}
+	superseded := model.WipsEvent{ID: "C2", Type: model.EventTypeGitCommit, Content: "abc123 commit"}
+	if err := superseded.SetMeta(model.MetaSuperseded, model.Superseded{By: "C1", SHA: "abc123", At: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := []model.WipsEvent{
+		{ID: "N1", Type: model.EventTypeNote, Content: "undone note"},
+		edited,
+		{ID: "C1", Type: model.EventTypeGitCommit, Content: "abc123 commit"},
+		superseded,
+		undoEvent(t, "U1", Ref{Action: ActionNote, Target: "N1"}),
+		undoEvent(t, "U2", Ref{Action: ActionEdit, Target: "N2", Rev: 3}),
+		undoEvent(t, "U3", Ref{Action: ActionEdit, Target: "N2", Rev: 2}),
+		undoEvent(t, "U4", Ref{Action: ActionUndo, Target: "U3"}),
+	}
+
+	got := New(events).Resolve(events)
+	if len(got) != 2 {
+		t.Fatalf("Resolve() returned %d events, want 2: %+v", len(got), got)
+	}
+	if got[0].ID != "N2" || got[0].Content != "v2" {
+		t.Errorf("edited note = %s %q, want N2 %q", got[0].ID, got[0].Content, "v2")
+	}
+	if got[1].ID != "C1" {
+		t.Errorf("second event = %s, want C1", got[1].ID)
+	}
+}
+
+func TestRecordEdit(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := model.WipsEvent{Content: "first"}
+
+	if err := RecordEdit(&e, "second", at); err != nil {
+		t.Fatal(err)
+	}
+
+	revs := Revisions(&e)
+	if e.Content != "second" || len(revs) != 1 {
+		t.Fatalf("after edit: content %q, %d revisions", e.Content, len(revs))
+	}
+	if revs[0].Rev != 1 || revs[0].Content != "first" || !revs[0].ReplacedAt.Equal(at) {
+		t.Errorf("revision = %+v", revs[0])
+	}
+}
+
+func TestVersions(t *testing.T) {
+	created := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	firstEdit := created.Add(time.Hour)
+	secondEdit := created.Add(2 * time.Hour)
+
+	e := model.WipsEvent{ID: "N1", TS: created, Content: "one"}
+	RecordEdit(&e, "two", firstEdit)
+	RecordEdit(&e, "three", secondEdit)
+
+	want := []Version{
+		{Rev: 1, TS: created, Content: "one"},
+		{Rev: 2, TS: firstEdit, Content: "two"},
+		{Rev: 3, TS: secondEdit, Content: "three"},
+	}
+	got := Versions(&e)
+	if len(got) != len(want) {
+		t.Fatalf("Versions() returned %d versions, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Rev != want[i].Rev || !got[i].TS.Equal(want[i].TS) || got[i].Content != want[i].Content {
+			t.Errorf("Versions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// An event that was never edited has a single version
+	plain := model.WipsEvent{TS: created, Content: "only"}
+	if v := Versions(&plain); len(v) != 1 || v[0].Rev != 1 || v[0].Content != "only" {
+		t.Errorf("Versions() of unedited event = %+v", v)
+	}
+}
+
+func TestLoad_FillsFromLog(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Events recorded before the dictionaries existed
+	now := time.Now()
+	note := model.WipsEvent{ID: id.GenerateULIDAt(now), TS: now, Type: model.EventTypeNote, Content: "first"}
+	if err := RecordEdit(&note, "second", now); err != nil {
+		t.Fatal(err)
+	}
+	undo := undoEvent(t, id.GenerateULIDAt(now), Ref{Action: ActionNote, Target: note.ID})
+	undo.TS = now
+	for _, e := range []*model.WipsEvent{&note, &undo} {
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j, err := Load(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !j.IsUndone(Ref{Action: ActionNote, Target: note.ID}) {
+		t.Error("IsUndone() = false, want the note undone by the undo event in the log")
+	}
+	changes, err := LoadChanges(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Ref{Action: ActionEdit, Target: note.ID, Rev: 2}); len(changes) != 1 || changes[0].Ref != want {
+		t.Errorf("LoadChanges() = %+v, want the edit to revision 2", changes)
+	}
+
+	// The dictionary is filled only once
+	if changes, err := LoadChanges(s); err != nil || len(changes) != 1 {
+		t.Errorf("LoadChanges() again = %+v, %v", changes, err)
+	}
+}
@@ -0,0 +1,147 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// ChangesDict is the dictionary of edits and deletions, keyed by a ULID taken when they were made.
+const ChangesDict = "changes"
+
+// Change is an edit or a deletion recorded in the changes dictionary.
+type Change struct {
+	Ref Ref       `json:"ref"`
+	At  time.Time `json:"at"`
+}
+
+// RecordEdit replaces the content of e, keeping the previous content as a revision.
+// Tags written in the content are updated to match.
+func RecordEdit(e *model.WipsEvent, content string, at time.Time) error {
+	revs := Revisions(e)
+	revs = append(revs, model.Revision{
+		Rev:        len(revs) + 1,
+		Content:    e.Content,
+		ReplacedAt: at,
+	})
+	if err := e.SetMeta(model.MetaRevisions, revs); err != nil {
+		return err
+	}
+	e.SetContent(content)
+	return nil
+}
+
+// Edit replaces the content of a stored event with RecordEdit, adding refs to
+// its links, and records the edit in the changes dictionary so it can be undone.
+func Edit(s store.Store, eventID, content string, at time.Time, refs ...string) error {
+	var rev int
+	err := s.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		e.AddRefs(refs...)
+		if err := RecordEdit(e, content, at); err != nil {
+			return err
+		}
+		rev = len(Revisions(e)) + 1
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update event %s: %w", eventID, err)
+	}
+	return RecordChange(s, Ref{Action: ActionEdit, Target: eventID, Rev: rev}, at)
+}
+
+// RecordUndo saves the reference of an undo event to the undo dictionary.
+func RecordUndo(s store.Store, undoID string, ref Ref) error {
+	if err := s.SaveDict(UndoDict, undoID, ref); err != nil {
+		return fmt.Errorf("failed to save undo %s: %w", undoID, err)
+	}
+	return nil
+}
+
+// RecordChange saves an edit or a deletion made at the given time, so that undo finds it.
+func RecordChange(s store.Store, ref Ref, at time.Time) error {
+	if err := s.SaveDict(ChangesDict, id.GenerateULIDAt(at), Change{Ref: ref, At: at}); err != nil {
+		return fmt.Errorf("failed to save change of event %s: %w", ref.Target, err)
+	}
+	return nil
+}
+
+// LoadChanges returns every recorded edit and deletion, most recent first.
+// The changes dictionary is filled from the revisions and the trash of the
+// events the first time.
+func LoadChanges(s store.Store) ([]Change, error) {
+	dict, err := s.LoadDict(ChangesDict)
+	if err != nil {
+		return nil, err
+	}
+	if dict == nil {
+		dict = make(map[string]interface{})
+	}
+	if err := store.FillDict(s, ChangesDict, func() error { return fillChanges(s, dict) }); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+	changes := make([]Change, 0, len(keys))
+	for _, key := range keys {
+		var c Change
+		if err := decodeDictValue(dict[key], &c); err != nil {
+			return nil, fmt.Errorf("failed to decode change %s: %w", key, err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// fillChanges records the edits and deletions of every event in the changes
+// dictionary, except those already in it, and adds them to its content dict.
+func fillChanges(s store.Store, dict map[string]interface{}) error {
+	recorded := make(map[Ref]bool)
+	for _, value := range dict {
+		var c Change
+		if err := decodeDictValue(value, &c); err == nil {
+			recorded[c.Ref] = true
+		}
+	}
+
+	filled := make(map[string]interface{})
+	add := func(ref Ref, at time.Time) {
+		if !recorded[ref] {
+			key := id.GenerateULIDAt(at)
+			filled[key] = Change{Ref: ref, At: at}
+			dict[key] = filled[key]
+		}
+	}
+	err := s.IterateEvents(store.Query{IncludeTrashed: true}, func(e *model.WipsEvent) error {
+		// The edit to version n replaced revision n-1
+		for i, r := range Revisions(e) {
+			add(Ref{Action: ActionEdit, Target: e.ID, Rev: i + 2}, r.ReplacedAt)
+		}
+		if t := e.Trashed(); t != nil {
+			add(Ref{Action: ActionDelete, Target: e.ID, Deletion: t.ID}, t.At)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+	return store.SaveDictEntries(s, ChangesDict, filled)
+}
+
+// decodeDictValue converts a generically decoded dictionary value into v.
+func decodeDictValue(value interface{}, v interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Command is the Meta of a command event, recorded by 'wip run' or the shell integration.
+type Command struct {
+	Argv     []string      `json:"argv,omitempty"` // Not known for commands recorded by the shell
+	ExitCode int           `json:"exitCode"`
+	Duration time.Duration `json:"duration"`
+	// Output is the end of the command's combined stdout and stderr.
+	Output    string `json:"output,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"` // Output does not start at the beginning
+	Shell     bool   `json:"shell,omitempty"`     // Recorded by the shell integration, without its output
+}
+
+// MetaCommand is the Meta key holding the Command of a command event.
+const MetaCommand = "command"
+
+// Failed reports whether the command exited with a non-zero status.
+func (c *Command) Failed() bool {
+	return c.ExitCode != 0
+}
+
+// CommandMeta returns the command run by a command event, or nil for other events.
+func (e *WipsEvent) CommandMeta() *Command {
+	if e.Type != EventTypeCommand {
+		return nil
+	}
+	var c Command
+	if found, err := e.GetMeta(MetaCommand, &c); !found || err != nil {
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,136 @@
+package model
+
+import "time"
+
+// Meta recorded by the git hooks, one key per event type.
+
+// Commit is the Meta of a git_commit event.
+// Events recorded by earlier versions have none; their Content is the output of 'git show --stat --oneline'.
+type Commit struct {
+	SHA        string       `json:"sha"`
+	Parents    []string     `json:"parents,omitempty"`
+	Author     string       `json:"author"`
+	Email      string       `json:"email,omitempty"`
+	AuthorDate time.Time    `json:"authorDate"`
+	CommitDate time.Time    `json:"commitDate"`
+	Subject    string       `json:"subject"`
+	Body       string       `json:"body,omitempty"`
+	Files      []FileChange `json:"files,omitempty"`
+}
+
+// FileChange is a file changed by a commit, with its line counts from 'git show --numstat'.
+type FileChange struct {
+	Path       string `json:"path"`
+	Insertions int    `json:"insertions"`
+	Deletions  int    `json:"deletions"`
+	Binary     bool   `json:"binary,omitempty"` // No line counts
+}
+
+// MetaCommit is the Meta key holding the Commit of a git_commit event.
+const MetaCommit = "commit"
+
+// ShortSHA returns the abbreviated SHA of the commit.
+func (c *Commit) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// Churn returns the number of lines inserted and deleted by the commit.
+func (c *Commit) Churn() (insertions, deletions int) {
+	for _, f := range c.Files {
+		insertions += f.Insertions
+		deletions += f.Deletions
+	}
+	return insertions, deletions
+}
+
+// CommitMeta returns the commit of a git_commit event, or nil if it was recorded without one.
+func (e *WipsEvent) CommitMeta() *Commit {
+	if e.Type != EventTypeGitCommit {
+		return nil
+	}
+	var c Commit
+	if found, err := e.GetMeta(MetaCommit, &c); !found || err != nil {
+		return nil
+	}
+	return &c
+}
+
+// Checkout is the Meta of a git_checkout event: a switch from one branch or commit to another.
+type Checkout struct {
+	From     string `json:"from"` // Branch name, or the abbreviated commit when detached
+	To       string `json:"to"`
+	FromHead string `json:"fromHead"` // Full SHA
+	ToHead   string `json:"toHead"`
+}
+
+// MetaCheckout is the Meta key holding the Checkout of a git_checkout event.
+const MetaCheckout = "checkout"
+
+// Merge is the Meta of a git_merge event, recorded after 'git merge' or 'git pull'.
+type Merge struct {
+	Head   string `json:"head"`             // Full SHA of HEAD after the merge
+	Squash bool   `json:"squash,omitempty"` // Changes were staged without a merge commit
+}
+
+// MetaMerge is the Meta key holding the Merge of a git_merge event.
+const MetaMerge = "merge"
+
+// Rewrite is the Meta of a git_rewrite event, recorded after an amend or a rebase.
+type Rewrite struct {
+	Command string       `json:"command"` // "amend" or "rebase"
+	Commits []RewriteSHA `json:"commits"`
+}
+
+// RewriteSHA maps a rewritten commit to the commit replacing it.
+type RewriteSHA struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// MetaRewrite is the Meta key holding the Rewrite of a git_rewrite event.
+const MetaRewrite = "rewrite"
+
+// Push is the Meta of a git_push event, recorded before the push is sent.
+type Push struct {
+	Remote string    `json:"remote"`
+	URL    string    `json:"url,omitempty"` // Without credentials
+	Refs   []PushRef `json:"refs"`
+}
+
+// PushRef is a ref updated by a push. LocalSHA is all zeros when the remote ref is deleted.
+type PushRef struct {
+	LocalRef  string `json:"localRef"`
+	LocalSHA  string `json:"localSha"`
+	RemoteRef string `json:"remoteRef"`
+	RemoteSHA string `json:"remoteSha"`
+}
+
+// MetaPush is the Meta key holding the Push of a git_push event.
+const MetaPush = "push"
+
+// Superseded marks a git_commit event replaced by an earlier event for the same commit.
+// Amends and rebases run post-commit again for each commit they create; the event
+// recorded for the original commit is kept, pointed at the new one, and the others are superseded.
+type Superseded struct {
+	By  string    `json:"by"`  // ID of the event kept for the commit
+	SHA string    `json:"sha"` // The commit both events refer to
+	At  time.Time `json:"at"`
+}
+
+// MetaSuperseded is the Meta key holding the Superseded marker of a git_commit event.
+const MetaSuperseded = "superseded"
+
+// Superseded returns the marker of a git_commit event replaced by another one, or nil.
+func (e *WipsEvent) Superseded() *Superseded {
+	if e.Type != EventTypeGitCommit {
+		return nil
+	}
+	var s Superseded
+	if found, err := e.GetMeta(MetaSuperseded, &s); !found || err != nil {
+		return nil
+	}
+	return &s
+}
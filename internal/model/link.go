@@ -0,0 +1,49 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// refPattern matches a reference to an event, [[id-prefix]]. ULIDs start with
+// a digit from 0 to 7, which tells references from wiki links like [[Notes]].
+var refPattern = regexp.MustCompile(`\[\[([0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{3,25})\]\]`)
+
+// ParseRefs returns the event ID prefixes referenced in content as [[id-prefix]],
+// uppercased, in order of first appearance.
+func ParseRefs(content string) []string {
+	var refs []string
+	for _, m := range refPattern.FindAllStringSubmatch(content, -1) {
+		prefix := strings.ToUpper(m[1])
+		if !contains(refs, prefix) {
+			refs = append(refs, prefix)
+		}
+	}
+	return refs
+}
+
+// RefList returns the IDs of the events referenced in the content. References
+// removed from the content by an edit are left out, so undoing the edit
+// brings them back.
+func (e *WipsEvent) RefList() []string {
+	written := ParseRefs(e.Content)
+	var refs []string
+	for _, id := range e.Refs {
+		for _, prefix := range written {
+			if strings.HasPrefix(id, prefix) {
+				refs = append(refs, id)
+				break
+			}
+		}
+	}
+	return refs
+}
+
+// AddRefs records references to the events with the given IDs.
+func (e *WipsEvent) AddRefs(ids ...string) {
+	for _, id := range ids {
+		if !contains(e.Refs, id) {
+			e.Refs = append(e.Refs, id)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRefs(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"see [[01HQ3K]]", []string{"01HQ3K"}},
+		{"[[01hq3k]] and [[01HQ3K]], [[01HQ4Z]]", []string{"01HQ3K", "01HQ4Z"}},
+		{"wiki links like [[Notes]] or [[2024 plan]]", nil},
+		{"too short [[01H]], not an ID [[01HQIL]]", nil},
+		{"[01HQ3K]", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ParseRefs(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRefs(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestRefList(t *testing.T) {
+	e := WipsEvent{Content: "see [[01HQ3K]]"}
+	e.AddRefs("01HQ3KAAAAAAAAAAAAAAAAAAAA", "01HQ4ZAAAAAAAAAAAAAAAAAAAA")
+	e.AddRefs("01HQ3KAAAAAAAAAAAAAAAAAAAA")
+
+	if len(e.Refs) != 2 {
+		t.Errorf("Refs = %q, want two IDs", e.Refs)
+	}
+	// The second reference is no longer written in the content
+	want := []string{"01HQ3KAAAAAAAAAAAAAAAAAAAA"}
+	if got := e.RefList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RefList() = %q, want %q", got, want)
+	}
+}
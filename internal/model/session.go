@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// SessionAction is what a session event records.
+type SessionAction string
+
+const (
+	SessionStart  SessionAction = "start"
+	SessionPause  SessionAction = "pause"
+	SessionResume SessionAction = "resume"
+	SessionStop   SessionAction = "stop"
+)
+
+// Session is the Meta of a session event, recorded by 'wip start', 'wip pause',
+// 'wip resume' and 'wip stop'. The events after the start refer to it, and copy
+// its Content (the topic), tags and context.
+type Session struct {
+	Action  SessionAction `json:"action"`
+	Start   string        `json:"start,omitempty"`   // ID of the start event, for the other actions
+	Elapsed time.Duration `json:"elapsed,omitempty"` // Time worked in the session, without pauses; set on stop
+}
+
+// MetaSession is the Meta key holding the Session of a session event.
+const MetaSession = "session"
+
+// StartID returns the ID of the start event of the session.
+func (s *Session) StartID(e *WipsEvent) string {
+	if s.Action == SessionStart {
+		return e.ID
+	}
+	return s.Start
+}
+
+// SessionMeta returns the session action recorded by a session event, or nil for other events.
+func (e *WipsEvent) SessionMeta() *Session {
+	if e.Type != EventTypeSession {
+		return nil
+	}
+	var s Session
+	if found, err := e.GetMeta(MetaSession, &s); !found || err != nil {
+		return nil
+	}
+	return &s
+}
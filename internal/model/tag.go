@@ -0,0 +1,109 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ParseTags returns the #tags written in content, lowercased and without the '#',
+// in order of first appearance. A tag starts with a letter and runs until a
+// character other than a letter, digit, '-', '_' or '/', so issue references
+// like #123, URL fragments and markdown headings are not tags.
+func ParseTags(content string) []string {
+	var tags []string
+	runes := []rune(content)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && (isTagChar(runes[i-1]) || runes[i-1] == '#' || runes[i-1] == '&')) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isTagChar(runes[end]) {
+			end++
+		}
+		tag := strings.TrimRight(string(runes[i+1:end]), "-_/")
+		i = end - 1
+		if tag == "" || !unicode.IsLetter([]rune(tag)[0]) {
+			continue
+		}
+		tags = addTag(tags, strings.ToLower(tag))
+	}
+	return tags
+}
+
+// NormalizeTag returns tag as it is stored: lowercased and without a leading '#'.
+// It reports false if tag is not a valid tag.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	parsed := ParseTags("#" + tag)
+	if len(parsed) != 1 || parsed[0] != strings.ToLower(tag) {
+		return "", false
+	}
+	return parsed[0], true
+}
+
+func isTagChar(r rune) bool {
+	return r == '-' || r == '_' || r == '/' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// TagList returns the tags of the event. Events recorded before tags were
+// stored have theirs parsed from the content.
+func (e *WipsEvent) TagList() []string {
+	if e.Tags != nil {
+		return e.Tags
+	}
+	return ParseTags(e.Content)
+}
+
+// HasTag reports whether the event has tag (case-insensitive, with or without the '#').
+func (e *WipsEvent) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	for _, t := range e.TagList() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTags replaces the tags of the event.
+func (e *WipsEvent) SetTags(tags []string) {
+	if len(tags) == 0 {
+		tags = nil
+	}
+	e.Tags = tags
+}
+
+// SetContent replaces the content of the event and updates the tags written in it:
+// tags no longer written are removed and new ones added. Tags added with
+// 'wip tag add' are kept.
+func (e *WipsEvent) SetContent(content string) {
+	old, written := ParseTags(e.Content), ParseTags(content)
+	var tags []string
+	for _, t := range e.TagList() {
+		if !contains(old, t) || contains(written, t) {
+			tags = append(tags, t)
+		}
+	}
+	for _, t := range written {
+		tags = addTag(tags, t)
+	}
+	e.Content = content
+	e.SetTags(tags)
+}
+
+// addTag appends tag to tags unless it is already there.
+func addTag(tags []string, tag string) []string {
+	if contains(tags, tag) {
+		return tags
+	}
+	return append(tags, tag)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
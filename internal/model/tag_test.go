@@ -0,0 +1,91 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"fix #bug", []string{"bug"}},
+		{"#Bug at start", []string{"bug"}},
+		{"fix #bugfix", []string{"bugfix"}},
+		{"fix #bug-123.", []string{"bug-123"}},
+		{"fix #bug, later #bugfix and #BUG", []string{"bug", "bugfix"}},
+		{"#api/v2 #wip-", []string{"api/v2", "wip"}},
+		{"no tags", nil},
+		{"closes #123", nil},
+		{"# Heading\n## Sub", nil},
+		{"see https://example.com/page#anchor and a&#39;b", nil},
+		{"日本語 #設計、#レビュー", []string{"設計", "レビュー"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseTags(tt.content); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   string
+		wantOK bool
+	}{
+		{"bug", "bug", true},
+		{"#Bug", "bug", true},
+		{"api/v2", "api/v2", true},
+		{"two words", "", false},
+		{"123", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeTag(tt.tag)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("NormalizeTag(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestWipsEvent_HasTag(t *testing.T) {
+	tests := []struct {
+		name  string
+		event WipsEvent
+		tag   string
+		want  bool
+	}{
+		{"Stored tag", WipsEvent{Content: "fix", Tags: []string{"bug"}}, "bug", true},
+		{"Prefix of a tag", WipsEvent{Tags: []string{"bugfix"}}, "bug", false},
+		{"Case and hash", WipsEvent{Tags: []string{"bug"}}, "#Bug", true},
+		{"Legacy event", WipsEvent{Content: "fix #bug"}, "bug", true},
+		{"Stored tags win over content", WipsEvent{Content: "fix #bug", Tags: []string{"other"}}, "bug", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.HasTag(tt.tag); got != tt.want {
+				t.Errorf("HasTag(%q) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWipsEvent_SetContent(t *testing.T) {
+	e := WipsEvent{Content: "fix #bug in #parser", Tags: []string{"bug", "parser", "urgent"}}
+
+	e.SetContent("fix #parser, #regression")
+	if want := []string{"parser", "urgent", "regression"}; !reflect.DeepEqual(e.Tags, want) {
+		t.Errorf("Tags = %q, want %q", e.Tags, want)
+	}
+
+	e = WipsEvent{Content: "#bug"}
+	e.SetContent("no tags")
+	if e.Tags != nil || len(e.TagList()) != 0 {
+		t.Errorf("Tags = %q, want none", e.TagList())
+	}
+}
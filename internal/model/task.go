@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Done marks a task event as completed, stored in Meta by 'wip done'.
+type Done struct {
+	At time.Time `json:"at"`
+}
+
+// MetaDone is the Meta key holding the Done marker of a completed task.
+const MetaDone = "done"
+
+// Done returns the completion marker of a task event, or nil if it is open or not a task.
+func (e *WipsEvent) Done() *Done {
+	if e.Type != EventTypeTask {
+		return nil
+	}
+	var d Done
+	if found, err := e.GetMeta(MetaDone, &d); !found || err != nil {
+		return nil
+	}
+	return &d
+}
+
+// SetDone marks the task as completed, or reopens it if d is nil.
+func (e *WipsEvent) SetDone(d *Done) error {
+	if d == nil {
+		return e.SetMeta(MetaDone, nil)
+	}
+	return e.SetMeta(MetaDone, d)
+}
+
+// IsOpenTask reports whether the event is a task that is not done yet.
+func (e *WipsEvent) IsOpenTask() bool {
+	return e.Type == EventTypeTask && e.Done() == nil
+}
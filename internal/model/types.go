@@ -1,7 +1,9 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -9,9 +11,17 @@ import (
 type EventType string
 
 const (
-	EventTypeNote      EventType = "note"
-	EventTypeGitCommit EventType = "git_commit"
-	EventTypeUndo      EventType = "undo"
+	EventTypeNote        EventType = "note"
+	EventTypeGitCommit   EventType = "git_commit"
+	EventTypeGitCheckout EventType = "git_checkout"
+	EventTypeGitMerge    EventType = "git_merge"
+	EventTypeGitRewrite  EventType = "git_rewrite"
+	EventTypeGitPush     EventType = "git_push"
+	EventTypeCommand     EventType = "command"
+	EventTypeDir         EventType = "dir"
+	EventTypeTask        EventType = "task"
+	EventTypeSession     EventType = "session"
+	EventTypeUndo        EventType = "undo"
 )
 
 // WipsEvent represents a single event log in the WIPS system.
@@ -29,6 +39,16 @@ type WipsEvent struct {
 	// Content is the main payload of the event (the note text or commit message).
 	Content string `json:"content"`
 
+	// Tags are the event's tags, lowercased and without the '#': those written in
+	// the content as #tag when it was recorded, and those added with 'wip tag add'.
+	Tags []string `json:"tags,omitempty"`
+
+	// ParentID is the ID of the event this one replies to, set by 'wip reply'.
+	ParentID string `json:"parentId,omitempty"`
+
+	// Refs are the IDs of the events referenced in the content as [[id-prefix]].
+	Refs []string `json:"refs,omitempty"`
+
 	// Ctx contains environmental context associated with the event (repository, cwd, etc.).
 	Ctx Context `json:"ctx"`
 
@@ -43,8 +63,32 @@ type Context struct {
 	EnvID  *string `json:"envId,omitempty"`
 	Branch string  `json:"branch,omitempty"`
 	Head   string  `json:"head,omitempty"`
+	// Worktree is the root of the linked worktree the event was recorded in,
+	// when it is not the repository's main working tree.
+	Worktree string `json:"worktree,omitempty"`
+}
+
+// Revision is a previous version of an event's content, kept in Meta by edits.
+// Versions are numbered from 1 (the original content).
+type Revision struct {
+	Rev        int       `json:"rev"`
+	Content    string    `json:"content"`
+	ReplacedAt time.Time `json:"replacedAt"` // When the next version was saved
 }
 
+// MetaRevisions is the Meta key holding the []Revision of an edited event.
+const MetaRevisions = "revisions"
+
+// Trash marks an event as moved to the trash, stored in Meta by delete.
+// Trashed events are hidden from every listing until restored or purged.
+type Trash struct {
+	ID string    `json:"id"` // ULID of the deletion, to tell repeated deletions apart
+	At time.Time `json:"at"`
+}
+
+// MetaTrashed is the Meta key holding the Trash of a deleted event.
+const MetaTrashed = "trashed"
+
 // RepoInfo represents repository information stored in dict/repos.json
 type RepoInfo struct {
 	Name   string `json:"name"`
@@ -59,3 +103,76 @@ type EnvInfo struct {
 	Arch string `json:"arch"`
 	User string `json:"user"`
 }
+
+// GetMeta decodes the value stored under key in Meta into v.
+// It reports whether the key was present.
+func (e *WipsEvent) GetMeta(key string, v interface{}) (bool, error) {
+	if len(e.Meta) == 0 {
+		return false, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(e.Meta, &fields); err != nil {
+		return false, fmt.Errorf("failed to decode meta: %w", err)
+	}
+	raw, ok := fields[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("failed to decode meta %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetMeta stores v under key in Meta, keeping any other keys.
+// A nil v removes the key.
+func (e *WipsEvent) SetMeta(key string, v interface{}) error {
+	fields := make(map[string]json.RawMessage)
+	if len(e.Meta) > 0 {
+		if err := json.Unmarshal(e.Meta, &fields); err != nil {
+			return fmt.Errorf("failed to decode meta: %w", err)
+		}
+	}
+
+	if v == nil {
+		delete(fields, key)
+	} else {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode meta %q: %w", key, err)
+		}
+		fields[key] = raw
+	}
+
+	if len(fields) == 0 {
+		e.Meta = nil
+		return nil
+	}
+	meta, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode meta: %w", err)
+	}
+	e.Meta = meta
+	return nil
+}
+
+// Trashed returns the trash marker of the event, or nil if it is not in the trash.
+func (e *WipsEvent) Trashed() *Trash {
+	// Cheap check first: this runs for every event read
+	if !bytes.Contains(e.Meta, []byte(`"`+MetaTrashed+`"`)) {
+		return nil
+	}
+	var t Trash
+	if found, err := e.GetMeta(MetaTrashed, &t); !found || err != nil {
+		return nil
+	}
+	return &t
+}
+
+// SetTrashed moves the event to the trash, or restores it if t is nil.
+func (e *WipsEvent) SetTrashed(t *Trash) error {
+	if t == nil {
+		return e.SetMeta(MetaTrashed, nil)
+	}
+	return e.SetMeta(MetaTrashed, t)
+}
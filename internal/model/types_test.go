@@ -71,3 +71,40 @@ func TestWipsEvent_JSON(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestWipsEvent_Meta(t *testing.T) {
+	e := WipsEvent{Meta: json.RawMessage(`{"keep":true}`)}
+
+	if err := e.SetMeta("count", 3); err != nil {
+		t.Fatalf("SetMeta() error = %v", err)
+	}
+
+	var count int
+	found, err := e.GetMeta("count", &count)
+	if err != nil || !found || count != 3 {
+		t.Errorf("GetMeta(count) = %v, %v, %v, want 3, true, nil", count, found, err)
+	}
+
+	var keep bool
+	if found, _ := e.GetMeta("keep", &keep); !found || !keep {
+		t.Errorf("SetMeta() dropped existing key, meta = %s", e.Meta)
+	}
+
+	var missing string
+	if found, err := e.GetMeta("missing", &missing); found || err != nil {
+		t.Errorf("GetMeta(missing) = %v, %v, want false, nil", found, err)
+	}
+
+	// Removing every key clears Meta so it is omitted from JSON
+	e.SetMeta("count", nil)
+	e.SetMeta("keep", nil)
+	if e.Meta != nil {
+		t.Errorf("Meta = %s, want nil", e.Meta)
+	}
+
+	// Meta that is not an object cannot be extended
+	e.Meta = json.RawMessage(`[1,2]`)
+	if err := e.SetMeta("count", 1); err == nil {
+		t.Error("SetMeta() on non-object meta expected error")
+	}
+}
@@ -0,0 +1,207 @@
+package query
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// Env holds the data needed to evaluate a query besides the event itself.
+type Env struct {
+	Repos map[string]interface{} // repos dictionary, keyed by RepoID
+	Dirs  map[string]interface{} // dirs dictionary, keyed by CwdID
+
+	// MatchText reports whether the event content matches a text term.
+	// If nil, a case-insensitive substring match is used.
+	MatchText func(e *model.WipsEvent, t *Text) bool
+}
+
+// Match reports whether the event satisfies the query. A nil query matches everything.
+func Match(n Node, e *model.WipsEvent, env *Env) bool {
+	if env == nil {
+		env = &Env{}
+	}
+
+	switch n := n.(type) {
+	case nil:
+		return true
+	case *And:
+		for _, c := range n.Nodes {
+			if !Match(c, e, env) {
+				return false
+			}
+		}
+		return true
+	case *Or:
+		for _, c := range n.Nodes {
+			if Match(c, e, env) {
+				return true
+			}
+		}
+		return false
+	case *Not:
+		return !Match(n.Node, e, env)
+	case *Text:
+		if env.MatchText != nil {
+			return env.MatchText(e, n)
+		}
+		return strings.Contains(strings.ToLower(e.Content), strings.ToLower(strings.TrimSuffix(n.Value, "*")))
+	case *Field:
+		return matchField(n, e, env)
+	}
+	return false
+}
+
+func matchField(f *Field, e *model.WipsEvent, env *Env) bool {
+	switch f.Name {
+	case FieldRepo:
+		if e.Ctx.RepoID == nil {
+			return false
+		}
+		for _, name := range repoNames(env.Repos[*e.Ctx.RepoID]) {
+			if matchPattern(f.Value, name) {
+				return true
+			}
+		}
+		return false
+	case FieldBranch:
+		return e.Ctx.Branch != "" && matchPattern(f.Value, e.Ctx.Branch)
+	case FieldType:
+		return e.Type == typeAliases[strings.ToLower(f.Value)]
+	case FieldTag:
+		return e.HasTag(f.Value)
+	case FieldDir:
+		if e.Ctx.CwdID == nil {
+			return false
+		}
+		dir, ok := env.Dirs[*e.Ctx.CwdID].(string)
+		return ok && matchDir(f.Value, dir)
+	case FieldAfter:
+		return !e.TS.Before(f.Time)
+	case FieldBefore:
+		return e.TS.Before(f.Time)
+	case FieldID:
+		return strings.HasPrefix(strings.ToUpper(e.ID), strings.ToUpper(f.Value))
+	}
+	return false
+}
+
+// repoNames returns the names a repository can be referred to by:
+// its stored name, the base name of its root and the base name of its remote.
+// Entries written by older versions use capitalized keys, so both forms are read.
+func repoNames(entry interface{}) []string {
+	info, ok := entry.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	get := func(keys ...string) string {
+		for _, key := range keys {
+			if v, ok := info[key].(string); ok && v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	var names []string
+	if name := get("name", "Name"); name != "" {
+		names = append(names, name)
+	}
+	if root := get("root", "Root"); root != "" {
+		names = append(names, filepath.Base(root), root)
+	}
+	if remote := get("remote", "Remote"); remote != "" {
+		remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+		if i := strings.LastIndexAny(remote, "/:"); i >= 0 {
+			names = append(names, remote[i+1:])
+		}
+	}
+	return names
+}
+
+// matchPattern matches a value case-insensitively, as a glob if the pattern
+// contains wildcards and exactly otherwise.
+func matchPattern(pattern, value string) bool {
+	pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := path.Match(pattern, value)
+		return err == nil && ok
+	}
+	return pattern == value
+}
+
+// matchDir matches a directory against a glob, or against a path prefix
+// so that dir:~/src also matches its subdirectories. A leading ~ is expanded.
+func matchDir(pattern, dir string) bool {
+	pattern = expandHome(pattern)
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := filepath.Match(pattern, dir)
+		return err == nil && ok
+	}
+	pattern = strings.TrimSuffix(pattern, string(filepath.Separator))
+	return dir == pattern || strings.HasPrefix(dir, pattern+string(filepath.Separator))
+}
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
+
+// TimeBounds returns the time range every match must fall within, as implied
+// by after:/before: terms that are not negated or part of an OR.
+// Zero values mean unbounded; end is inclusive.
+func TimeBounds(n Node) (start, end time.Time) {
+	switch n := n.(type) {
+	case *And:
+		for _, c := range n.Nodes {
+			s, e := TimeBounds(c)
+			if !s.IsZero() && s.After(start) {
+				start = s
+			}
+			if !e.IsZero() && (end.IsZero() || e.Before(end)) {
+				end = e
+			}
+		}
+	case *Field:
+		switch n.Name {
+		case FieldAfter:
+			start = n.Time
+		case FieldBefore:
+			end = n.Time.Add(-time.Nanosecond)
+		}
+	}
+	return start, end
+}
+
+// TextTerms returns the text terms that contribute to a match, i.e. those
+// not under a NOT. They can be used to rank results.
+func TextTerms(n Node) []*Text {
+	var terms []*Text
+	var walk func(Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *And:
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *Or:
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *Text:
+			terms = append(terms, n)
+		}
+	}
+	walk(n)
+	return terms
+}
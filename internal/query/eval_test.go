@@ -0,0 +1,108 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestMatch(t *testing.T) {
+	env := &Env{
+		Repos: map[string]interface{}{
+			// Legacy entries use capitalized keys
+			"r1": map[string]interface{}{"Root": "/home/me/src/wips-cli", "Remote": "git@github.com:rynskrmt/wips-cli.git"},
+			"r2": map[string]interface{}{"name": "dotfiles", "root": "/home/me/dotfiles"},
+		},
+		Dirs: map[string]interface{}{
+			"d1": "/home/me/src/wips-cli/internal",
+			"d2": "/home/me/scratch",
+		},
+	}
+
+	commit := model.WipsEvent{
+		ID:      "01HQZ8X0000000000000000000",
+		TS:      time.Date(2024, 2, 10, 12, 0, 0, 0, time.Local),
+		Type:    model.EventTypeGitCommit,
+		Content: "Fix login redirect #bug",
+		Ctx:     model.Context{RepoID: strPtr("r1"), CwdID: strPtr("d1"), Branch: "feat/login"},
+	}
+	note := model.WipsEvent{
+		ID:      "01HR000000000000000000000",
+		TS:      time.Date(2023, 12, 31, 23, 0, 0, 0, time.Local),
+		Type:    model.EventTypeNote,
+		Content: "Flaky test again #bug #wontfix",
+		Ctx:     model.Context{RepoID: strPtr("r2"), CwdID: strPtr("d2"), Branch: "main"},
+	}
+
+	tests := []struct {
+		query      string
+		wantCommit bool
+		wantNote   bool
+	}{
+		{"", true, true},
+		{"repo:wips-cli", true, false},
+		{"repo:WIPS-*", true, false},
+		{"repo:dotfiles", false, true},
+		{"branch:feat/*", true, false},
+		{"branch:feat", false, false},
+		{"type:commit", true, false},
+		{"type:note", false, true},
+		{"tag:bug", true, true},
+		{"tag:bug -tag:wontfix", true, false},
+		{"tag:bu", false, false},
+		{"dir:/home/me/src", true, false},
+		{"dir:/home/me/s*", false, true},
+		{"after:2024-01-01", true, false},
+		{"before:2024-01-01", false, true},
+		{"id:01hqz", true, false},
+		{"login", true, false},
+		{`"test again"`, false, true},
+		{"login OR flaky", true, true},
+		{"(tag:wontfix OR branch:feat/*) type:note", false, true},
+		{"NOT (repo:wips-cli OR repo:dotfiles)", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			n, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.query, err)
+			}
+			if got := Match(n, &commit, env); got != tt.wantCommit {
+				t.Errorf("Match(%q, commit) = %v, want %v", tt.query, got, tt.wantCommit)
+			}
+			if got := Match(n, &note, env); got != tt.wantNote {
+				t.Errorf("Match(%q, note) = %v, want %v", tt.query, got, tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestMatch_TextMatcher(t *testing.T) {
+	n, err := Parse(`auth -"old api"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	env := &Env{MatchText: func(e *model.WipsEvent, t *Text) bool {
+		seen = append(seen, t.String())
+		return t.Value == "auth"
+	}}
+
+	if !Match(n, &model.WipsEvent{}, env) {
+		t.Error("expected match using the custom text matcher")
+	}
+	if len(seen) != 2 {
+		t.Errorf("text matcher called for %v, want both terms", seen)
+	}
+
+	// Only terms outside NOT contribute to ranking
+	terms := TextTerms(n)
+	if len(terms) != 1 || terms[0].Value != "auth" {
+		t.Errorf("TextTerms = %v, want [auth]", terms)
+	}
+}
@@ -0,0 +1,187 @@
+// Package query implements the search query language used by `wip search`.
+//
+// A query is a list of terms combined with AND (implicit), OR and NOT:
+//
+//	repo:wips-cli branch:feat/* type:commit tag:bug -tag:wontfix "exact phrase" after:2024-01-01
+//	(tag:bug OR tag:regression) NOT dir:~/scratch
+//
+// Bare words and quoted phrases match the event content; field terms match
+// event metadata. Parse errors report the position of the offending input.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokPhrase
+	tokField
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokEOF
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int    // 1-based character position in the query
+	text  string // Word/phrase text, or field value
+	field string // Field name for tokField
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	case tokAnd:
+		return "AND"
+	case tokOr:
+		return "OR"
+	case tokNot:
+		return "NOT"
+	case tokEOF:
+		return "end of query"
+	case tokField:
+		return fmt.Sprintf("%q", t.field+":"+t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// ParseError describes invalid query syntax.
+type ParseError struct {
+	Pos int // 1-based character position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+func errorAt(pos int, format string, args ...interface{}) *ParseError {
+	return &ParseError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lex splits the query into tokens.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, pos: pos})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			tokens = append(tokens, token{kind: tokNot, pos: pos})
+			i++
+		case r == '"':
+			text, next, err := readQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokPhrase, pos: pos, text: text})
+			i = next
+		default:
+			end := i
+			for end < len(runes) && !isDelimiter(runes[end]) {
+				if runes[end] == ':' {
+					break
+				}
+				end++
+			}
+			word := string(runes[i:end])
+
+			if end < len(runes) && runes[end] == ':' && isFieldName(word) {
+				// field:value or field:"quoted value"
+				valueStart := end + 1
+				var value string
+				next := valueStart
+				if valueStart < len(runes) && runes[valueStart] == '"' {
+					v, n, err := readQuoted(runes, valueStart)
+					if err != nil {
+						return nil, err
+					}
+					value, next = v, n
+				} else {
+					for next < len(runes) && !isDelimiter(runes[next]) {
+						next++
+					}
+					value = string(runes[valueStart:next])
+				}
+				if value == "" {
+					return nil, errorAt(pos, "expected a value after %q", word+":")
+				}
+				tokens = append(tokens, token{kind: tokField, pos: pos, field: strings.ToLower(word), text: value})
+				i = next
+				continue
+			}
+
+			// Plain word (may contain ':' if it is not a field, e.g. "12:30")
+			for end < len(runes) && !isDelimiter(runes[end]) {
+				end++
+			}
+			word = string(runes[i:end])
+			switch word {
+			case "AND":
+				tokens = append(tokens, token{kind: tokAnd, pos: pos})
+			case "OR":
+				tokens = append(tokens, token{kind: tokOr, pos: pos})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokNot, pos: pos})
+			default:
+				tokens = append(tokens, token{kind: tokWord, pos: pos, text: word})
+			}
+			i = end
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
+
+// readQuoted reads a double-quoted string starting at runes[start] == '"'.
+// It returns the unquoted text and the index after the closing quote.
+func readQuoted(runes []rune, start int) (string, int, error) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end == len(runes) {
+		return "", 0, errorAt(start+1, "unterminated quote")
+	}
+	return string(runes[start+1 : end]), end + 1, nil
+}
+
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+}
+
+func isFieldName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
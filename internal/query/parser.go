@@ -0,0 +1,311 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/tj/go-naturaldate"
+)
+
+// Node is an element of a parsed query.
+type Node interface {
+	String() string
+}
+
+// And matches events matching every child.
+type And struct{ Nodes []Node }
+
+// Or matches events matching at least one child.
+type Or struct{ Nodes []Node }
+
+// Not matches events not matching its child.
+type Not struct{ Node Node }
+
+// Text matches the event content. A bare word may end with * for prefix matching.
+type Text struct {
+	Value  string
+	Phrase bool // Quoted text matched as consecutive words
+}
+
+// Field matches event metadata, e.g. repo:wips-cli.
+type Field struct {
+	Name  string
+	Value string
+	Time  time.Time // Parsed value of after:/before:
+}
+
+func (n *And) String() string { return joinNodes("AND", n.Nodes) }
+func (n *Or) String() string  { return joinNodes("OR", n.Nodes) }
+func (n *Not) String() string { return "(NOT " + n.Node.String() + ")" }
+
+func (n *Text) String() string {
+	if n.Phrase {
+		return fmt.Sprintf("%q", n.Value)
+	}
+	return n.Value
+}
+
+func (n *Field) String() string {
+	if strings.ContainsAny(n.Value, " \t") {
+		return fmt.Sprintf("%s:%q", n.Name, n.Value)
+	}
+	return n.Name + ":" + n.Value
+}
+
+func joinNodes(op string, nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+	}
+	return "(" + op + " " + strings.Join(parts, " ") + ")"
+}
+
+// Field names understood by the query language.
+const (
+	FieldRepo   = "repo"
+	FieldBranch = "branch"
+	FieldType   = "type"
+	FieldTag    = "tag"
+	FieldDir    = "dir"
+	FieldAfter  = "after"
+	FieldBefore = "before"
+	FieldID     = "id"
+)
+
+var knownFields = map[string]bool{
+	FieldRepo: true, FieldBranch: true, FieldType: true, FieldTag: true,
+	FieldDir: true, FieldAfter: true, FieldBefore: true, FieldID: true,
+}
+
+// typeAliases maps the values accepted by type: to event types.
+var typeAliases = map[string]model.EventType{
+	"note":         model.EventTypeNote,
+	"commit":       model.EventTypeGitCommit,
+	"git_commit":   model.EventTypeGitCommit,
+	"checkout":     model.EventTypeGitCheckout,
+	"git_checkout": model.EventTypeGitCheckout,
+	"merge":        model.EventTypeGitMerge,
+	"git_merge":    model.EventTypeGitMerge,
+	"rewrite":      model.EventTypeGitRewrite,
+	"git_rewrite":  model.EventTypeGitRewrite,
+	"push":         model.EventTypeGitPush,
+	"git_push":     model.EventTypeGitPush,
+	"command":      model.EventTypeCommand,
+	"dir":          model.EventTypeDir,
+	"task":         model.EventTypeTask,
+	"todo":         model.EventTypeTask,
+	"session":      model.EventTypeSession,
+	"undo":         model.EventTypeUndo,
+}
+
+// ParseType returns the event type a value of type: stands for, such as commit or todo.
+func ParseType(name string) (model.EventType, error) {
+	t, ok := typeAliases[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("unknown event type %q (expected one of: %s)", name, strings.Join(typeNames(), ", "))
+	}
+	return t, nil
+}
+
+// Parse parses a query string into an AST.
+// An empty query returns a nil Node, which matches every event.
+// Relative dates in after:/before: are resolved against the current time.
+func Parse(input string) (Node, error) {
+	return ParseAt(input, time.Now())
+}
+
+// ParseAt is like Parse but resolves relative dates against now.
+func ParseAt(input string, now time.Time) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	if p.peek().kind == tokEOF {
+		return nil, nil
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokRParen {
+			return nil, errorAt(t.pos, "unexpected ')' without matching '('")
+		}
+		return nil, errorAt(t.pos, "unexpected %s", t.describe())
+	}
+	return n, nil
+}
+
+// parser is a recursive descent parser over the grammar:
+//
+//	or    = and { "OR" and }
+//	and   = unary { ["AND"] unary }
+//	unary = ("NOT" | "-") unary | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for {
+		t := p.peek()
+		if t.kind == tokAnd {
+			p.next()
+		} else if !startsOperand(t.kind) {
+			break
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &And{Nodes: nodes}, nil
+}
+
+func startsOperand(k tokenKind) bool {
+	switch k {
+	case tokWord, tokPhrase, tokField, tokLParen, tokNot:
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNot:
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Node: n}, nil
+	case tokLParen:
+		if p.peek().kind == tokRParen {
+			return nil, errorAt(t.pos, "empty parentheses")
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, errorAt(t.pos, "missing ')' to close '('")
+		}
+		p.next()
+		return n, nil
+	case tokWord:
+		return &Text{Value: t.text}, nil
+	case tokPhrase:
+		return &Text{Value: t.text, Phrase: true}, nil
+	case tokField:
+		return p.parseField(t)
+	default:
+		return nil, errorAt(t.pos, "expected a search term but found %s", t.describe())
+	}
+}
+
+func (p *parser) parseField(t token) (Node, error) {
+	if !knownFields[t.field] {
+		return nil, errorAt(t.pos, "unknown field %q (known fields: %s); quote the term to search for it as text",
+			t.field, strings.Join(fieldNames(), ", "))
+	}
+
+	f := &Field{Name: t.field, Value: t.text}
+	switch t.field {
+	case FieldType:
+		if _, err := ParseType(t.text); err != nil {
+			return nil, errorAt(t.pos, "%s", err)
+		}
+	case FieldAfter, FieldBefore:
+		ts, err := ParseDate(t.text, p.now)
+		if err != nil {
+			return nil, errorAt(t.pos, "invalid date %q for \"%s:\" (use YYYY-MM-DD or a relative date like yesterday)", t.text, t.field)
+		}
+		f.Time = ts
+	case FieldTag:
+		f.Value = strings.TrimPrefix(t.text, "#")
+	}
+	return f, nil
+}
+
+// ParseDate parses an absolute date (YYYY-MM-DD) or a natural language date
+// and returns the start of that day in the local timezone.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	t, err := naturaldate.Parse(s, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// naturaldate returns the reference time unchanged for input it does not understand
+	if t.Equal(now) {
+		return time.Time{}, fmt.Errorf("unrecognized date")
+	}
+	t = t.In(now.Location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location()), nil
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(knownFields))
+	for name := range knownFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func typeNames() []string {
+	names := make([]string, 0, len(typeAliases))
+	for name := range typeAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,135 @@
+package query
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "   ", "<nil>"},
+		{"single word", "auth", "auth"},
+		{"implicit and", "auth bug", "(AND auth bug)"},
+		{"explicit and", "auth AND bug", "(AND auth bug)"},
+		{"phrase", `"exact phrase"`, `"exact phrase"`},
+		{"fields", "repo:wips-cli branch:feat/* type:commit", "(AND repo:wips-cli branch:feat/* type:commit)"},
+		{"quoted field value", `dir:"/tmp/my dir"`, `dir:"/tmp/my dir"`},
+		{"negation with dash", "tag:bug -tag:wontfix", "(AND tag:bug (NOT tag:wontfix))"},
+		{"negation with NOT", "NOT auth", "(NOT auth)"},
+		{"tag hash is stripped", "tag:#bug", "tag:bug"},
+		{"or binds looser than and", "a b OR c", "(OR (AND a b) c)"},
+		{"parentheses", "(a OR b) c", "(AND (OR a b) c)"},
+		{"negated group", "-(a OR b)", "(NOT (OR a b))"},
+		{"hyphen inside word", "foo-bar", "foo-bar"},
+		{"colon in non-field word", "12:30", "12:30"},
+		{"lowercase or is a word", "a or b", "(AND a or b)"},
+		{
+			"full example",
+			`repo:wips-cli branch:feat/* type:commit tag:bug -tag:wontfix "exact phrase" after:2024-01-01`,
+			`(AND repo:wips-cli branch:feat/* type:commit tag:bug (NOT tag:wontfix) "exact phrase" after:2024-01-01)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			got := "<nil>"
+			if n != nil {
+				got = n.String()
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{`"unterminated`, 1, "unterminated quote"},
+		{`tag:"bug`, 5, "unterminated quote"},
+		{"(a OR b", 1, "missing ')'"},
+		{"a OR b)", 7, "unexpected ')'"},
+		{"()", 1, "empty parentheses"},
+		{"a OR", 5, "expected a search term but found end of query"},
+		{"a AND OR b", 7, "expected a search term but found OR"},
+		{"repo:", 1, `expected a value after "repo:"`},
+		{"foo:bar", 1, `unknown field "foo"`},
+		{"type:commmit", 1, `unknown event type "commmit"`},
+		{"x after:someday", 3, `invalid date "someday"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if err == nil {
+				t.Fatalf("Parse(%q) expected error", tt.input)
+			}
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("Parse(%q) error type = %T, want *ParseError", tt.input, err)
+			}
+			if perr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error position = %d, want %d (%v)", tt.input, perr.Pos, tt.pos, err)
+			}
+			if !strings.Contains(perr.Msg, tt.message) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.input, perr.Msg, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for name, want := range map[string]model.EventType{"note": model.EventTypeNote, "Todo": model.EventTypeTask, "push": model.EventTypeGitPush} {
+		if got, err := ParseType(name); err != nil || got != want {
+			t.Errorf("ParseType(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseType("commmit"); err == nil || !strings.Contains(err.Error(), "expected one of") {
+		t.Errorf("ParseType(%q) error = %v, want unknown event type", "commmit", err)
+	}
+}
+
+func TestParse_Dates(t *testing.T) {
+	now := time.Date(2024, 3, 15, 14, 30, 0, 0, time.Local)
+
+	n, err := ParseAt("after:2024-01-01 before:yesterday", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	and := n.(*And)
+	if got, want := and.Nodes[0].(*Field).Time, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("after: = %v, want %v", got, want)
+	}
+	if got, want := and.Nodes[1].(*Field).Time, time.Date(2024, 3, 14, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("before: = %v, want %v", got, want)
+	}
+
+	start, end := TimeBounds(n)
+	if !start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("TimeBounds start = %v", start)
+	}
+	if !end.Equal(time.Date(2024, 3, 14, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)) {
+		t.Errorf("TimeBounds end = %v", end)
+	}
+
+	// Bounds under OR or NOT do not constrain the range
+	n, _ = ParseAt("after:2024-01-01 OR -before:2024-02-01", now)
+	if start, end := TimeBounds(n); !start.IsZero() || !end.IsZero() {
+		t.Errorf("TimeBounds(%s) = %v, %v, want unbounded", n, start, end)
+	}
+}
@@ -0,0 +1,228 @@
+// Package shell generates the shell integration snippets and reads the spool file they append to.
+//
+// The snippets run on every prompt, so they never start wip themselves: they only
+// append a line per event to the spool file, and start 'wip shell flush' in the
+// background once in a while to turn the spooled lines into events.
+package shell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// SpoolFile is the name of the spool file in the data directory.
+const SpoolFile = "shell.spool"
+
+// The snippets start a flush after this many spooled lines, or this many seconds after the last one.
+const (
+	flushBatch    = 10
+	flushInterval = 300
+)
+
+// Options configure a snippet.
+type Options struct {
+	Spool     string        // Path of the spool file
+	Dirs      bool          // Spool directory changes
+	Commands  bool          // Spool commands running at least Threshold
+	Threshold time.Duration // Rounded down to seconds
+}
+
+// Shells lists the supported shells.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Snippet returns the integration code for shell, to be evaluated by the shell at startup.
+func Snippet(shell string, opts Options) (string, error) {
+	src, ok := snippets[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell: %s (expected one of: %s)", shell, strings.Join(Shells, ", "))
+	}
+	quote := singleQuote
+	if shell == "fish" {
+		quote = fishQuote
+	}
+
+	tmpl, err := template.New(shell).Funcs(template.FuncMap{"quote": quote}).Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	err = tmpl.Execute(&b, map[string]interface{}{
+		"Spool":     opts.Spool,
+		"Dirs":      opts.Dirs,
+		"Commands":  opts.Commands,
+		"Threshold": int(opts.Threshold.Seconds()),
+		"Batch":     flushBatch,
+		"Interval":  flushInterval,
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// singleQuote quotes s for bash and zsh.
+func singleQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish, where a backslash escapes a quote or a backslash inside single quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// Kind is the kind of a spooled line.
+type Kind string
+
+const (
+	KindDir     Kind = "cd"  // cd <time> <dir>
+	KindCommand Kind = "cmd" // cmd <time> <dir> <exit code> <seconds> <command line>
+)
+
+// Entry is a line of the spool file. Fields are separated by tabs.
+type Entry struct {
+	Kind     Kind
+	At       time.Time
+	Dir      string
+	ExitCode int           // KindCommand only
+	Duration time.Duration // KindCommand only
+	Command  string        // KindCommand only
+}
+
+// Parse reads the entries of a spool file. Lines it cannot parse, such as a line cut
+// short when the disk was full, are skipped.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if e, ok := parseLine(scanner.Text()); ok {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spool: %w", err)
+	}
+	return entries, nil
+}
+
+func parseLine(line string) (Entry, bool) {
+	fields := strings.SplitN(line, "\t", 6)
+	if len(fields) < 3 || fields[2] == "" {
+		return Entry{}, false
+	}
+	secs, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Entry{}, false
+	}
+	e := Entry{Kind: Kind(fields[0]), At: time.Unix(secs, 0), Dir: fields[2]}
+
+	switch e.Kind {
+	case KindDir:
+		return e, len(fields) == 3
+	case KindCommand:
+		if len(fields) != 6 || strings.TrimSpace(fields[5]) == "" {
+			return Entry{}, false
+		}
+		if e.ExitCode, err = strconv.Atoi(fields[3]); err != nil {
+			return Entry{}, false
+		}
+		d, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return Entry{}, false
+		}
+		e.Duration = time.Duration(d) * time.Second
+		e.Command = strings.TrimSpace(fields[5])
+		return e, true
+	}
+	return Entry{}, false
+}
+
+// formatLine returns the spool line of an entry, the reverse of parseLine.
+func formatLine(e Entry) string {
+	fields := []string{string(e.Kind), strconv.FormatInt(e.At.Unix(), 10), e.Dir}
+	if e.Kind == KindCommand {
+		fields = append(fields, strconv.Itoa(e.ExitCode), strconv.Itoa(int(e.Duration/time.Second)), e.Command)
+	}
+	return strings.Join(fields, "\t")
+}
+
+// Drain passes the entries spooled at path to fn and removes them once fn succeeds.
+// The spool is moved aside first, so the shells keep appending to a new file meanwhile.
+// If another flush is running, Drain returns without doing anything.
+//
+// fn returns the number of entries it is done with. If it fails, only the
+// entries after those are kept for the next flush, so none is recorded twice.
+func Drain(path string, fn func([]Entry) (int, error)) error {
+	lock := flock.New(path + ".lock")
+	locked, err := lock.TryLock()
+	if err != nil {
+		return fmt.Errorf("failed to lock spool: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer lock.Unlock()
+
+	// A previous flush that failed left its entries here
+	flushing := path + ".flushing"
+	if _, err := os.Stat(flushing); os.IsNotExist(err) {
+		if err := os.Rename(path, flushing); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to move spool: %w", err)
+		}
+	}
+
+	f, err := os.Open(flushing)
+	if err != nil {
+		return fmt.Errorf("failed to open spool: %w", err)
+	}
+	entries, err := Parse(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	done, err := fn(entries)
+	if err != nil {
+		if done > 0 {
+			if werr := writeEntries(flushing, entries[done:]); werr != nil {
+				return fmt.Errorf("%w (and failed to keep the remaining entries: %v)", err, werr)
+			}
+		}
+		return err
+	}
+	return os.Remove(flushing)
+}
+
+// writeEntries replaces the file at path with the given entries.
+func writeEntries(path string, entries []Entry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, e := range entries {
+		fmt.Fprintln(w, formatLine(e))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
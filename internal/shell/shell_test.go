@@ -0,0 +1,119 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnippet(t *testing.T) {
+	opts := Options{Spool: "/data/it's/shell.spool", Dirs: true, Commands: true, Threshold: 15 * time.Second}
+
+	for _, sh := range Shells {
+		t.Run(sh, func(t *testing.T) {
+			got, err := Snippet(sh, opts)
+			if err != nil {
+				t.Fatalf("Snippet() error = %v", err)
+			}
+			for _, want := range []string{"wip shell flush", " 15", "'cd\\t"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("Snippet() does not contain %q:\n%s", want, got)
+				}
+			}
+
+			// Check the syntax when the shell is installed
+			if path, err := exec.LookPath(sh); err == nil {
+				flag := "-n"
+				if sh == "fish" {
+					flag = "--no-execute"
+				}
+				cmd := exec.Command(path, flag)
+				cmd.Stdin = strings.NewReader(got)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("%s %s: %v\n%s", sh, flag, err, out)
+				}
+			}
+		})
+	}
+
+	bash, err := Snippet("bash", Options{Spool: "/tmp/s", Dirs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(bash, "'cmd\\t") || !strings.Contains(bash, `__wip_spool='/tmp/s'`) {
+		t.Errorf("Snippet() without commands:\n%s", bash)
+	}
+
+	if _, err := Snippet("tcsh", opts); err == nil {
+		t.Error("Snippet() should reject unsupported shells")
+	}
+}
+
+func TestParse(t *testing.T) {
+	spool := "cd\t1700000000\t/work/app\n" +
+		"cmd\t1700000100\t/work/app\t1\t42\tgo test ./...\twith a tab\n" +
+		"cmd\t1700000200\t/work/app\t0\n" + // Cut short
+		"cd\tnot-a-time\t/work/app\n" +
+		"cmd\t1700000300\t/work/app\t0\t12\t  \n"
+
+	got, err := Parse(strings.NewReader(spool))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Parse() returned %d entries, want 2: %+v", len(got), got)
+	}
+	if got[0].Kind != KindDir || got[0].Dir != "/work/app" || got[0].At.Unix() != 1700000000 {
+		t.Errorf("entry 0 = %+v", got[0])
+	}
+	want := Entry{Kind: KindCommand, At: time.Unix(1700000100, 0), Dir: "/work/app", ExitCode: 1, Duration: 42 * time.Second, Command: "go test ./...\twith a tab"}
+	if got[1] != want {
+		t.Errorf("entry 1 = %+v, want %+v", got[1], want)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_shell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, SpoolFile)
+
+	// Nothing spooled
+	called := false
+	if err := Drain(path, func([]Entry) (int, error) { called = true; return 0, nil }); err != nil || called {
+		t.Fatalf("Drain() of a missing spool = %v, called = %v", err, called)
+	}
+
+	spool := "cd\t1700000000\t/work/app\n" +
+		"cmd\t1700000100\t/work/app\t1\t42\tgo test ./...\twith a tab\n" +
+		"cd\t1700000200\t/work/lib\n"
+	if err := os.WriteFile(path, []byte(spool), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var all []Entry
+	if err := Drain(path, func(entries []Entry) (int, error) { all = entries; return 0, os.ErrClosed }); err == nil {
+		t.Fatal("Drain() should return the error of fn")
+	}
+	// A failure keeps the entries not done yet for the next flush
+	if err := Drain(path, func([]Entry) (int, error) { return 1, os.ErrClosed }); err == nil {
+		t.Fatal("Drain() should return the error of fn")
+	}
+	var got []Entry
+	if err := Drain(path, func(entries []Entry) (int, error) { got = entries; return len(entries), nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || !reflect.DeepEqual(got, all[1:]) {
+		t.Errorf("Drain() passed %+v after a failure, want %+v", got, all[1:])
+	}
+	for _, p := range []string{path, path + ".flushing"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", p)
+		}
+	}
+}
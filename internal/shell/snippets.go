@@ -0,0 +1,132 @@
+package shell
+
+// snippets are the templates of the integration code, by shell.
+// Each one spools tab-separated lines in the format read by Parse.
+var snippets = map[string]string{
+	"bash": bashSnippet,
+	"zsh":  zshSnippet,
+	"fish": fishSnippet,
+}
+
+// bashSnippet needs bash 4.2 or later for printf's %(...)T.
+// Commands are read back from the history, which keeps their start time.
+const bashSnippet = `# wip shell integration for bash. Add to ~/.bashrc: eval "$(wip shell init bash)"
+__wip_spool={{quote .Spool}}
+__wip_dir=$PWD
+__wip_hist=
+__wip_pending=0
+printf -v __wip_flushed '%(%s)T' -1
+
+__wip_flush_check() {
+  __wip_pending=$((__wip_pending + 1))
+  if [ "$__wip_pending" -ge {{.Batch}} ] || [ $(($1 - __wip_flushed)) -ge {{.Interval}} ]; then
+    __wip_pending=0
+    __wip_flushed=$1
+    (command wip shell flush >/dev/null 2>&1 &)
+  fi
+}
+
+__wip_prompt() {
+  local status=$? now
+  printf -v now '%(%s)T' -1
+{{- if .Commands}}
+  local num start cmd
+  read -r num start cmd <<< "$(HISTTIMEFORMAT='%s ' builtin history 1)"
+  if [ -n "$__wip_hist" ] && [ "$num" != "$__wip_hist" ] && [ $((now - start)) -ge {{.Threshold}} ]; then
+    printf 'cmd\t%s\t%s\t%s\t%s\t%s\n' "$now" "$PWD" "$status" "$((now - start))" "$cmd" >> "$__wip_spool" 2>/dev/null &&
+      __wip_flush_check "$now"
+  fi
+  __wip_hist=$num
+{{- end}}
+{{- if .Dirs}}
+  if [ "$PWD" != "$__wip_dir" ]; then
+    __wip_dir=$PWD
+    printf 'cd\t%s\t%s\n' "$now" "$PWD" >> "$__wip_spool" 2>/dev/null &&
+      __wip_flush_check "$now"
+  fi
+{{- end}}
+  return $status
+}
+
+# Run first, to see the exit code of the command
+case ";$PROMPT_COMMAND;" in
+  *";__wip_prompt;"*) ;;
+  *) PROMPT_COMMAND="__wip_prompt${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+`
+
+const zshSnippet = `# wip shell integration for zsh. Add to ~/.zshrc: eval "$(wip shell init zsh)"
+zmodload zsh/datetime
+typeset -g __wip_spool={{quote .Spool}} __wip_dir=$PWD __wip_cmd= __wip_start= __wip_pending=0 __wip_flushed=$EPOCHSECONDS
+
+__wip_flush_check() {
+  if (( ++__wip_pending >= {{.Batch}} || $1 - __wip_flushed >= {{.Interval}} )); then
+    __wip_pending=0
+    __wip_flushed=$1
+    (command wip shell flush >/dev/null 2>&1 &)
+  fi
+}
+
+__wip_preexec() {
+  __wip_cmd=$1
+  __wip_start=$EPOCHSECONDS
+}
+
+__wip_precmd() {
+  local st=$? now=$EPOCHSECONDS
+{{- if .Commands}}
+  if [[ -n $__wip_start ]] && (( now - __wip_start >= {{.Threshold}} )); then
+    printf 'cmd\t%s\t%s\t%s\t%s\t%s\n' "$now" "$PWD" "$st" "$(( now - __wip_start ))" "${__wip_cmd//$'\n'/ }" >> "$__wip_spool" 2>/dev/null &&
+      __wip_flush_check "$now"
+  fi
+  __wip_start=
+{{- end}}
+{{- if .Dirs}}
+  if [[ $PWD != "$__wip_dir" ]]; then
+    __wip_dir=$PWD
+    printf 'cd\t%s\t%s\n' "$now" "$PWD" >> "$__wip_spool" 2>/dev/null &&
+      __wip_flush_check "$now"
+  fi
+{{- end}}
+}
+
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __wip_preexec
+add-zsh-hook precmd __wip_precmd
+`
+
+const fishSnippet = `# wip shell integration for fish. Add to ~/.config/fish/config.fish: wip shell init fish | source
+set -g __wip_spool {{quote .Spool}}
+set -g __wip_pending 0
+set -g __wip_flushed (date +%s)
+
+function __wip_flush_check
+    set __wip_pending (math $__wip_pending + 1)
+    if test $__wip_pending -ge {{.Batch}}; or test (math $argv[1] - $__wip_flushed) -ge {{.Interval}}
+        set __wip_pending 0
+        set __wip_flushed $argv[1]
+        command wip shell flush >/dev/null 2>&1 &
+        disown
+    end
+end
+{{- if .Commands}}
+
+function __wip_postexec --on-event fish_postexec
+    set -l st $status
+    set -l secs (math --scale=0 "$CMD_DURATION / 1000")
+    if test $secs -ge {{.Threshold}}
+        set -l now (date +%s)
+        printf 'cmd\t%s\t%s\t%s\t%s\t%s\n' $now $PWD $st $secs (string replace -a \n ' ' -- $argv[1]) >>$__wip_spool 2>/dev/null
+        and __wip_flush_check $now
+    end
+end
+{{- end}}
+{{- if .Dirs}}
+
+function __wip_cd --on-variable PWD
+    set -l now (date +%s)
+    printf 'cd\t%s\t%s\n' $now $PWD >>$__wip_spool 2>/dev/null
+    and __wip_flush_check $now
+end
+{{- end}}
+`
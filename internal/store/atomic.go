@@ -0,0 +1,102 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gofrs/flock"
+)
+
+// lockFile returns the lock guarding path.
+// The lock lives in a separate file so that it stays valid when path is
+// replaced by writeFileAtomic: a lock held on the replaced inode would not
+// exclude processes opening the new one.
+func lockFile(path string) *flock.Flock {
+	return flock.New(path + ".lock")
+}
+
+// Steps of writeFileAtomic passed to failpoint.
+const (
+	stepWrite  = "write"
+	stepSync   = "sync"
+	stepRename = "rename"
+)
+
+// failpoint is called before each step of writeFileAtomic (and before every
+// write to the temp file). Tests replace it to simulate a crash or a full disk;
+// a non-nil error aborts the rewrite at that point.
+var failpoint = func(step string) error { return nil }
+
+// failWriter consults failpoint before every write.
+type failWriter struct {
+	w io.Writer
+}
+
+func (fw failWriter) Write(p []byte) (int, error) {
+	if err := failpoint(stepWrite); err != nil {
+		return 0, err
+	}
+	return fw.w.Write(p)
+}
+
+// writeFileAtomic replaces path with the output of write.
+// The content goes to a temp file in the same directory, which is synced to
+// disk and renamed over path, so readers see either the old or the new content
+// and a failure at any point leaves the original untouched.
+// The caller must hold the exclusive lock of path.
+func writeFileAtomic(path string, write func(w io.Writer) error) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %w", path, err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set mode of %s: %w", tmp.Name(), err)
+	}
+
+	bw := bufio.NewWriter(tmp)
+	if err := write(failWriter{w: bw}); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+
+	if err := failpoint(stepSync); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmp.Name(), err)
+	}
+
+	if err := failpoint(stepRename); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	// Persist the rename itself; not every platform supports syncing a directory
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// failAt makes the n-th call of the given step fail until the returned func is called.
+func failAt(step string, n int) func() {
+	calls := 0
+	failpoint = func(s string) error {
+		if s != step {
+			return nil
+		}
+		calls++
+		if calls == n {
+			return errors.New("injected failure at " + step)
+		}
+		return nil
+	}
+	return func() { failpoint = func(string) error { return nil } }
+}
+
+func TestFileStore_RewritesAreAtomic(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, _ := NewStore(tempDir)
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		at := ts.Add(time.Duration(i) * time.Minute)
+		e := &model.WipsEvent{ID: ulid.MustNew(ulid.Timestamp(at), nil).String(), TS: at, Type: model.EventTypeNote, Content: "note"}
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, e.ID)
+	}
+	if err := s.SaveDict("dirs", "d1", "/src/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	eventsPath := filepath.Join(tempDir, "events", "2024-03.ndjson")
+	dictPath := filepath.Join(tempDir, "dict", "dirs.json")
+
+	tests := []struct {
+		name  string
+		step  string
+		nth   int
+		apply func() error
+		path  string
+	}{
+		{"update fails mid-write", stepWrite, 2, func() error {
+			return s.UpdateEvent(ids[0], func(e *model.WipsEvent) error { e.Content = "edited"; return nil })
+		}, eventsPath},
+		{"update fails before sync", stepSync, 1, func() error {
+			return s.UpdateEvent(ids[1], func(e *model.WipsEvent) error { e.Content = "edited"; return nil })
+		}, eventsPath},
+		{"delete fails before rename", stepRename, 1, func() error {
+			return s.DeleteEvent(ids[2])
+		}, eventsPath},
+		{"dict fails mid-write", stepWrite, 1, func() error {
+			return s.SaveDict("dirs", "d2", "/src/b")
+		}, dictPath},
+		{"dict fails before rename", stepRename, 1, func() error {
+			return s.SaveDict("dirs", "d3", "/src/c")
+		}, dictPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			restore := failAt(tt.step, tt.nth)
+			err = tt.apply()
+			restore()
+			if err == nil || !strings.Contains(err.Error(), "injected failure") {
+				t.Fatalf("expected injected failure, got %v", err)
+			}
+
+			after, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(after) != string(before) {
+				t.Errorf("file changed by failed rewrite:\n got %s\nwant %s", after, before)
+			}
+			assertNoTempFiles(t, filepath.Dir(tt.path))
+
+			// The same operation succeeds once the failure is gone
+			if err := tt.apply(); err != nil {
+				t.Fatalf("retry failed: %v", err)
+			}
+		})
+	}
+
+	events, err := s.QueryEvents(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Content != "edited" || events[1].Content != "edited" {
+		t.Errorf("unexpected events after rewrites: %+v", events)
+	}
+	dict, err := s.LoadDict("dirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dict) != 3 {
+		t.Errorf("dict = %v, want 3 entries", dict)
+	}
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temp file left behind: %s", e.Name())
+		}
+	}
+}
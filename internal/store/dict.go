@@ -0,0 +1,30 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// FilledDict is the dictionary of the dictionaries filled from the events
+// recorded before they existed, keyed by name.
+const FilledDict = "filled"
+
+// FillDict calls fill unless the dictionary name has been filled already,
+// then records it as filled. Fsck forgets the dictionaries it resets, so
+// they are filled again.
+func FillDict(s Store, name string, fill func() error) error {
+	filled, err := s.LoadDict(FilledDict)
+	if err != nil {
+		return err
+	}
+	if _, ok := filled[name]; ok {
+		return nil
+	}
+	if err := fill(); err != nil {
+		return err
+	}
+	if err := s.SaveDict(FilledDict, name, time.Now()); err != nil {
+		return fmt.Errorf("failed to save dictionary %s as filled: %w", name, err)
+	}
+	return nil
+}
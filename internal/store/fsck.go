@@ -0,0 +1,507 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// ProblemKind classifies an integrity problem found by Fsck.
+type ProblemKind string
+
+const (
+	ProblemMalformed   ProblemKind = "malformed"    // A line or row that is not a valid event
+	ProblemDuplicate   ProblemKind = "duplicate"    // An event ID already seen elsewhere
+	ProblemWrongMonth  ProblemKind = "wrong-month"  // An event filed under another month than its timestamp
+	ProblemDanglingRef ProblemKind = "dangling-ref" // A context ID missing from its dictionary
+	ProblemBadDict     ProblemKind = "bad-dict"     // A dictionary that cannot be parsed
+)
+
+// Problem is a single integrity problem.
+type Problem struct {
+	Kind     ProblemKind
+	File     string // Path relative to the store root
+	Line     int    // 1-based line number, 0 when not tied to a line
+	ID       string // Event ID, when known
+	Detail   string
+	Repaired bool
+}
+
+// FsckResult reports what Fsck checked and found.
+type FsckResult struct {
+	Files    int
+	Events   int
+	Problems []Problem
+}
+
+// Repaired returns the number of problems fixed by the repair.
+func (r *FsckResult) Repaired() int {
+	n := 0
+	for _, p := range r.Problems {
+		if p.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+// fsckable is implemented by the built-in backends.
+type fsckable interface {
+	fsck(repair bool) (*FsckResult, error)
+}
+
+// Fsck scans every event and dictionary of the store for integrity problems.
+// With repair, unreadable lines are moved into a ".corrupt" file next to the
+// file they came from, exact duplicates are quarantined the same way and events
+// filed under the wrong month are moved to the right file. Other problems,
+// such as dangling dictionary references, are only reported.
+func Fsck(s Store, repair bool) (*FsckResult, error) {
+	c, ok := s.(fsckable)
+	if !ok {
+		return nil, fmt.Errorf("store does not support integrity checks")
+	}
+	return c.fsck(repair)
+}
+
+// contextRefs lists the context IDs of an event with the dictionary holding them.
+func contextRefs(e *model.WipsEvent) [][2]string {
+	var refs [][2]string
+	if e.Ctx.RepoID != nil {
+		refs = append(refs, [2]string{"repos", *e.Ctx.RepoID})
+	}
+	if e.Ctx.CwdID != nil {
+		refs = append(refs, [2]string{"dirs", *e.Ctx.CwdID})
+	}
+	if e.Ctx.EnvID != nil {
+		refs = append(refs, [2]string{"env", *e.Ctx.EnvID})
+	}
+	return refs
+}
+
+// danglingRefs describes the context IDs of e missing from dicts.
+// Dictionaries absent from dicts (because they could not be parsed) are not checked.
+func danglingRefs(e *model.WipsEvent, dicts map[string]map[string]interface{}, bad map[string]bool) []string {
+	var missing []string
+	for _, ref := range contextRefs(e) {
+		name, key := ref[0], ref[1]
+		if bad[name] {
+			continue
+		}
+		if _, ok := dicts[name][key]; !ok {
+			missing = append(missing, fmt.Sprintf("%s entry %s not found", name, key))
+		}
+	}
+	return missing
+}
+
+// decodeEventLine decodes a single stored event, rejecting lines without an ID.
+func decodeEventLine(line []byte) (model.WipsEvent, error) {
+	var e model.WipsEvent
+	if err := json.Unmarshal(line, &e); err != nil {
+		return e, err
+	}
+	if e.ID == "" {
+		return e, fmt.Errorf("missing event ID")
+	}
+	return e, nil
+}
+
+// quarantine appends lines to path+".corrupt" so that repaired data is never lost.
+func quarantine(path string, lines [][]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(path+".corrupt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open quarantine file: %w", err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.Write(append(append([]byte{}, line...), '\n')); err != nil {
+			return fmt.Errorf("failed to write quarantine file: %w", err)
+		}
+	}
+	return nil
+}
+
+// seenEvent remembers where an event ID was first found.
+type seenEvent struct {
+	file  string
+	line  int
+	raw   []byte
+	moved bool // Appended to the file of its month by the repair
+}
+
+func (s *FileStore) fsck(repair bool) (*FsckResult, error) {
+	result := &FsckResult{}
+
+	names, err := s.dictNames()
+	if err != nil {
+		return nil, err
+	}
+	dicts := make(map[string]map[string]interface{})
+	badDicts := make(map[string]bool)
+	for _, name := range names {
+		result.Files++
+		dict, problem, err := s.fsckDict(name, repair)
+		if err != nil {
+			return nil, err
+		}
+		if problem != nil {
+			result.Problems = append(result.Problems, *problem)
+			badDicts[name] = true
+			continue
+		}
+		dicts[name] = dict
+	}
+
+	paths, err := s.monthFiles(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]seenEvent)
+	for _, path := range paths {
+		result.Files++
+		if err := s.fsckEvents(path, repair, result, seen, dicts, badDicts); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// fsckDict checks that a dictionary file parses. A broken file is moved aside when repairing
+// and the dictionary reset: the dictionaries filled from the event log are filled again on
+// next use, while the context dictionaries only get entries again as events are recorded.
+func (s *FileStore) fsckDict(name string, repair bool) (map[string]interface{}, *Problem, error) {
+	path := filepath.Join(s.RootDir, "dict", name+".json")
+
+	fileLock := lockFile(path)
+	if err := fileLock.Lock(); err != nil {
+		return nil, nil, fmt.Errorf("failed to lock dict file: %w", err)
+	}
+	defer fileLock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read dict file: %w", err)
+	}
+	dict := make(map[string]interface{})
+	if len(bytes.TrimSpace(data)) == 0 {
+		return dict, nil, nil
+	}
+	err = json.Unmarshal(data, &dict)
+	if err == nil {
+		return dict, nil, nil
+	}
+
+	problem := &Problem{Kind: ProblemBadDict, File: s.relPath(path), Detail: err.Error()}
+	if repair {
+		if err := quarantine(path, [][]byte{bytes.TrimRight(data, "\n")}); err != nil {
+			return nil, nil, err
+		}
+		if err := writeFileAtomic(path, func(io.Writer) error { return nil }); err != nil {
+			return nil, nil, fmt.Errorf("failed to reset dict file: %w", err)
+		}
+		if err := s.unfill(name); err != nil {
+			return nil, nil, err
+		}
+		problem.Repaired = true
+	}
+	return nil, problem, nil
+}
+
+// unfill removes a dictionary from the filled dictionary, so that FillDict fills it again.
+func (s *FileStore) unfill(name string) error {
+	path := filepath.Join(s.RootDir, "dict", FilledDict+".json")
+	if name == FilledDict {
+		return nil
+	}
+
+	fileLock := lockFile(path)
+	if err := fileLock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock dict file: %w", err)
+	}
+	defer fileLock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dict file: %w", err)
+	}
+	filled := make(map[string]interface{})
+	if err := json.Unmarshal(data, &filled); err != nil {
+		return nil // Reset on its own, which unfills every dictionary
+	}
+	if _, ok := filled[name]; !ok {
+		return nil
+	}
+	delete(filled, name)
+	return writeFileAtomic(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(filled)
+	})
+}
+
+// fsckEvents checks every line of a monthly event file.
+// When repairing, misfiled lines are appended to the file of their month before
+// this one is rewritten without them, so that a failure in between leaves a
+// duplicate for the next run rather than losing the events.
+func (s *FileStore) fsckEvents(path string, repair bool, result *FsckResult, seen map[string]seenEvent, dicts map[string]map[string]interface{}, badDicts map[string]bool) error {
+	fileLock := lockFile(path)
+	if err := fileLock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock file %s: %w", path, err)
+	}
+	defer fileLock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+
+	rel := s.relPath(path)
+	month := strings.TrimSuffix(filepath.Base(path), ".ndjson")
+	var kept, bad [][]byte
+	moves := make(map[string][][]byte) // Month -> lines filed here
+	changed := false
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		lineNo := i + 1
+
+		e, err := decodeEventLine(line)
+		if err != nil {
+			result.Problems = append(result.Problems, Problem{
+				Kind: ProblemMalformed, File: rel, Line: lineNo, Detail: err.Error(), Repaired: repair,
+			})
+			bad = append(bad, line)
+			changed = true
+			continue
+		}
+		want := e.TS.Format("2006-01")
+		if first, ok := seen[e.ID]; ok && first.moved && want == month && bytes.Equal(first.raw, line) {
+			// Moved here earlier in this run; already checked where it was found
+			seen[e.ID] = seenEvent{file: rel, line: lineNo, raw: line}
+			kept = append(kept, line)
+			continue
+		}
+		result.Events++
+
+		if first, ok := seen[e.ID]; ok {
+			p := Problem{
+				Kind: ProblemDuplicate, File: rel, Line: lineNo, ID: e.ID,
+				Detail: fmt.Sprintf("also at %s:%d", first.file, first.line),
+			}
+			if bytes.Equal(first.raw, line) {
+				// An exact copy carries no information of its own
+				p.Repaired = repair
+				bad = append(bad, line)
+				changed = true
+				result.Problems = append(result.Problems, p)
+				continue
+			}
+			p.Detail += " with different content"
+			result.Problems = append(result.Problems, p)
+		} else {
+			seen[e.ID] = seenEvent{file: rel, line: lineNo, raw: line}
+		}
+
+		for _, missing := range danglingRefs(&e, dicts, badDicts) {
+			result.Problems = append(result.Problems, Problem{
+				Kind: ProblemDanglingRef, File: rel, Line: lineNo, ID: e.ID, Detail: missing,
+			})
+		}
+
+		if want != month {
+			result.Problems = append(result.Problems, Problem{
+				Kind: ProblemWrongMonth, File: rel, Line: lineNo, ID: e.ID,
+				Detail: fmt.Sprintf("belongs in events/%s.ndjson", want), Repaired: repair,
+			})
+			if first := seen[e.ID]; repair && first.file == rel && first.line == lineNo {
+				first.moved = true
+				seen[e.ID] = first
+			}
+			moves[want] = append(moves[want], line)
+			changed = true
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	if !repair || !changed {
+		return nil
+	}
+
+	months := make([]string, 0, len(moves))
+	for m := range moves {
+		months = append(months, m)
+	}
+	sort.Strings(months)
+	for _, m := range months {
+		if err := s.appendLines(m, moves[m]); err != nil {
+			return err
+		}
+	}
+	if err := quarantine(path, bad); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, func(w io.Writer) error {
+		for _, line := range kept {
+			if _, err := w.Write(append(append([]byte{}, line...), '\n')); err != nil {
+				return fmt.Errorf("failed to write event: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// appendLines appends raw event lines to the monthly file.
+func (s *FileStore) appendLines(month string, lines [][]byte) error {
+	path := filepath.Join(s.RootDir, "events", month+".ndjson")
+
+	fileLock := lockFile(path)
+	if err := fileLock.Lock(); err != nil {
+		return fmt.Errorf("failed to lock event file: %w", err)
+	}
+	defer fileLock.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.Write(append(append([]byte{}, line...), '\n')); err != nil {
+			return fmt.Errorf("failed to write event: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) relPath(path string) string {
+	if rel, err := filepath.Rel(s.RootDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func (s *SQLiteStore) fsck(repair bool) (*FsckResult, error) {
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+	result := &FsckResult{Files: 1}
+	dbPath := filepath.Join(s.RootDir, SQLiteFilename)
+
+	var integrity string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&integrity); err != nil {
+		return nil, fmt.Errorf("failed to check database integrity: %w", err)
+	}
+	if integrity != "ok" {
+		// Damage below the row level cannot be repaired from here
+		result.Problems = append(result.Problems, Problem{Kind: ProblemMalformed, File: SQLiteFilename, Detail: integrity})
+	}
+
+	dicts := make(map[string]map[string]interface{})
+	badDicts := make(map[string]bool)
+	var quarantined [][]byte
+	var badEntries [][2]string
+
+	rows, err := db.Query(`SELECT name, key, value FROM dicts ORDER BY name, key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dicts: %w", err)
+	}
+	for rows.Next() {
+		var name, key, raw string
+		if err := rows.Scan(&name, &key, &raw); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read dicts: %w", err)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			result.Problems = append(result.Problems, Problem{
+				Kind: ProblemBadDict, File: SQLiteFilename,
+				Detail: fmt.Sprintf("%s entry %s: %v", name, key, err), Repaired: repair,
+			})
+			entry, _ := json.Marshal(map[string]string{"dict": name, "key": key, "value": raw})
+			quarantined = append(quarantined, entry)
+			badEntries = append(badEntries, [2]string{name, key})
+			continue
+		}
+		if dicts[name] == nil {
+			dicts[name] = make(map[string]interface{})
+		}
+		dicts[name][key] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dicts: %w", err)
+	}
+
+	rows, err = db.Query(`SELECT id, data FROM events ORDER BY ts, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	var badEvents []string
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read events: %w", err)
+		}
+		e, err := decodeEventLine([]byte(data))
+		if err != nil {
+			result.Problems = append(result.Problems, Problem{
+				Kind: ProblemMalformed, File: SQLiteFilename, ID: id, Detail: err.Error(), Repaired: repair,
+			})
+			quarantined = append(quarantined, []byte(data))
+			badEvents = append(badEvents, id)
+			continue
+		}
+		result.Events++
+		for _, missing := range danglingRefs(&e, dicts, badDicts) {
+			result.Problems = append(result.Problems, Problem{
+				Kind: ProblemDanglingRef, File: SQLiteFilename, ID: e.ID, Detail: missing,
+			})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	if !repair || len(quarantined) == 0 {
+		return result, nil
+	}
+	if err := quarantine(dbPath, quarantined); err != nil {
+		return nil, err
+	}
+	for _, entry := range badEntries {
+		if _, err := db.Exec(`DELETE FROM dicts WHERE name = ? AND key = ?`, entry[0], entry[1]); err != nil {
+			return nil, fmt.Errorf("failed to remove dict entry: %w", err)
+		}
+		// Dictionaries filled from the event log get the entry back on next use
+		if _, err := db.Exec(`DELETE FROM dicts WHERE name = ? AND key = ?`, FilledDict, entry[0]); err != nil {
+			return nil, fmt.Errorf("failed to remove dict entry: %w", err)
+		}
+	}
+	for _, id := range badEvents {
+		if _, err := db.Exec(`DELETE FROM events WHERE id = ?`, id); err != nil {
+			return nil, fmt.Errorf("failed to remove event: %w", err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFsck_FileStore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_fsck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, _ := NewStore(tempDir)
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	good := `{"id":"01HS0000000000000000000001","ts":"2024-01-05T10:00:00+09:00","type":"note","content":"ok","ctx":{"repoId":"r1"}}`
+	dangling := `{"id":"01HS0000000000000000000002","ts":"2024-01-06T10:00:00+09:00","type":"note","content":"where","ctx":{"cwdId":"missing"}}`
+	misfiled := `{"id":"01HS0000000000000000000003","ts":"2024-02-01T10:00:00+09:00","type":"note","content":"february","ctx":{}}`
+	conflict := `{"id":"01HS0000000000000000000001","ts":"2024-01-05T10:00:00+09:00","type":"note","content":"changed","ctx":{"repoId":"r1"}}`
+	january := strings.Join([]string{good, `{"id":"broken`, dangling, good, misfiled, conflict}, "\n") + "\n"
+	february := `{"id":"01HS0000000000000000000004","ts":"2024-02-02T10:00:00+09:00","type":"note","content":"later","ctx":{}}` + "\n"
+
+	writeFile := func(rel, content string) {
+		if err := os.WriteFile(filepath.Join(tempDir, rel), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("events/2024-01.ndjson", january)
+	writeFile("events/2024-02.ndjson", february)
+	writeFile("dict/repos.json", `{"r1": {"root": "/src/a"}}`)
+	writeFile("dict/dirs.json", `{"d1": "/src/a"`)
+
+	if _, err := s.QueryEvents(Query{}); err == nil || !strings.Contains(err.Error(), "wip fsck") {
+		t.Errorf("QueryEvents() error = %v, want a hint to run wip fsck", err)
+	}
+
+	type found struct {
+		kind ProblemKind
+		line int
+	}
+	want := []found{
+		{ProblemBadDict, 0},
+		{ProblemMalformed, 2},
+		{ProblemDuplicate, 4},
+		{ProblemWrongMonth, 5},
+		{ProblemDuplicate, 6},
+	}
+
+	res, err := Fsck(s, false)
+	if err != nil {
+		t.Fatalf("Fsck() error = %v", err)
+	}
+	var got []found
+	for _, p := range res.Problems {
+		got = append(got, found{p.Kind, p.Line})
+		if p.Repaired {
+			t.Errorf("Problem %+v repaired without --repair", p)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Fsck() problems = %+v, want %+v", res.Problems, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Problem %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if res.Events != 6 {
+		t.Errorf("Fsck() events = %d, want 6", res.Events)
+	}
+
+	res, err = Fsck(s, true)
+	if err != nil {
+		t.Fatalf("Fsck(repair) error = %v", err)
+	}
+	// Everything but the conflicting duplicate can be fixed; the misfiled event
+	// moved to the February file is not seen again as a duplicate when it is scanned
+	if len(res.Problems) != len(want) || res.Repaired() != len(want)-1 {
+		t.Errorf("Repaired() = %d, want %d: %+v", res.Repaired(), len(want)-1, res.Problems)
+	}
+
+	corrupt, err := os.ReadFile(filepath.Join(tempDir, "events", "2024-01.ndjson.corrupt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(corrupt) != `{"id":"broken`+"\n"+good+"\n" {
+		t.Errorf("Quarantined lines = %q", corrupt)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "dict", "dirs.json.corrupt")); err != nil {
+		t.Errorf("Expected broken dict to be quarantined: %v", err)
+	}
+
+	events, err := s.QueryEvents(Query{})
+	if err != nil {
+		t.Fatalf("QueryEvents() after repair error = %v", err)
+	}
+	if len(events) != 5 {
+		t.Errorf("Got %d events after repair, want 5", len(events))
+	}
+
+	// With the dictionary reset, the dangling reference shows up; the conflict remains
+	res, err = Fsck(s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[ProblemKind]int{}
+	for _, p := range res.Problems {
+		kinds[p.Kind]++
+	}
+	if len(res.Problems) != 2 || kinds[ProblemDuplicate] != 1 || kinds[ProblemDanglingRef] != 1 {
+		t.Errorf("Fsck() after repair = %+v, want one duplicate and one dangling ref", res.Problems)
+	}
+}
+
+func TestFsck_UnfillsResetDicts(t *testing.T) {
+	files := func(t *testing.T) Store {
+		tempDir, err := os.MkdirTemp("", "wips_test_fsck_filled")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(tempDir) })
+		s, _ := NewStore(tempDir)
+		if err := s.Prepare(); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	sqlite := func(t *testing.T) Store { return newTestSQLiteStore(t) }
+
+	for name, open := range map[string]func(*testing.T) Store{"files": files, "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			for _, dict := range []string{"undo", "links"} {
+				if err := s.SaveDict(FilledDict, dict, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.SaveDict(dict, "k", "v"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Break the undo dictionary
+			if fs, ok := s.(*FileStore); ok {
+				if err := os.WriteFile(filepath.Join(fs.RootDir, "dict", "undo.json"), []byte(`{"k":`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				db, err := s.(*SQLiteStore).conn()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := db.Exec(`UPDATE dicts SET value = '{' WHERE name = 'undo'`); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			res, err := Fsck(s, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Repaired() != 1 {
+				t.Errorf("Repaired() = %d, want 1: %+v", res.Repaired(), res.Problems)
+			}
+			filled, err := s.LoadDict(FilledDict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := filled["undo"]; ok {
+				t.Error("undo still marked as filled after it was reset")
+			}
+			if _, ok := filled["links"]; !ok {
+				t.Error("links no longer marked as filled")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func TestIterateEvents(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_iterate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files, _ := NewStore(tempDir)
+	backends := map[string]Store{
+		BackendFiles:  files,
+		BackendSQLite: newTestSQLiteStore(t),
+	}
+
+	base := time.Date(2024, 1, 20, 9, 0, 0, 0, time.Local)
+	events := []model.WipsEvent{
+		{ID: "01HS0000000000000000000001", TS: base, Type: model.EventTypeNote},
+		{ID: "01HS0000000000000000000003", TS: base.AddDate(0, 1, 0).Add(time.Hour), Type: model.EventTypeGitCommit},
+		// Appended after a later event of the same month
+		{ID: "01HS0000000000000000000002", TS: base.AddDate(0, 1, 0), Type: model.EventTypeNote},
+		{ID: "01HS0000000000000000000004", TS: base.AddDate(0, 2, 0), Type: model.EventTypeNote},
+	}
+
+	tests := []struct {
+		name  string
+		q     Query
+		limit int
+		want  []string
+	}{
+		{"Forward", Query{}, 0, []string{"01HS0000000000000000000001", "01HS0000000000000000000002", "01HS0000000000000000000003", "01HS0000000000000000000004"}},
+		{"Reverse", Query{Reverse: true}, 0, []string{"01HS0000000000000000000004", "01HS0000000000000000000003", "01HS0000000000000000000002", "01HS0000000000000000000001"}},
+		{"Reverse across months with early stop", Query{Reverse: true, Types: []model.EventType{model.EventTypeNote}}, 3, []string{"01HS0000000000000000000004", "01HS0000000000000000000002", "01HS0000000000000000000001"}},
+		{"Time bounds", Query{Start: base.AddDate(0, 1, 0), End: base.AddDate(0, 2, 0), Reverse: true}, 0, []string{"01HS0000000000000000000004", "01HS0000000000000000000003", "01HS0000000000000000000002"}},
+		{"Early stop", Query{}, 2, []string{"01HS0000000000000000000001", "01HS0000000000000000000002"}},
+	}
+
+	for name, s := range backends {
+		if err := s.Prepare(); err != nil {
+			t.Fatal(err)
+		}
+		for i := range events {
+			if err := s.AppendEvent(&events[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				var got []string
+				err := s.IterateEvents(tt.q, func(e *model.WipsEvent) error {
+					got = append(got, e.ID)
+					if len(got) == tt.limit {
+						return ErrStop
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("IterateEvents() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("IterateEvents() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		t.Run(name+"/Callback error", func(t *testing.T) {
+			boom := errors.New("boom")
+			calls := 0
+			err := s.IterateEvents(Query{}, func(*model.WipsEvent) error {
+				calls++
+				return boom
+			})
+			if err != boom || calls != 1 {
+				t.Errorf("IterateEvents() = %v after %d calls, want the callback error after 1", err, calls)
+			}
+		})
+	}
+}
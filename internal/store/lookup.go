@@ -0,0 +1,151 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// ErrEventNotFound is returned by GetEvent when no event has the given ID or prefix.
+var ErrEventNotFound = errors.New("event not found")
+
+// maxCandidates is the number of events listed by an AmbiguousIDError.
+const maxCandidates = 10
+
+// AmbiguousIDError is returned by GetEvent when a prefix matches several events.
+type AmbiguousIDError struct {
+	Prefix     string
+	Candidates []model.WipsEvent // Oldest first
+	Truncated  bool              // More events match than listed
+}
+
+func (e *AmbiguousIDError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event ID %q is ambiguous; it matches:", e.Prefix)
+	for _, c := range e.Candidates {
+		content := strings.SplitN(c.Content, "\n", 2)[0]
+		if len([]rune(content)) > 50 {
+			content = string([]rune(content)[:47]) + "..."
+		}
+		fmt.Fprintf(&b, "\n  %s  %s  %s", c.ID, c.TS.Format("2006-01-02 15:04"), content)
+	}
+	if e.Truncated {
+		b.WriteString("\n  ...")
+	}
+	return b.String()
+}
+
+// idRange returns the smallest and largest IDs starting with prefix,
+// and the ULID time range they span.
+func idRange(prefix string) (lo, hi string, start, end time.Time, err error) {
+	if prefix == "" || len(prefix) > ulid.EncodedSize {
+		return "", "", time.Time{}, time.Time{}, fmt.Errorf("invalid event ID: %q", prefix)
+	}
+	pad := ulid.EncodedSize - len(prefix)
+	lo = prefix + strings.Repeat("0", pad)
+	hi = prefix + strings.Repeat("Z", pad)
+
+	loID, err := ulid.ParseStrict(lo)
+	if err != nil {
+		return "", "", time.Time{}, time.Time{}, fmt.Errorf("invalid event ID %q: %w", prefix, err)
+	}
+	hiID, err := ulid.ParseStrict(hi)
+	if err != nil {
+		return "", "", time.Time{}, time.Time{}, fmt.Errorf("invalid event ID %q: %w", prefix, err)
+	}
+	return lo, hi, ulid.Time(loID.Time()), ulid.Time(hiID.Time()), nil
+}
+
+// normalizeID makes ID prefixes case-insensitive, as ULIDs are.
+func normalizeID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+// pickEvent returns the single event among those matching prefix.
+func pickEvent(prefix string, matches []model.WipsEvent) (*model.WipsEvent, error) {
+	for i := range matches {
+		if matches[i].ID == prefix {
+			return &matches[i], nil
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrEventNotFound, prefix)
+	case 1:
+		return &matches[0], nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	err := &AmbiguousIDError{Prefix: prefix, Candidates: matches}
+	if len(matches) > maxCandidates {
+		err.Candidates, err.Truncated = matches[:maxCandidates], true
+	}
+	return nil, err
+}
+
+// GetEvent finds an event by ID or ID prefix, including events in the trash.
+// Only the monthly files around the time encoded in the ID are read.
+func (s *FileStore) GetEvent(id string) (*model.WipsEvent, error) {
+	prefix := normalizeID(id)
+	_, _, start, end, err := idRange(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// An event is filed by its timestamp, which can differ slightly from its ID's
+	paths, err := s.monthFiles(start.Add(-24*time.Hour), end.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []model.WipsEvent
+	for _, path := range paths {
+		events, err := s.readEventsFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if strings.HasPrefix(e.ID, prefix) {
+				matches = append(matches, e)
+			}
+		}
+	}
+	return pickEvent(prefix, matches)
+}
+
+// GetEvent finds an event by ID or ID prefix, including events in the trash.
+func (s *SQLiteStore) GetEvent(id string) (*model.WipsEvent, error) {
+	prefix := normalizeID(id)
+	lo, hi, _, _, err := idRange(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT data FROM events WHERE id BETWEEN ? AND ? ORDER BY id LIMIT ?`, lo, hi, maxCandidates+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []model.WipsEvent
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	return pickEvent(prefix, matches)
+}
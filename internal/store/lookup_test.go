@@ -0,0 +1,91 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func TestGetEvent(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_lookup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files, _ := NewStore(tempDir)
+	backends := map[string]Store{
+		BackendFiles:  files,
+		BackendSQLite: newTestSQLiteStore(t),
+	}
+
+	// IDs encode the time, so events sharing a prefix are close in time
+	ts := time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local)
+	events := []model.WipsEvent{
+		{ID: "01HRJ4V0000000000000000001", TS: ts, Type: model.EventTypeNote, Content: "first"},
+		{ID: "01HRJ4V0000000000000000002", TS: ts, Type: model.EventTypeNote, Content: "second"},
+		{ID: "01HRJ5A0000000000000000003", TS: ts.Add(time.Hour), Type: model.EventTypeNote, Content: "third"},
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		want      string
+		ambiguous int
+		notFound  bool
+		invalid   bool
+	}{
+		{name: "Full ID", id: "01HRJ4V0000000000000000002", want: "second"},
+		{name: "Unique prefix", id: "01HRJ5", want: "third"},
+		{name: "Lowercase prefix", id: "01hrj5a", want: "third"},
+		{name: "Ambiguous prefix", id: "01HRJ4V", ambiguous: 2},
+		{name: "Unknown prefix", id: "01HRJ6", notFound: true},
+		{name: "Not a ULID", id: "01HRJ-", invalid: true},
+	}
+
+	for name, s := range backends {
+		if err := s.Prepare(); err != nil {
+			t.Fatal(err)
+		}
+		for i := range events {
+			if err := s.AppendEvent(&events[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				e, err := s.GetEvent(tt.id)
+				var ambiguous *AmbiguousIDError
+				switch {
+				case tt.ambiguous > 0:
+					if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != tt.ambiguous {
+						t.Fatalf("GetEvent(%q) error = %v, want %d candidates", tt.id, err, tt.ambiguous)
+					}
+					if !strings.Contains(err.Error(), events[0].ID) || !strings.Contains(err.Error(), events[1].ID) {
+						t.Errorf("error %q should list the candidates", err)
+					}
+				case tt.notFound:
+					if !errors.Is(err, ErrEventNotFound) {
+						t.Errorf("GetEvent(%q) error = %v, want ErrEventNotFound", tt.id, err)
+					}
+				case tt.invalid:
+					if err == nil || errors.Is(err, ErrEventNotFound) {
+						t.Errorf("GetEvent(%q) error = %v, want invalid ID", tt.id, err)
+					}
+				default:
+					if err != nil {
+						t.Fatalf("GetEvent(%q) error = %v", tt.id, err)
+					}
+					if e.Content != tt.want {
+						t.Errorf("GetEvent(%q) = %q, want %q", tt.id, e.Content, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
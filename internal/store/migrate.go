@@ -0,0 +1,226 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// MigrateResult reports what Migrate copied.
+type MigrateResult struct {
+	Events      int
+	DictEntries int
+	BackupDir   string // Where the source data was moved to
+}
+
+// migratable is implemented by the built-in backends so that Migrate can
+// enumerate dictionaries, write events in bulk and move old data aside.
+type migratable interface {
+	dictNames() ([]string, error)
+	appendEvents(events []model.WipsEvent) error
+	archive(dir string) error
+}
+
+// Migrate copies every event and dictionary entry from src into dst.
+// The destination must not contain any events yet. Once the copy succeeded,
+// the source data is moved into backup/<backend>-<timestamp>/ under the source root
+// so that the two backends never hold diverging copies side by side.
+func Migrate(src, dst Store) (*MigrateResult, error) {
+	from, ok := src.(migratable)
+	if !ok {
+		return nil, fmt.Errorf("source store does not support migration")
+	}
+	to, ok := dst.(migratable)
+	if !ok {
+		return nil, fmt.Errorf("destination store does not support migration")
+	}
+
+	existing, err := dst.QueryEvents(Query{IncludeTrashed: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect destination: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("destination already contains %d events", len(existing))
+	}
+
+	result := &MigrateResult{}
+
+	names, err := from.dictNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		dict, err := src.LoadDict(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load dict %s: %w", name, err)
+		}
+		for key, value := range dict {
+			if err := dst.SaveDict(name, key, value); err != nil {
+				return nil, fmt.Errorf("failed to save dict %s: %w", name, err)
+			}
+			result.DictEntries++
+		}
+	}
+
+	events, err := src.QueryEvents(Query{IncludeTrashed: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source events: %w", err)
+	}
+	if err := to.appendEvents(events); err != nil {
+		return nil, err
+	}
+	result.Events = len(events)
+
+	backend := BackendFiles
+	if _, ok := src.(*SQLiteStore); ok {
+		backend = BackendSQLite
+	}
+	result.BackupDir = filepath.Join(src.GetRootDir(), "backup", backend+"-"+time.Now().Format("20060102-150405"))
+	if err := from.archive(result.BackupDir); err != nil {
+		return nil, fmt.Errorf("failed to back up source data: %w", err)
+	}
+
+	// The search index is not kept up to date by the bulk copy: it is rebuilt
+	// from the new backend on the next search
+	for _, root := range []string{src.GetRootDir(), dst.GetRootDir()} {
+		if err := os.RemoveAll(filepath.Join(root, "index")); err != nil {
+			return nil, fmt.Errorf("failed to remove search index: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// moveInto renames the existing paths into dir, creating it first.
+func moveInto(dir string, paths ...string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dictNames lists the dictionaries stored under dict/.
+func (s *FileStore) dictNames() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.RootDir, "dict"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dict dir: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// appendEvents appends events in order, opening each monthly file once.
+func (s *FileStore) appendEvents(events []model.WipsEvent) error {
+	byMonth := make(map[string][]model.WipsEvent)
+	var months []string
+	for _, e := range events {
+		month := e.TS.Format("2006-01")
+		if _, ok := byMonth[month]; !ok {
+			months = append(months, month)
+		}
+		byMonth[month] = append(byMonth[month], e)
+	}
+
+	for _, month := range months {
+		path := filepath.Join(s.RootDir, "events", month+".ndjson")
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open event file: %w", err)
+		}
+		encoder := json.NewEncoder(f)
+		for _, e := range byMonth[month] {
+			if err := encoder.Encode(e); err != nil {
+				f.Close()
+				return fmt.Errorf("failed to write event: %w", err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to close event file: %w", err)
+		}
+	}
+	return nil
+}
+
+// archive moves the events and dict directories into dir.
+func (s *FileStore) archive(dir string) error {
+	return moveInto(dir, filepath.Join(s.RootDir, "events"), filepath.Join(s.RootDir, "dict"))
+}
+
+// archive closes the database and moves its files into dir.
+func (s *SQLiteStore) archive(dir string) error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	path := filepath.Join(s.RootDir, SQLiteFilename)
+	return moveInto(dir, path, path+"-wal", path+"-shm")
+}
+
+// dictNames lists the dictionaries present in the database.
+func (s *SQLiteStore) dictNames() ([]string, error) {
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT DISTINCT name FROM dicts ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dicts: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// appendEvents inserts events inside a single transaction.
+func (s *SQLiteStore) appendEvents(events []model.WipsEvent) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range events {
+		if err := insertEvent(tx, &events[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
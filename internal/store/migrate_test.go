@@ -0,0 +1,105 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func TestMigrate_RoundTrip(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files, _ := NewStore(tempDir)
+	if err := files.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	repoID := "repo1"
+	jst := time.FixedZone("JST", 9*60*60)
+	events := []model.WipsEvent{
+		{ID: "01HS0000000000000000000001", TS: time.Date(2023, 12, 31, 23, 0, 0, 123, jst), Type: model.EventTypeNote, Content: "年末のメモ", Ctx: model.Context{RepoID: &repoID, Branch: "main"}},
+		{ID: "01HS0000000000000000000002", TS: time.Date(2024, 1, 2, 8, 0, 0, 0, jst), Type: model.EventTypeGitCommit, Content: "abc123 fix", Meta: json.RawMessage(`{"k":"v"}`)},
+	}
+	for i := range events {
+		if err := files.AppendEvent(&events[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files.SaveDict("repos", repoID, map[string]string{"root": "/src/a"})
+	files.SaveDict("dirs", "d1", "/src/a/sub")
+
+	wantDicts := make(map[string]map[string]interface{})
+	for _, name := range []string{"repos", "dirs"} {
+		wantDicts[name], _ = files.LoadDict(name)
+	}
+
+	indexDir := filepath.Join(tempDir, "index")
+	if err := os.MkdirAll(indexDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlite, _ := NewSQLiteStore(tempDir)
+	defer sqlite.(*SQLiteStore).Close()
+
+	res, err := Migrate(files, sqlite)
+	if err != nil {
+		t.Fatalf("Migrate() to sqlite error = %v", err)
+	}
+	if res.Events != 2 || res.DictEntries != 2 {
+		t.Errorf("Migrate() = %+v, want 2 events and 2 dict entries", res)
+	}
+	if _, err := os.Stat(filepath.Join(res.BackupDir, "events")); err != nil {
+		t.Errorf("Expected source events to be moved to backup: %v", err)
+	}
+	if _, err := os.Stat(indexDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the search index to be removed: %v", err)
+	}
+
+	// Migrating again into a non-empty store must be refused
+	if _, err := Migrate(files, sqlite); err == nil {
+		t.Error("Migrate() into non-empty destination should fail")
+	}
+
+	backDir, err := os.MkdirTemp("", "wips_test_migrate_back")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(backDir)
+
+	back, _ := NewStore(backDir)
+	back.Prepare()
+	if _, err := Migrate(sqlite, back); err != nil {
+		t.Fatalf("Migrate() to files error = %v", err)
+	}
+
+	got, err := back.QueryEvents(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(events) {
+		t.Fatalf("Got %d events after round trip, want %d", len(got), len(events))
+	}
+	for i := range events {
+		want, _ := json.Marshal(events[i])
+		have, _ := json.Marshal(got[i])
+		if string(want) != string(have) {
+			t.Errorf("Event %d changed in round trip:\n got %s\nwant %s", i, have, want)
+		}
+	}
+
+	for name, want := range wantDicts {
+		have, _ := back.LoadDict(name)
+		if !reflect.DeepEqual(want, have) {
+			t.Errorf("Dict %s changed in round trip: got %v, want %v", name, have, want)
+		}
+	}
+}
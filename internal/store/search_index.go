@@ -0,0 +1,25 @@
+package store
+
+import (
+	"github.com/rynskrmt/wips-cli/internal/index"
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// indexEvent adds or refreshes an event in the search index, if one has been built.
+func indexEvent(rootDir string, e *model.WipsEvent) {
+	syncIndex(rootDir, index.Change{ID: e.ID, TS: e.TS, Content: e.Content})
+}
+
+// unindexEvent removes an event from the search index, if one has been built.
+func unindexEvent(rootDir string, id string) {
+	syncIndex(rootDir, index.Change{ID: id, Removed: true})
+}
+
+// syncIndex records a change in the index log. Index maintenance never fails
+// the write itself: if the change cannot be recorded the index is dropped
+// instead of going stale, and the next search rebuilds it.
+func syncIndex(rootDir string, c index.Change) {
+	if err := index.Log(rootDir, c); err != nil {
+		index.Drop(rootDir)
+	}
+}
@@ -0,0 +1,411 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+
+	_ "modernc.org/sqlite" // Registers the pure Go "sqlite" driver
+)
+
+// SQLiteFilename is the name of the database file inside the store root.
+const SQLiteFilename = "wips.db"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS events (
+	id      TEXT PRIMARY KEY,
+	ts      INTEGER NOT NULL,
+	type    TEXT NOT NULL,
+	repo_id TEXT,
+	cwd_id  TEXT,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_events_ts ON events(ts);
+CREATE INDEX IF NOT EXISTS idx_events_type_ts ON events(type, ts);
+CREATE INDEX IF NOT EXISTS idx_events_repo_ts ON events(repo_id, ts);
+CREATE INDEX IF NOT EXISTS idx_events_cwd_ts ON events(cwd_id, ts);
+
+CREATE TABLE IF NOT EXISTS dicts (
+	name  TEXT NOT NULL,
+	key   TEXT NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (name, key)
+);
+`
+
+// SQLiteStore stores events and dictionaries in an embedded SQLite database.
+// Each event is kept as its full JSON document alongside indexed columns
+// (timestamp, type, repository and directory) used for querying,
+// so conversions to and from FileStore are lossless.
+type SQLiteStore struct {
+	RootDir string
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a new SQLite-backed store instance.
+// If rootDir is empty, the default data directory is used.
+// The database is opened lazily by Prepare or the first operation.
+func NewSQLiteStore(rootDir string) (Store, error) {
+	if rootDir == "" {
+		dir, err := DefaultRootDir()
+		if err != nil {
+			return nil, err
+		}
+		rootDir = dir
+	}
+	return &SQLiteStore{RootDir: rootDir}, nil
+}
+
+func (s *SQLiteStore) GetRootDir() string {
+	return s.RootDir
+}
+
+// Prepare creates the root directory, opens the database and applies the schema.
+func (s *SQLiteStore) Prepare() error {
+	_, err := s.conn()
+	return err
+}
+
+// Close releases the underlying database handle.
+func (s *SQLiteStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+// conn returns the open database, opening it and applying the schema on first use.
+func (s *SQLiteStore) conn() (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	if err := os.MkdirAll(s.RootDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dir %s: %w", s.RootDir, err)
+	}
+
+	path := filepath.Join(s.RootDir, SQLiteFilename)
+	dsn := url.URL{
+		Scheme:   "file",
+		Path:     path,
+		RawQuery: "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+
+	s.db = db
+	return s.db, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertEvent writes a single event row.
+func insertEvent(x execer, event *model.WipsEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, err = x.Exec(
+		`INSERT INTO events (id, ts, type, repo_id, cwd_id, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		event.ID, event.TS.UnixNano(), string(event.Type), nullable(event.Ctx.RepoID), nullable(event.Ctx.CwdID), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+func nullable(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// AppendEvent inserts a new event.
+func (s *SQLiteStore) AppendEvent(event *model.WipsEvent) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+	if err := insertEvent(db, event); err != nil {
+		return err
+	}
+
+	indexEvent(s.RootDir, event)
+	return nil
+}
+
+// SaveDict stores a dictionary entry idempotently: existing keys are left untouched.
+func (s *SQLiteStore) SaveDict(dictName string, key string, value interface{}) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode dict value: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT OR IGNORE INTO dicts (name, key, value) VALUES (?, ?, ?)`, dictName, key, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save dict entry: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) saveDictEntries(dictName string, entries map[string]interface{}) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, value := range entries {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode dict value: %w", err)
+		}
+		_, err = tx.Exec(`INSERT OR IGNORE INTO dicts (name, key, value) VALUES (?, ?, ?)`, dictName, key, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to save dict entry: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadDict loads every entry of a dictionary into a map.
+func (s *SQLiteStore) LoadDict(dictName string) (map[string]interface{}, error) {
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT key, value FROM dicts WHERE name = ?`, dictName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dict %s: %w", dictName, err)
+	}
+	defer rows.Close()
+
+	content := make(map[string]interface{})
+	for rows.Next() {
+		var key, raw string
+		if err := rows.Scan(&key, &raw); err != nil {
+			return nil, fmt.Errorf("failed to read dict %s: %w", dictName, err)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("failed to decode dict %s entry %s: %w", dictName, key, err)
+		}
+		content[key] = value
+	}
+	return content, rows.Err()
+}
+
+// GetEvents returns events within the given time range.
+func (s *SQLiteStore) GetEvents(start, end time.Time) ([]model.WipsEvent, error) {
+	return s.QueryEvents(Query{Start: start, End: end})
+}
+
+// QueryEvents returns events matching the query using the table indexes.
+func (s *SQLiteStore) QueryEvents(q Query) ([]model.WipsEvent, error) {
+	var events []model.WipsEvent
+	err := s.IterateEvents(q, func(e *model.WipsEvent) error {
+		events = append(events, *e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// IterateEvents streams the events matching the query from the database.
+func (s *SQLiteStore) IterateEvents(q Query, fn func(*model.WipsEvent) error) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	var where []string
+	var args []interface{}
+	if !q.Start.IsZero() {
+		where = append(where, "ts >= ?")
+		args = append(args, q.Start.UnixNano())
+	}
+	if !q.End.IsZero() {
+		where = append(where, "ts <= ?")
+		args = append(args, q.End.UnixNano())
+	}
+	if len(q.Types) > 0 {
+		values := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			values[i] = string(t)
+		}
+		where = append(where, "type IN ("+placeholders(len(values))+")")
+		args = appendStrings(args, values)
+	}
+	if len(q.RepoIDs) > 0 {
+		where = append(where, "repo_id IN ("+placeholders(len(q.RepoIDs))+")")
+		args = appendStrings(args, q.RepoIDs)
+	}
+	if len(q.CwdIDs) > 0 {
+		where = append(where, "cwd_id IN ("+placeholders(len(q.CwdIDs))+")")
+		args = appendStrings(args, q.CwdIDs)
+	}
+	if !q.IncludeTrashed {
+		where = append(where, "json_extract(data, '$.meta."+model.MetaTrashed+"') IS NULL")
+	}
+
+	stmt := "SELECT data FROM events"
+	if len(where) > 0 {
+		stmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	if q.Reverse {
+		stmt += " ORDER BY ts DESC, id DESC"
+	} else {
+		stmt += " ORDER BY ts, id"
+	}
+
+	rows, err := db.Query(stmt, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(&e); err != nil {
+			if err == ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func appendStrings(args []interface{}, values []string) []interface{} {
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEvent(row scanner) (model.WipsEvent, error) {
+	var data string
+	var e model.WipsEvent
+	if err := row.Scan(&data); err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return e, fmt.Errorf("failed to decode event: %w", err)
+	}
+	return e, nil
+}
+
+// UpdateEvent finds an event by ID and updates it using the mutator function.
+func (s *SQLiteStore) UpdateEvent(id string, mutator func(*model.WipsEvent) error) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	e, err := scanEvent(tx.QueryRow(`SELECT data FROM events WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("event not found: %s", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := mutator(&e); err != nil {
+		return err
+	}
+	e.ID = id
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, err = tx.Exec(
+		`UPDATE events SET ts = ?, type = ?, repo_id = ?, cwd_id = ?, data = ? WHERE id = ?`,
+		e.TS.UnixNano(), string(e.Type), nullable(e.Ctx.RepoID), nullable(e.Ctx.CwdID), string(data), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	indexEvent(s.RootDir, &e)
+	return nil
+}
+
+// DeleteEvent permanently removes an event by ID.
+func (s *SQLiteStore) DeleteEvent(id string) error {
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	res, err := db.Exec(`DELETE FROM events WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("event not found: %s", id)
+	}
+
+	unindexEvent(s.RootDir, id)
+	return nil
+}
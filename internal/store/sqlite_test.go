@@ -0,0 +1,149 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	tempDir, err := os.MkdirTemp("", "wips_test_sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	s, err := NewSQLiteStore(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	ss := s.(*SQLiteStore)
+	t.Cleanup(func() { ss.Close() })
+	return ss
+}
+
+func TestSQLiteStore_Events(t *testing.T) {
+	s := newTestSQLiteStore(t)
+
+	repoID := "repo1"
+	cwdID := "cwd1"
+	base := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	events := []model.WipsEvent{
+		{ID: "01HS0000000000000000000001", TS: base, Type: model.EventTypeNote, Content: "first", Ctx: model.Context{RepoID: &repoID, CwdID: &cwdID}},
+		{ID: "01HS0000000000000000000002", TS: base.Add(time.Hour), Type: model.EventTypeGitCommit, Content: "abc123 fix", Ctx: model.Context{CwdID: &cwdID, Branch: "main"}},
+		{ID: "01HS0000000000000000000003", TS: base.AddDate(0, 1, 0), Type: model.EventTypeNote, Content: "next month"},
+	}
+	for i := range events {
+		if err := s.AppendEvent(&events[i]); err != nil {
+			t.Fatalf("AppendEvent() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		q       Query
+		wantIDs []string
+	}{
+		{name: "All", q: Query{}, wantIDs: []string{events[0].ID, events[1].ID, events[2].ID}},
+		{name: "Time range", q: Query{Start: base, End: base.Add(time.Hour)}, wantIDs: []string{events[0].ID, events[1].ID}},
+		{name: "Type", q: Query{Types: []model.EventType{model.EventTypeGitCommit}}, wantIDs: []string{events[1].ID}},
+		{name: "Repo", q: Query{RepoIDs: []string{repoID}}, wantIDs: []string{events[0].ID}},
+		{name: "Dir", q: Query{CwdIDs: []string{cwdID}}, wantIDs: []string{events[0].ID, events[1].ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.QueryEvents(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("QueryEvents() returned %d events, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if got[i].ID != id {
+					t.Errorf("QueryEvents()[%d].ID = %s, want %s", i, got[i].ID, id)
+				}
+			}
+		})
+	}
+
+	t.Run("Update", func(t *testing.T) {
+		err := s.UpdateEvent(events[0].ID, func(e *model.WipsEvent) error {
+			e.Content = "edited"
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := s.QueryEvents(Query{RepoIDs: []string{repoID}})
+		if len(got) != 1 || got[0].Content != "edited" {
+			t.Errorf("UpdateEvent() did not persist, got %+v", got)
+		}
+		if err := s.UpdateEvent("missing", func(e *model.WipsEvent) error { return nil }); err == nil {
+			t.Error("UpdateEvent() on missing ID should fail")
+		}
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		err := s.UpdateEvent(events[1].ID, func(e *model.WipsEvent) error {
+			return e.SetTrashed(&model.Trash{ID: "01HS0000000000000000000009", At: base})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.QueryEvents(Query{}); len(got) != 2 {
+			t.Errorf("QueryEvents() returned %d events, want trashed event excluded", len(got))
+		}
+		got, _ := s.QueryEvents(Query{IncludeTrashed: true})
+		if len(got) != 3 || got[1].Trashed() == nil {
+			t.Errorf("QueryEvents(IncludeTrashed) = %+v, want trashed event included", got)
+		}
+		s.UpdateEvent(events[1].ID, func(e *model.WipsEvent) error { return e.SetTrashed(nil) })
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := s.DeleteEvent(events[2].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.DeleteEvent(events[2].ID); err == nil {
+			t.Error("DeleteEvent() twice should fail")
+		}
+		got, _ := s.QueryEvents(Query{})
+		if len(got) != 2 {
+			t.Errorf("Expected 2 events after delete, got %d", len(got))
+		}
+	})
+}
+
+func TestSQLiteStore_Dict(t *testing.T) {
+	s := newTestSQLiteStore(t)
+
+	if err := s.SaveDict("repos", "r1", map[string]string{"root": "/src/a"}); err != nil {
+		t.Fatal(err)
+	}
+	// Existing keys must not be overwritten
+	if err := s.SaveDict("repos", "r1", map[string]string{"root": "/src/b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dict, err := s.LoadDict("repos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := dict["r1"].(map[string]interface{})
+	if !ok || entry["root"] != "/src/a" {
+		t.Errorf("LoadDict() = %v, want root /src/a", dict)
+	}
+
+	empty, err := s.LoadDict("missing")
+	if err != nil || len(empty) != 0 {
+		t.Errorf("LoadDict() for missing dict = %v, %v", empty, err)
+	}
+}
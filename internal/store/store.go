@@ -2,13 +2,16 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/gofrs/flock"
 	"github.com/oklog/ulid/v2"
 	"github.com/rynskrmt/wips-cli/internal/model"
 )
@@ -29,9 +32,25 @@ type Store interface {
 	// LoadDict loads an entire dictionary file into a map.
 	LoadDict(dictName string) (map[string]interface{}, error)
 
-	// GetEvents retrieves events within a specific time range.
+	// GetEvents retrieves events within a specific time range, excluding the trash.
 	GetEvents(start, end time.Time) ([]model.WipsEvent, error)
 
+	// QueryEvents retrieves events matching the given query, oldest first
+	// (newest first if q.Reverse is set).
+	QueryEvents(q Query) ([]model.WipsEvent, error)
+
+	// IterateEvents calls fn for each event matching the query, oldest first
+	// (newest first if q.Reverse is set), without loading them all at once.
+	// Iteration stops at the first error returned by fn; returning ErrStop
+	// ends it early without error.
+	IterateEvents(q Query, fn func(*model.WipsEvent) error) error
+
+	// GetEvent retrieves a single event by its ID or an unambiguous prefix of it,
+	// like a short git hash. Events in the trash are included.
+	// It returns ErrEventNotFound or an *AmbiguousIDError if the prefix does not
+	// identify exactly one event.
+	GetEvent(id string) (*model.WipsEvent, error)
+
 	// UpdateEvent modifies an existing event identified by ID.
 	UpdateEvent(id string, mutator func(*model.WipsEvent) error) error
 
@@ -42,10 +61,117 @@ type Store interface {
 	GetRootDir() string
 }
 
+// Supported storage backends.
+const (
+	BackendFiles  = "files"
+	BackendSQLite = "sqlite"
+)
+
+// ErrStop can be returned by an IterateEvents callback to stop early.
+var ErrStop = errors.New("stop iteration")
+
+// Query describes a filtered event lookup.
+// Zero values leave the corresponding constraint open; time bounds are inclusive.
+// Events in the trash are excluded unless IncludeTrashed is set.
+type Query struct {
+	Start          time.Time
+	End            time.Time
+	Types          []model.EventType
+	RepoIDs        []string
+	CwdIDs         []string
+	IncludeTrashed bool
+	Reverse        bool // Newest first
+}
+
+// Matches reports whether the event satisfies every constraint of the query.
+func (q Query) Matches(e *model.WipsEvent) bool {
+	if !q.Start.IsZero() && e.TS.Before(q.Start) {
+		return false
+	}
+	if !q.End.IsZero() && e.TS.After(q.End) {
+		return false
+	}
+	if len(q.Types) > 0 && !containsType(q.Types, e.Type) {
+		return false
+	}
+	if len(q.RepoIDs) > 0 && (e.Ctx.RepoID == nil || !containsString(q.RepoIDs, *e.Ctx.RepoID)) {
+		return false
+	}
+	if len(q.CwdIDs) > 0 && (e.Ctx.CwdID == nil || !containsString(q.CwdIDs, *e.Ctx.CwdID)) {
+		return false
+	}
+	if !q.IncludeTrashed && e.Trashed() != nil {
+		return false
+	}
+	return true
+}
+
+func containsType(types []model.EventType, t model.EventType) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLocation splits a store location such as "sqlite:///path/to/dir" into
+// its root directory and backend. A scheme in the location takes precedence
+// over the given backend; an empty backend means BackendFiles.
+func ParseLocation(location, backend string) (rootDir string, resolved string) {
+	if scheme, rest, ok := strings.Cut(location, "://"); ok {
+		location = rest
+		backend = scheme
+	}
+	if backend == "" {
+		backend = BackendFiles
+	}
+	return location, backend
+}
+
+// Open creates a store for the given location using the selected backend.
+// See ParseLocation for the accepted location format.
+func Open(location, backend string) (Store, error) {
+	rootDir, backend := ParseLocation(location, backend)
+	switch backend {
+	case BackendFiles:
+		return NewStore(rootDir)
+	case BackendSQLite:
+		return NewSQLiteStore(rootDir)
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", backend)
+	}
+}
+
+// DefaultRootDir returns the default data directory for the store.
+func DefaultRootDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home dir: %w", err)
+	}
+	// Default path: ~/Library/Application Support/wip on macOS
+	// but spec says standard app data.
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(home, ".wip"), nil
+	}
+	return filepath.Join(configDir, "wip"), nil
+}
+
 // FileStore handles file system operations for wips-cli.
 // It stores events in monthly NDJSON files (e.g., events/2023-01.ndjson)
 // and metadata in JSON dictionary files.
-// Access to files is guarded by file locks (flock) to ensure safe concurrent access across processes.
+// Access to files is guarded by file locks (flock) on sibling ".lock" files to ensure safe
+// concurrent access across processes, and rewrites replace files atomically.
 type FileStore struct {
 	RootDir string
 	mu      sync.Mutex // Process-internal lock, file lock used for inter-process
@@ -59,18 +185,11 @@ func (s *FileStore) GetRootDir() string {
 // If rootDir is empty, it attempts to find the default data directory.
 func NewStore(rootDir string) (Store, error) {
 	if rootDir == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get user home dir: %w", err)
-		}
-		// Default path: ~/Library/Application Support/wip on macOS
-		// but spec says standard app data.
-		configDir, err := os.UserConfigDir()
+		dir, err := DefaultRootDir()
 		if err != nil {
-			rootDir = filepath.Join(home, ".wip")
-		} else {
-			rootDir = filepath.Join(configDir, "wip")
+			return nil, err
 		}
+		rootDir = dir
 	}
 
 	return &FileStore{RootDir: rootDir}, nil
@@ -95,7 +214,7 @@ func (s *FileStore) AppendEvent(event *model.WipsEvent) error {
 	filename := event.TS.Format("2006-01") + ".ndjson"
 	path := filepath.Join(s.RootDir, "events", filename)
 
-	fileLock := flock.New(path)
+	fileLock := lockFile(path)
 	locked, err := fileLock.TryLock()
 	if err != nil {
 		return fmt.Errorf("failed to lock event file: %w", err)
@@ -120,16 +239,44 @@ func (s *FileStore) AppendEvent(event *model.WipsEvent) error {
 		return fmt.Errorf("failed to write event: %w", err)
 	}
 
+	indexEvent(s.RootDir, event)
+	return nil
+}
+
+// dictBatcher is implemented by the built-in backends so that SaveDictEntries
+// writes a dictionary once for many entries.
+type dictBatcher interface {
+	saveDictEntries(dictName string, entries map[string]interface{}) error
+}
+
+// SaveDictEntries saves many entries to a dictionary idempotently, like SaveDict,
+// in a single write when the store supports it.
+func SaveDictEntries(s Store, dictName string, entries map[string]interface{}) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if b, ok := s.(dictBatcher); ok {
+		return b.saveDictEntries(dictName, entries)
+	}
+	for key, value := range entries {
+		if err := s.SaveDict(dictName, key, value); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // SaveDict updates a dictionary file idempotently.
-// It reads the existing JSON, checks if the key exists, adds it if not, and writes back.
+// It reads the existing JSON, checks if the key exists, adds it if not, and atomically replaces the file.
 // Uses file locking to prevent race conditions.
 func (s *FileStore) SaveDict(dictName string, key string, value interface{}) error {
+	return s.saveDictEntries(dictName, map[string]interface{}{key: value})
+}
+
+func (s *FileStore) saveDictEntries(dictName string, entries map[string]interface{}) error {
 	path := filepath.Join(s.RootDir, "dict", dictName+".json")
 
-	fileLock := flock.New(path)
+	fileLock := lockFile(path)
 	locked, err := fileLock.TryLock()
 	if err != nil {
 		return fmt.Errorf("failed to lock dict file: %w", err)
@@ -141,90 +288,139 @@ func (s *FileStore) SaveDict(dictName string, key string, value interface{}) err
 	}
 	defer fileLock.Unlock()
 
-	// Open file with RDWR to allow reading and writing
-	// O_CREAT ensure it exists
-	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open dict file: %w", err)
-	}
-	defer f.Close()
-
-	// Read content
-	var content map[string]interface{}
-	fi, err := f.Stat()
-	if err != nil {
-		return err
+	content := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read dict file: %w", err)
 	}
-
-	if fi.Size() > 0 {
-		decoder := json.NewDecoder(f)
-		if err := decoder.Decode(&content); err != nil {
-			// If JSON is corrupted, we might want to backup and reset, but for MVP just error
-			return fmt.Errorf("failed to decode dict file: %w", err)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &content); err != nil {
+			return fmt.Errorf("failed to decode dict file %s (run 'wip fsck' to repair it): %w", path, err)
 		}
-	} else {
-		content = make(map[string]interface{})
 	}
 
 	// Check idempotency (simple key existence check)
-	if _, exists := content[key]; exists {
-		return nil // Already exists, do nothing
-	}
-
-	// Update map
-	content[key] = value
-
-	// Rewind and write
-	if _, err := f.Seek(0, 0); err != nil {
-		return err
-	}
-	if err := f.Truncate(0); err != nil {
-		return err
+	added := false
+	for key, value := range entries {
+		if _, exists := content[key]; !exists {
+			content[key] = value
+			added = true
+		}
 	}
-
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(content); err != nil {
-		return fmt.Errorf("failed to encode dict file: %w", err)
+	if !added {
+		return nil // Already exists, do nothing
 	}
 
-	return nil
+	return writeFileAtomic(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(content); err != nil {
+			return fmt.Errorf("failed to encode dict file: %w", err)
+		}
+		return nil
+	})
 }
 
 // GetEvents returns events within the given time range.
 func (s *FileStore) GetEvents(start, end time.Time) ([]model.WipsEvent, error) {
-	var events []model.WipsEvent
+	return s.QueryEvents(Query{Start: start, End: end})
+}
 
-	// Iterate over months from start to end
-	current := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
-	endMonth := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, end.Location())
+// QueryEvents returns events matching the query.
+func (s *FileStore) QueryEvents(q Query) ([]model.WipsEvent, error) {
+	var events []model.WipsEvent
+	err := s.IterateEvents(q, func(e *model.WipsEvent) error {
+		events = append(events, *e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
 
-	for !current.After(endMonth) {
-		filename := current.Format("2006-01") + ".ndjson"
-		path := filepath.Join(s.RootDir, "events", filename)
+// IterateEvents calls fn for each event matching the query.
+// Only the monthly files overlapping the query's time range are read, one at a time,
+// walking backwards from the newest month when q.Reverse is set.
+// The file lock is released before fn is called, so fn may modify the store.
+func (s *FileStore) IterateEvents(q Query, fn func(*model.WipsEvent) error) error {
+	paths, err := s.monthFiles(q.Start, q.End)
+	if err != nil {
+		return err
+	}
 
-		// It's possible the file doesn't exist if no events were recorded that month
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			current = current.AddDate(0, 1, 0)
-			continue
+	for i := range paths {
+		path := paths[i]
+		if q.Reverse {
+			path = paths[len(paths)-1-i]
 		}
 
 		fileEvents, err := s.readEventsFromFile(path)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		// Filter by exact time range
-		for _, e := range fileEvents {
-			if (e.TS.Equal(start) || e.TS.After(start)) && (e.TS.Equal(end) || e.TS.Before(end)) {
-				events = append(events, e)
+		// Files are in append order, which may differ from event time
+		sort.SliceStable(fileEvents, func(a, b int) bool {
+			return fileEvents[a].TS.Before(fileEvents[b].TS)
+		})
+
+		for j := range fileEvents {
+			e := &fileEvents[j]
+			if q.Reverse {
+				e = &fileEvents[len(fileEvents)-1-j]
+			}
+			if !q.Matches(e) {
+				continue
+			}
+			if err := fn(e); err != nil {
+				if err == ErrStop {
+					return nil
+				}
+				return err
 			}
 		}
+	}
 
-		current = current.AddDate(0, 1, 0)
+	return nil
+}
+
+// monthFiles lists the existing monthly event files between start and end, oldest first.
+// A zero start or end leaves that side of the range open.
+func (s *FileStore) monthFiles(start, end time.Time) ([]string, error) {
+	dir := filepath.Join(s.RootDir, "events")
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events dir: %w", err)
 	}
 
-	return events, nil
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".ndjson") {
+			continue
+		}
+		month := strings.TrimSuffix(name, ".ndjson")
+		if _, err := time.Parse("2006-01", month); err != nil {
+			continue
+		}
+		if !start.IsZero() && month < start.Format("2006-01") {
+			continue
+		}
+		if !end.IsZero() && month > end.Format("2006-01") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	return paths, nil
 }
 
 // UpdateEvent finds an event by ID and updates it using the mutator function.
@@ -240,13 +436,15 @@ func (s *FileStore) UpdateEvent(id string, mutator func(*model.WipsEvent) error)
 	filename := ts.Format("2006-01") + ".ndjson"
 	path := filepath.Join(s.RootDir, "events", filename)
 
-	return s.rewriteFile(path, func(events []model.WipsEvent) ([]model.WipsEvent, error) {
+	var updated model.WipsEvent
+	err = s.rewriteFile(path, func(events []model.WipsEvent) ([]model.WipsEvent, error) {
 		found := false
 		for i := range events {
 			if events[i].ID == id {
 				if err := mutator(&events[i]); err != nil {
 					return nil, err
 				}
+				updated = events[i]
 				found = true
 				break
 			}
@@ -256,6 +454,12 @@ func (s *FileStore) UpdateEvent(id string, mutator func(*model.WipsEvent) error)
 		}
 		return events, nil
 	})
+	if err != nil {
+		return err
+	}
+
+	indexEvent(s.RootDir, &updated)
+	return nil
 }
 
 // DeleteEvent deletes an event by ID.
@@ -271,7 +475,7 @@ func (s *FileStore) DeleteEvent(id string) error {
 	filename := ts.Format("2006-01") + ".ndjson"
 	path := filepath.Join(s.RootDir, "events", filename)
 
-	return s.rewriteFile(path, func(events []model.WipsEvent) ([]model.WipsEvent, error) {
+	err = s.rewriteFile(path, func(events []model.WipsEvent) ([]model.WipsEvent, error) {
 		newEvents := make([]model.WipsEvent, 0, len(events))
 		found := false
 		for _, e := range events {
@@ -286,11 +490,17 @@ func (s *FileStore) DeleteEvent(id string) error {
 		}
 		return newEvents, nil
 	})
+	if err != nil {
+		return err
+	}
+
+	unindexEvent(s.RootDir, id)
+	return nil
 }
 
 // readEventsFromFile reads all events from a given file path.
 func (s *FileStore) readEventsFromFile(path string) ([]model.WipsEvent, error) {
-	fileLock := flock.New(path)
+	fileLock := lockFile(path)
 	// Try shared lock for reading
 	locked, err := fileLock.TryRLock()
 	if err != nil {
@@ -315,67 +525,64 @@ func (s *FileStore) readEventsFromFile(path string) ([]model.WipsEvent, error) {
 	for decoder.More() {
 		var e model.WipsEvent
 		if err := decoder.Decode(&e); err != nil {
-			return nil, fmt.Errorf("failed to decode event in %s: %w", path, err)
+			return nil, fmt.Errorf("failed to decode event in %s (run 'wip fsck' to find and repair the bad line): %w", path, err)
 		}
 		events = append(events, e)
 	}
 	return events, nil
 }
 
-// rewriteFile safely rewrites a file by reading all content, applying a transformation, and writing back.
+// rewriteFile safely rewrites a file by reading all content, applying a transformation,
+// and atomically replacing the file with the result.
 func (s *FileStore) rewriteFile(path string, transform func([]model.WipsEvent) ([]model.WipsEvent, error)) error {
-	fileLock := flock.New(path)
+	fileLock := lockFile(path)
 	if err := fileLock.Lock(); err != nil {
 		return fmt.Errorf("failed to lock file %s: %w", path, err)
 	}
 	defer fileLock.Unlock()
 
-	f, err := os.OpenFile(path, os.O_RDWR, 0644)
+	f, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("file not found: %s", path)
 		}
 		return fmt.Errorf("failed to open file %s: %w", path, err)
 	}
-	defer f.Close()
 
 	var events []model.WipsEvent
 	decoder := json.NewDecoder(f)
 	for decoder.More() {
 		var e model.WipsEvent
 		if err := decoder.Decode(&e); err != nil {
-			return fmt.Errorf("failed to decode event in %s: %w", path, err)
+			f.Close()
+			return fmt.Errorf("failed to decode event in %s (run 'wip fsck' to find and repair the bad line): %w", path, err)
 		}
 		events = append(events, e)
 	}
+	f.Close()
 
 	newEvents, err := transform(events)
 	if err != nil {
 		return err
 	}
 
-	if _, err := f.Seek(0, 0); err != nil {
-		return err
-	}
-	if err := f.Truncate(0); err != nil {
-		return err
-	}
-
-	encoder := json.NewEncoder(f)
-	for _, e := range newEvents {
-		if err := encoder.Encode(e); err != nil {
-			return fmt.Errorf("failed to encode event: %w", err)
+	// Replace the file atomically so that a crash never leaves it half written
+	return writeFileAtomic(path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, e := range newEvents {
+			if err := encoder.Encode(e); err != nil {
+				return fmt.Errorf("failed to encode event: %w", err)
+			}
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // LoadDict loads a dictionary file.
 func (s *FileStore) LoadDict(dictName string) (map[string]interface{}, error) {
 	path := filepath.Join(s.RootDir, "dict", dictName+".json")
 
-	fileLock := flock.New(path)
+	fileLock := lockFile(path)
 	if err := fileLock.RLock(); err != nil {
 		return nil, fmt.Errorf("failed to lock dict file: %w", err)
 	}
@@ -396,7 +603,7 @@ func (s *FileStore) LoadDict(dictName string) (map[string]interface{}, error) {
 		if err.Error() == "EOF" {
 			return make(map[string]interface{}), nil
 		}
-		return nil, fmt.Errorf("failed to decode dict file: %w", err)
+		return nil, fmt.Errorf("failed to decode dict file %s (run 'wip fsck' to repair it): %w", path, err)
 	}
 
 	return content, nil
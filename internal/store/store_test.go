@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/index"
 	"github.com/rynskrmt/wips-cli/internal/model"
 )
 
@@ -78,6 +81,33 @@ func TestStore_AppendEvent_TableDriven(t *testing.T) {
 	}
 }
 
+func TestSaveDictEntries(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_dict_entries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files, _ := NewStore(tempDir)
+	files.Prepare()
+	for name, s := range map[string]Store{"files": files, "sqlite": newTestSQLiteStore(t)} {
+		if err := s.SaveDict("d", "a", "kept"); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveDictEntries(s, "d", map[string]interface{}{"a": "replaced", "b": "added", "c": "added"}); err != nil {
+			t.Fatalf("%s: SaveDictEntries() error = %v", name, err)
+		}
+		got, err := s.LoadDict("d")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]interface{}{"a": "kept", "b": "added", "c": "added"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: dict = %v, want %v", name, got, want)
+		}
+	}
+}
+
 func TestStore_SaveDict_TableDriven(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "wips_test_dict")
 	if err != nil {
@@ -175,3 +205,47 @@ func TestStore_SaveDict_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_MaintainsSearchIndex(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_store_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, _ := NewStore(tempDir)
+	s.Prepare()
+
+	first := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "before index"}
+	if err := s.AppendEvent(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := index.Build(tempDir, []model.WipsEvent{*first}); err != nil {
+		t.Fatal(err)
+	}
+
+	second := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "appended later"}
+	if err := s.AppendEvent(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateEvent(first.ID, func(e *model.WipsEvent) error {
+		e.Content = "edited content"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteEvent(second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err := index.Load(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string]int{"appended": 0, "edited": 1, "before": 0} {
+		results, _ := ix.Search(query)
+		if len(results) != want {
+			t.Errorf("Search(%q) returned %d results, want %d", query, len(results), want)
+		}
+	}
+}
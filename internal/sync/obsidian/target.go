@@ -13,6 +13,7 @@ import (
 	"github.com/rynskrmt/wips-cli/internal/model"
 	"github.com/rynskrmt/wips-cli/internal/store"
 	"github.com/rynskrmt/wips-cli/internal/ui"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
 )
 
 type Target struct {
@@ -23,6 +24,7 @@ type Target struct {
 
 type TargetOptions struct {
 	CreateMissing bool
+	GroupBy       usecase.GroupBy // Group events by directory (default) or by tag
 }
 
 func NewTarget(cfg *config.ObsidianConfig, s store.Store, opts TargetOptions) *Target {
@@ -141,7 +143,7 @@ func (t *Target) generateContent(date time.Time, events []model.WipsEvent) (stri
 	}
 	sb.WriteString(sectionHeader + "\n\n")
 
-	// Group events by context (Repo or Dir)
+	// Group events by context (Repo or Dir), or by tag
 	type DirGroup struct {
 		Name   string
 		Events []model.WipsEvent
@@ -151,29 +153,13 @@ func (t *Target) generateContent(date time.Time, events []model.WipsEvent) (stri
 	var dirOrder []string
 
 	for _, e := range events {
-		// Resolve Dir Name (Similar to usecase/summary.go)
-		var dirName string
-		if e.Ctx.RepoID != nil {
-			if repoData, ok := reposDict[*e.Ctx.RepoID].(map[string]interface{}); ok {
-				if name, ok := repoData["name"].(string); ok {
-					dirName = "@" + name
-				}
-			}
-		}
-		if dirName == "" && e.Ctx.CwdID != nil {
-			if dirPath, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
-				dirName = "📁 " + dirPath
+		for _, dirName := range usecase.GroupNames(&e, t.opts.GroupBy, reposDict, dirsDict) {
+			if _, exists := dirGroups[dirName]; !exists {
+				dirGroups[dirName] = &DirGroup{Name: dirName, Events: []model.WipsEvent{}}
+				dirOrder = append(dirOrder, dirName)
 			}
+			dirGroups[dirName].Events = append(dirGroups[dirName].Events, e)
 		}
-		if dirName == "" {
-			dirName = "(unknown)"
-		}
-
-		if _, exists := dirGroups[dirName]; !exists {
-			dirGroups[dirName] = &DirGroup{Name: dirName, Events: []model.WipsEvent{}}
-			dirOrder = append(dirOrder, dirName)
-		}
-		dirGroups[dirName].Events = append(dirGroups[dirName].Events, e)
 	}
 
 	sort.Strings(dirOrder)
@@ -185,7 +171,11 @@ func (t *Target) generateContent(date time.Time, events []model.WipsEvent) (stri
 		for _, e := range group.Events {
 			timeStr := e.TS.Format("15:04")
 			content := ui.FormatEventPlain(e)
-			// Using standard markdown list format
+			// Using standard markdown list format, with a checkbox for tasks
+			if checkbox := ui.TaskCheckbox(e); checkbox != "" {
+				sb.WriteString(fmt.Sprintf("- %s **%s**: %s\n", checkbox, timeStr, content))
+				continue
+			}
 			sb.WriteString(fmt.Sprintf("- **%s**: %s\n", timeStr, content))
 		}
 		sb.WriteString("\n")
@@ -7,6 +7,8 @@ import (
 
 	"github.com/rynskrmt/wips-cli/internal/config"
 	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+	"github.com/rynskrmt/wips-cli/internal/usecase"
 )
 
 func TestFormatFilename(t *testing.T) {
@@ -88,6 +90,13 @@ func (m *mockStore) LoadDict(dictName string) (map[string]interface{}, error) {
 	return m.dicts[dictName], nil
 }
 func (m *mockStore) GetEvents(start, end time.Time) ([]model.WipsEvent, error) { return nil, nil }
+func (m *mockStore) QueryEvents(q store.Query) ([]model.WipsEvent, error)      { return nil, nil }
+func (m *mockStore) GetEvent(id string) (*model.WipsEvent, error) {
+	return nil, store.ErrEventNotFound
+}
+func (m *mockStore) IterateEvents(q store.Query, fn func(*model.WipsEvent) error) error {
+	return nil
+}
 func (m *mockStore) UpdateEvent(id string, mutator func(*model.WipsEvent) error) error {
 	return nil
 }
@@ -152,3 +161,52 @@ func TestGenerateContent(t *testing.T) {
 		t.Error("Content missing note message")
 	}
 }
+
+func TestGenerateContent_GroupByTag(t *testing.T) {
+	cfg := &config.ObsidianConfig{SectionHeader: "## wips logs"}
+	target := NewTarget(cfg, &mockStore{}, TargetOptions{GroupBy: usecase.GroupByTag})
+
+	now := time.Now()
+	events := []model.WipsEvent{
+		{TS: now, Content: "fix #bug", Type: model.EventTypeNote, Tags: []string{"bug", "urgent"}},
+		{TS: now.Add(time.Minute), Content: "lunch", Type: model.EventTypeNote},
+	}
+
+	content, err := target.generateContent(now, events)
+	if err != nil {
+		t.Fatalf("generateContent failed: %v", err)
+	}
+	for _, header := range []string{"### #bug", "### #urgent", "### (untagged)"} {
+		if !strings.Contains(content, header+"\n") {
+			t.Errorf("Content missing %q header:\n%s", header, content)
+		}
+	}
+	if strings.Index(content, "### #bug") > strings.Index(content, "### (untagged)") {
+		t.Error("Untagged events should come after the tags")
+	}
+}
+
+func TestGenerateContent_Tasks(t *testing.T) {
+	cfg := &config.ObsidianConfig{SectionHeader: "## wips logs"}
+	target := NewTarget(cfg, &mockStore{}, TargetOptions{})
+
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.Local)
+	done := model.WipsEvent{TS: now.Add(time.Minute), Content: "write docs", Type: model.EventTypeTask}
+	if err := done.SetDone(&model.Done{At: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	events := []model.WipsEvent{
+		{TS: now, Content: "revisit retry logic", Type: model.EventTypeTask},
+		done,
+	}
+
+	content, err := target.generateContent(now, events)
+	if err != nil {
+		t.Fatalf("generateContent failed: %v", err)
+	}
+	for _, line := range []string{"- [ ] **10:00**: revisit retry logic\n", "- [x] **10:01**: write docs\n"} {
+		if !strings.Contains(content, line) {
+			t.Errorf("Content missing %q:\n%s", line, content)
+		}
+	}
+}
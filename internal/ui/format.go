@@ -17,8 +17,21 @@ var (
 	TimeColorRecent = color.New(color.FgCyan, color.Bold).SprintFunc()
 	HashColor       = color.New(color.FgYellow).SprintFunc()
 	DateColor       = color.New(color.FgHiWhite, color.Bold).SprintFunc()
+	InsertColor     = color.New(color.FgGreen).SprintFunc()
+	DeleteColor     = color.New(color.FgRed).SprintFunc()
+	FailColor       = color.New(color.FgRed, color.Bold).SprintFunc()
+	FaintColor      = color.New(color.Faint).SprintFunc()
 )
 
+// gitIcons are the icons of the events recorded by git hooks.
+var gitIcons = map[model.EventType]string{
+	model.EventTypeGitCommit:   "🔧",
+	model.EventTypeGitCheckout: "🌿",
+	model.EventTypeGitMerge:    "🔀",
+	model.EventTypeGitRewrite:  "✏️ ",
+	model.EventTypeGitPush:     "🚀",
+}
+
 // FormatEventWithStyle returns an icon and formatted summary for the given event.
 // It applies terminal color styling suitable for CLI output.
 func FormatEventWithStyle(e model.WipsEvent) (icon string, summary string) {
@@ -27,9 +40,16 @@ func FormatEventWithStyle(e model.WipsEvent) (icon string, summary string) {
 	switch e.Type {
 	case model.EventTypeNote:
 		icon = "📝"
-	case model.EventTypeGitCommit:
-		icon = "🔧"
-		// Handle "hash msg" format (git show --oneline)
+	case model.EventTypeGitCommit, model.EventTypeGitMerge:
+		icon = gitIcons[e.Type]
+		if c := e.CommitMeta(); c != nil {
+			summary = fmt.Sprintf("%s (%s)", c.Subject, HashColor(c.ShortSHA()))
+			if ins, del := c.Churn(); ins+del > 0 {
+				summary += fmt.Sprintf(" %s %s", InsertColor(fmt.Sprintf("+%d", ins)), DeleteColor(fmt.Sprintf("-%d", del)))
+			}
+			break
+		}
+		// Events recorded by earlier versions: "hash msg" format (git show --oneline)
 		lines := strings.Split(summary, "\n")
 		if len(lines) > 0 {
 			firstLine := lines[0]
@@ -46,6 +66,29 @@ func FormatEventWithStyle(e model.WipsEvent) (icon string, summary string) {
 				summary = fmt.Sprintf("%s (%s)", msg, HashColor(hash))
 			}
 		}
+	case model.EventTypeGitCheckout, model.EventTypeGitRewrite, model.EventTypeGitPush:
+		icon = gitIcons[e.Type]
+	case model.EventTypeCommand:
+		icon = commandIcon(e)
+		if c := e.CommandMeta(); c != nil {
+			if c.Failed() {
+				summary = fmt.Sprintf("%s %s", FailColor(summary), FailColor("("+commandResult(c)+")"))
+			} else {
+				summary = fmt.Sprintf("%s %s", summary, FaintColor("("+commandResult(c)+")"))
+			}
+		}
+	case model.EventTypeTask:
+		icon = taskIcon(e)
+		if e.Done() != nil {
+			summary = FaintColor(summary)
+		}
+	case model.EventTypeSession:
+		icon = sessionIcon(e)
+		if r := sessionResult(e); r != "" {
+			summary = fmt.Sprintf("%s %s", summary, FaintColor("("+r+")"))
+		}
+	case model.EventTypeDir:
+		icon = "📂"
 	case model.EventTypeUndo:
 		icon = "↩️ "
 	default:
@@ -64,7 +107,16 @@ func FormatEventWithStyle(e model.WipsEvent) (icon string, summary string) {
 // It does not include any color codes.
 func FormatEventPlain(e model.WipsEvent) string {
 	content := e.Content
-	if e.Type == model.EventTypeGitCommit {
+	if c := e.CommitMeta(); c != nil {
+		return fmt.Sprintf("%s [%s]", c.Subject, c.ShortSHA())
+	}
+	if c := e.CommandMeta(); c != nil {
+		return fmt.Sprintf("%s [%s]", content, commandResult(c))
+	}
+	if r := sessionResult(e); r != "" {
+		return fmt.Sprintf("%s [%s]", content, r)
+	}
+	if e.Type == model.EventTypeGitCommit || e.Type == model.EventTypeGitMerge {
 		lines := strings.Split(content, "\n")
 		if len(lines) > 0 {
 			content = lines[0]
@@ -87,8 +139,12 @@ func FormatEventForSummary(e model.WipsEvent) (string, string) {
 	hashStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // Yellow
 
 	switch e.Type {
-	case model.EventTypeGitCommit:
-		icon = "🔧"
+	case model.EventTypeGitCommit, model.EventTypeGitMerge:
+		icon = gitIcons[e.Type]
+		if c := e.CommitMeta(); c != nil {
+			summary = fmt.Sprintf("%s (%s)", c.Subject, hashStyle.Render(c.ShortSHA()))
+			break
+		}
 		lines := strings.Split(summary, "\n")
 		if len(lines) > 0 {
 			firstLine := lines[0]
@@ -102,12 +158,37 @@ func FormatEventForSummary(e model.WipsEvent) (string, string) {
 				summary = firstLine
 			}
 		}
+	case model.EventTypeGitCheckout, model.EventTypeGitRewrite, model.EventTypeGitPush:
+		icon = gitIcons[e.Type]
+	case model.EventTypeCommand:
+		icon = commandIcon(e)
+		if c := e.CommandMeta(); c != nil {
+			result := "(" + commandResult(c) + ")"
+			if c.Failed() {
+				failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true) // Red
+				summary = failStyle.Render(summary + " " + result)
+			} else {
+				summary = fmt.Sprintf("%s %s", summary, lipgloss.NewStyle().Faint(true).Render(result))
+			}
+		}
+	case model.EventTypeTask:
+		icon = taskIcon(e)
+		if e.Done() != nil {
+			summary = lipgloss.NewStyle().Faint(true).Render(summary)
+		}
+	case model.EventTypeSession:
+		icon = sessionIcon(e)
+		if r := sessionResult(e); r != "" {
+			summary = fmt.Sprintf("%s %s", summary, lipgloss.NewStyle().Faint(true).Render("("+r+")"))
+		}
+	case model.EventTypeDir:
+		icon = "📂"
 	case model.EventTypeUndo:
 		icon = "↩️ "
 	}
 
 	// Truncate content for display (handle multiline if not git commit with special parsing)
-	if e.Type != model.EventTypeGitCommit {
+	if e.Type != model.EventTypeGitCommit && e.Type != model.EventTypeGitMerge {
 		lines := strings.Split(summary, "\n")
 		summary = lines[0]
 		if len(lines) > 1 {
@@ -118,6 +199,94 @@ func FormatEventForSummary(e model.WipsEvent) (string, string) {
 	return icon, summary
 }
 
+// commandIcon returns the icon of a command event, showing whether it failed.
+func commandIcon(e model.WipsEvent) string {
+	if c := e.CommandMeta(); c != nil && c.Failed() {
+		return "❌"
+	}
+	return "💻"
+}
+
+// taskIcon returns the icon of a task event, showing whether it is done.
+func taskIcon(e model.WipsEvent) string {
+	if e.Done() != nil {
+		return "✅"
+	}
+	return "⬜"
+}
+
+// sessionIcons are the icons of the session events, by action.
+var sessionIcons = map[model.SessionAction]string{
+	model.SessionStart:  "▶️ ",
+	model.SessionPause:  "⏸️ ",
+	model.SessionResume: "⏯️ ",
+	model.SessionStop:   "⏹️ ",
+}
+
+// sessionIcon returns the icon of a session event, showing its action.
+func sessionIcon(e model.WipsEvent) string {
+	if s := e.SessionMeta(); s != nil {
+		return sessionIcons[s.Action]
+	}
+	return "⏱️ "
+}
+
+// sessionResult describes a session event other than a start, e.g. "paused"
+// or "1h20m" for the time worked when stopped, or returns "".
+func sessionResult(e model.WipsEvent) string {
+	s := e.SessionMeta()
+	if s == nil {
+		return ""
+	}
+	switch s.Action {
+	case model.SessionPause:
+		return "paused"
+	case model.SessionResume:
+		return "resumed"
+	case model.SessionStop:
+		return FormatWorked(s.Elapsed)
+	}
+	return ""
+}
+
+// TaskCheckbox returns the markdown checkbox of a task event, "[ ]" or "[x]",
+// or "" for other events.
+func TaskCheckbox(e model.WipsEvent) string {
+	switch {
+	case e.Type != model.EventTypeTask:
+		return ""
+	case e.Done() != nil:
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// FormatTaskCounts describes the tasks opened and completed in a period, e.g.
+// "2 tasks opened, 1 done", or returns "" if there are none.
+func FormatTaskCounts(opened, done int) string {
+	if opened == 0 && done == 0 {
+		return ""
+	}
+	noun := "tasks"
+	if opened == 1 {
+		noun = "task"
+	}
+	return fmt.Sprintf("%d %s opened, %d done", opened, noun, done)
+}
+
+// commandResult describes how a command ended, e.g. "exit 1, 12.3s" or "4.2s".
+func commandResult(c *model.Command) string {
+	if c.Failed() {
+		return fmt.Sprintf("exit %d, %s", c.ExitCode, FormatElapsed(c.Duration))
+	}
+	return FormatElapsed(c.Duration)
+}
+
+// FormatChurn formats the lines inserted and deleted, e.g. "+120 -45".
+func FormatChurn(insertions, deletions int) string {
+	return fmt.Sprintf("+%d -%d", insertions, deletions)
+}
+
 // FormatDuration formats a duration into a human-readable relative time string.
 func FormatDuration(d time.Duration) string {
 	if d < time.Hour {
@@ -132,6 +301,28 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
 }
 
+// FormatElapsed formats how long something ran, e.g. "850ms", "12.3s" or "2m05s".
+func FormatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// FormatWorked formats time worked to the minute, e.g. "45m" or "3h10m".
+func FormatWorked(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
 // FormatTimeRelative formats a time as relative with appropriate color.
 func FormatTimeRelative(t time.Time) string {
 	d := time.Since(t)
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -32,6 +33,48 @@ func TestFormatEventWithStyle(t *testing.T) {
 			wantIcon:     "🔧",
 			wantContains: "Fix bug in parser",
 		},
+		{
+			name: "git merge event",
+			event: model.WipsEvent{
+				Type:    model.EventTypeGitMerge,
+				Content: "abc1234 pull: Fast-forward",
+			},
+			wantIcon:     "🔀",
+			wantContains: "pull: Fast-forward",
+		},
+		{
+			name: "git push event",
+			event: model.WipsEvent{
+				Type:    model.EventTypeGitPush,
+				Content: "origin: main",
+			},
+			wantIcon:     "🚀",
+			wantContains: "origin: main",
+		},
+		{
+			name:         "command event",
+			event:        commandEvent(t, "go test ./...", &model.Command{Argv: []string{"go", "test", "./..."}, Duration: 1500 * time.Millisecond}),
+			wantIcon:     "💻",
+			wantContains: "1.5s",
+		},
+		{
+			name:         "failed command event",
+			event:        commandEvent(t, "make deploy", &model.Command{Argv: []string{"make", "deploy"}, ExitCode: 2}),
+			wantIcon:     "❌",
+			wantContains: "exit 2",
+		},
+		{
+			name:         "open task",
+			event:        model.WipsEvent{Type: model.EventTypeTask, Content: "revisit retry logic"},
+			wantIcon:     "⬜",
+			wantContains: "revisit retry logic",
+		},
+		{
+			name:         "done task",
+			event:        doneTask(t, "revisit retry logic"),
+			wantIcon:     "✅",
+			wantContains: "revisit retry logic",
+		},
 		{
 			name: "undo event",
 			event: model.WipsEvent{
@@ -52,6 +95,9 @@ func TestFormatEventWithStyle(t *testing.T) {
 			if len(summary) == 0 {
 				t.Errorf("FormatEventWithStyle() summary is empty")
 			}
+			if !strings.Contains(summary, tt.wantContains) {
+				t.Errorf("FormatEventWithStyle() summary = %q, want it to contain %q", summary, tt.wantContains)
+			}
 		})
 	}
 }
@@ -78,6 +124,21 @@ func TestFormatEventPlain(t *testing.T) {
 			},
 			want: "Fix bug in parser [abc1234]",
 		},
+		{
+			name:  "git commit event - from meta",
+			event: commitEvent(t, "abc1234 Fix bug in parser\n\nLong description", &model.Commit{SHA: "abc1234def", Subject: "Fix bug in parser"}),
+			want:  "Fix bug in parser [abc1234]",
+		},
+		{
+			name:  "failed command event",
+			event: commandEvent(t, "go test ./...", &model.Command{ExitCode: 1, Duration: 2 * time.Minute}),
+			want:  "go test ./... [exit 1, 2m00s]",
+		},
+		{
+			name:  "stopped session event",
+			event: sessionEvent(t, "review", &model.Session{Action: model.SessionStop, Elapsed: 80 * time.Minute}),
+			want:  "review [1h20m]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,3 +188,90 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{850 * time.Millisecond, "850ms"},
+		{12300 * time.Millisecond, "12.3s"},
+		{2*time.Minute + 5*time.Second, "2m05s"},
+		{90 * time.Minute, "1h30m"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatElapsed(tt.duration); got != tt.want {
+			t.Errorf("FormatElapsed(%v) = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestFormatWorked(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{20 * time.Second, "0m"},
+		{45*time.Minute + 40*time.Second, "46m"},
+		{3*time.Hour + 10*time.Minute, "3h10m"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatWorked(tt.duration); got != tt.want {
+			t.Errorf("FormatWorked(%v) = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func sessionEvent(t *testing.T, content string, s *model.Session) model.WipsEvent {
+	t.Helper()
+	e := model.WipsEvent{Type: model.EventTypeSession, Content: content}
+	if err := e.SetMeta(model.MetaSession, s); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func commandEvent(t *testing.T, content string, c *model.Command) model.WipsEvent {
+	t.Helper()
+	e := model.WipsEvent{Type: model.EventTypeCommand, Content: content}
+	if err := e.SetMeta(model.MetaCommand, c); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func doneTask(t *testing.T, content string) model.WipsEvent {
+	t.Helper()
+	e := model.WipsEvent{Type: model.EventTypeTask, Content: content}
+	if err := e.SetDone(&model.Done{At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestTaskCheckbox(t *testing.T) {
+	tests := []struct {
+		event model.WipsEvent
+		want  string
+	}{
+		{model.WipsEvent{Type: model.EventTypeTask}, "[ ]"},
+		{doneTask(t, "done"), "[x]"},
+		{model.WipsEvent{Type: model.EventTypeNote}, ""},
+	}
+	for _, tt := range tests {
+		if got := TaskCheckbox(tt.event); got != tt.want {
+			t.Errorf("TaskCheckbox(%s) = %q, want %q", tt.event.Type, got, tt.want)
+		}
+	}
+}
+
+func commitEvent(t *testing.T, content string, c *model.Commit) model.WipsEvent {
+	t.Helper()
+	e := model.WipsEvent{Type: model.EventTypeGitCommit, Content: content}
+	if err := e.SetMeta(model.MetaCommit, c); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
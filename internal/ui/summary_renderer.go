@@ -13,7 +13,8 @@ import (
 
 // SummaryRenderer handles rendering of summary results
 type SummaryRenderer struct {
-	Out io.Writer
+	Out   io.Writer
+	Stats bool // Show commit counts and line churn per repository
 }
 
 func NewSummaryRenderer(out io.Writer) *SummaryRenderer {
@@ -35,19 +36,27 @@ func (r *SummaryRenderer) RenderPretty(result *usecase.SummaryResult) {
 		if dg.Date == time.Now().Format("2006-01-02") {
 			header += " [Today]"
 		}
-		fmt.Fprintln(r.Out, dateStyle.Render(header))
+		header = dateStyle.Render(header)
+		if tasks := FormatTaskCounts(dg.TasksOpened, dg.TasksDone); tasks != "" {
+			header += "  " + timeStyle.Render(tasks)
+		}
+		fmt.Fprintln(r.Out, header)
 
 		for _, dirName := range dg.DirOrder {
 			dirGroup := dg.DirMap[dirName]
-			fmt.Fprintln(r.Out, repoStyle.Render(dirGroup.Name))
+			header := repoStyle.Render(dirGroup.Name)
+			if stats := r.stats(dirGroup); stats != "" {
+				header += "  " + timeStyle.Render(stats)
+			}
+			fmt.Fprintln(r.Out, header)
 
-			for _, e := range dirGroup.Events {
+			for i, e := range dirGroup.Events {
 				timeStr := timeStyle.Render(e.TS.Format("15:04"))
 				// Use the centralized format function
 				icon, summaryStr := FormatEventForSummary(e)
 
 				summaryStr = descStyle.Render(summaryStr)
-				fmt.Fprintf(w, "    %s\t%s  %s\n", timeStr, icon, summaryStr)
+				fmt.Fprintf(w, "    %s\t%s%s  %s\n", timeStr, replyIndent(dirGroup, i), icon, summaryStr)
 			}
 			w.Flush()
 		}
@@ -71,24 +80,40 @@ func (r *SummaryRenderer) RenderExport(result *usecase.SummaryResult, format str
 		} else {
 			output.WriteString(fmt.Sprintf("\n[%s]\n", dateTitle))
 		}
+		if tasks := FormatTaskCounts(dg.TasksOpened, dg.TasksDone); tasks != "" {
+			output.WriteString(tasks + "\n")
+			if format == "md" {
+				output.WriteString("\n")
+			}
+		}
 
 		for _, dirName := range dg.DirOrder {
 			dirGroup := dg.DirMap[dirName]
+			name := dirGroup.Name
+			if stats := r.stats(dirGroup); stats != "" {
+				name += " (" + stats + ")"
+			}
 			if format == "md" {
-				output.WriteString(fmt.Sprintf("### %s\n\n", dirGroup.Name))
+				output.WriteString(fmt.Sprintf("### %s\n\n", name))
 			} else {
-				output.WriteString(fmt.Sprintf("\n%s\n", dirGroup.Name))
+				output.WriteString(fmt.Sprintf("\n%s\n", name))
 			}
 
-			for _, e := range dirGroup.Events {
+			for i, e := range dirGroup.Events {
 				timeStr := e.TS.Format("15:04")
 				// Use the centralized format function
 				content := FormatEventPlain(e)
 
+				checkbox := TaskCheckbox(e)
+				if checkbox != "" {
+					checkbox += " "
+				}
+				// Replies are nested list items
+				indent := strings.Repeat("  ", depth(dirGroup, i))
 				if format == "md" {
-					output.WriteString(fmt.Sprintf("- **%s**: %s\n", timeStr, content))
+					output.WriteString(fmt.Sprintf("%s- %s**%s**: %s\n", indent, checkbox, timeStr, content))
 				} else {
-					output.WriteString(fmt.Sprintf("- %s %s\n", timeStr, content))
+					output.WriteString(fmt.Sprintf("%s- %s %s%s\n", indent, timeStr, checkbox, content))
 				}
 			}
 			output.WriteString("\n")
@@ -98,3 +123,34 @@ func (r *SummaryRenderer) RenderExport(result *usecase.SummaryResult, format str
 	_, err := fmt.Fprint(r.Out, output.String())
 	return err
 }
+
+// depth returns the reply depth of the i-th event of a group.
+func depth(g *usecase.DirGroup, i int) int {
+	if i < len(g.Depths) {
+		return g.Depths[i]
+	}
+	return 0
+}
+
+// replyIndent returns the indent of the i-th event of a group in the pretty
+// format: "↳ " for a reply, indented further for deeper replies.
+func replyIndent(g *usecase.DirGroup, i int) string {
+	d := depth(g, i)
+	if d == 0 {
+		return ""
+	}
+	return strings.Repeat("  ", d-1) + "↳ "
+}
+
+// stats returns the commit count and line churn of a group, e.g. "3 commits, +120 -45",
+// or "" if stats are off or the group has no commits.
+func (r *SummaryRenderer) stats(g *usecase.DirGroup) string {
+	if !r.Stats || g.Commits == 0 {
+		return ""
+	}
+	noun := "commits"
+	if g.Commits == 1 {
+		noun = "commit"
+	}
+	return fmt.Sprintf("%d %s, %s", g.Commits, noun, FormatChurn(g.Insertions, g.Deletions))
+}
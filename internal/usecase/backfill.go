@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// BackfillOptions selects the commits imported by Backfill.
+type BackfillOptions struct {
+	Since  time.Time // Only commits authored at or after Since, if set
+	Author string    // Passed to git log --author; "me" is the configured user.email
+}
+
+// BackfillResult counts the commits Backfill found.
+type BackfillResult struct {
+	Imported int
+	Skipped  int // Already recorded
+}
+
+// BackfillUsecase defines the business logic for importing past commits.
+type BackfillUsecase interface {
+	// Backfill records a git_commit event for each commit of the repository's local branches
+	// that is not recorded yet, at the commit's author date.
+	Backfill(opts BackfillOptions) (BackfillResult, error)
+}
+
+type backfillUsecase struct {
+	store store.Store
+	git   GitRunner
+}
+
+// NewBackfillUsecase creates a new BackfillUsecase for the repository git runs in.
+func NewBackfillUsecase(s store.Store, git GitRunner) BackfillUsecase {
+	return &backfillUsecase{store: s, git: git}
+}
+
+// Backfill implementation.
+// Each commit is recorded once, under the first branch containing it: the default
+// branch, then the current branch, then the others.
+func (u *backfillUsecase) Backfill(opts BackfillOptions) (BackfillResult, error) {
+	var res BackfillResult
+
+	out, err := u.git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return res, fmt.Errorf("not in a git working tree: %w", err)
+	}
+	// Hooks run at the top of the working tree, so commits are recorded there
+	ctx := gatherContext(u.store, strings.TrimSpace(string(out)))
+	ctx.Worktree = ""
+
+	recorded, err := u.recorded(ctx.RepoID)
+	if err != nil {
+		return res, err
+	}
+
+	author := opts.Author
+	if author == "me" {
+		out, err := u.git("config", "user.email")
+		if err != nil {
+			return res, fmt.Errorf("failed to get user.email for --author me: %w", err)
+		}
+		author = strings.TrimSpace(string(out))
+	}
+
+	var seen []string
+	for _, branch := range u.branches() {
+		args := []string{"log", "--no-color", "--numstat", "--format=" + commitFormat}
+		if !opts.Since.IsZero() {
+			// git filters by commit date, which is never before the author date
+			args = append(args, "--since="+opts.Since.Format(time.RFC3339))
+		}
+		if author != "" {
+			args = append(args, "--author="+author)
+		}
+		args = append(args, "refs/heads/"+branch)
+		if len(seen) > 0 {
+			args = append(args, "--not")
+			args = append(args, seen...)
+		}
+		seen = append(seen, "refs/heads/"+branch)
+
+		out, err := u.git(append(args, "--")...)
+		if err != nil {
+			return res, fmt.Errorf("failed to read git log of %s: %w", branch, err)
+		}
+		commits, err := parseCommits(string(out))
+		if err != nil {
+			return res, err
+		}
+
+		// git log lists the newest first
+		for i := len(commits) - 1; i >= 0; i-- {
+			c := commits[i]
+			if c.AuthorDate.Before(opts.Since) {
+				continue
+			}
+			if recorded.has(c.SHA) {
+				res.Skipped++
+				continue
+			}
+
+			ts := c.AuthorDate.Local()
+			content := commitContent(&c)
+			event := &model.WipsEvent{
+				ID:      id.GenerateULIDAt(ts),
+				TS:      ts,
+				Type:    model.EventTypeGitCommit,
+				Content: content,
+				Tags:    model.ParseTags(content),
+				Ctx:     ctx,
+			}
+			event.Ctx.Branch = branch
+			event.Ctx.Head = c.ShortSHA()
+			if err := event.SetMeta(model.MetaCommit, c); err != nil {
+				return res, err
+			}
+			if err := u.store.AppendEvent(event); err != nil {
+				return res, err
+			}
+			recorded.add(c.SHA)
+			res.Imported++
+		}
+	}
+	return res, nil
+}
+
+// branches lists the local branches, with the default and the current branch first.
+func (u *backfillUsecase) branches() []string {
+	out, err := u.git("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil
+	}
+	local := strings.Fields(string(out))
+
+	var first []string
+	if out, err := u.git("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		first = append(first, strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/"))
+	}
+	first = append(first, "main", "master")
+	if out, err := u.git("symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		first = append(first, strings.TrimSpace(string(out)))
+	}
+
+	var ordered []string
+	added := make(map[string]bool)
+	for _, name := range append(first, local...) {
+		if added[name] || !containsName(local, name) {
+			continue
+		}
+		added[name] = true
+		ordered = append(ordered, name)
+	}
+	return ordered
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// commitSet holds the commits already recorded in a repository.
+type commitSet struct {
+	full  map[string]bool
+	short map[string]bool // Abbreviated heads of events recorded without a commit Meta
+}
+
+func (s commitSet) has(sha string) bool {
+	return s.full[sha] || s.short[abbrev(sha)]
+}
+
+func (s commitSet) add(sha string) {
+	s.full[sha] = true
+}
+
+// recorded returns the commits that have an event in the repository, including trashed events,
+// which were deleted on purpose.
+func (u *backfillUsecase) recorded(repoID *string) (commitSet, error) {
+	set := commitSet{full: make(map[string]bool), short: make(map[string]bool)}
+	q := store.Query{Types: []model.EventType{model.EventTypeGitCommit}, IncludeTrashed: true}
+	if repoID != nil {
+		q.RepoIDs = []string{*repoID}
+	}
+	err := u.store.IterateEvents(q, func(e *model.WipsEvent) error {
+		if c := e.CommitMeta(); c != nil {
+			set.full[c.SHA] = true
+		} else if len(e.Ctx.Head) >= 7 {
+			set.short[e.Ctx.Head[:7]] = true
+		}
+		return nil
+	})
+	return set, err
+}
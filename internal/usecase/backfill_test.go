@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func TestBackfill(t *testing.T) {
+	const (
+		sha1 = "1111111111111111111111111111111111111111"
+		sha2 = "2222222222222222222222222222222222222222"
+		sha3 = "3333333333333333333333333333333333333333"
+		sha4 = "4444444444444444444444444444444444444444"
+	)
+	record := func(sha, date, subject string) string {
+		return "\x1e" + sha + "\x00\x00Dev\x00dev@example.com\x00" + date + "\x002024-06-01T00:00:00Z\x00" + subject + "\x00\x00\n\n1\t0\tmain.go\n"
+	}
+	since := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	logArgs := "log --no-color --numstat --format=" + commitFormat + " --since=" + since.Format(time.RFC3339) + " --author=dev@example.com "
+	git := fakeGit(map[string]string{
+		"rev-parse --show-toplevel":                             "/work/project\n",
+		"config user.email":                                     "dev@example.com\n",
+		"for-each-ref --format=%(refname:short) refs/heads/":    "feature\nmain\n",
+		"symbolic-ref --quiet --short refs/remotes/origin/HEAD": "origin/main\n",
+		"symbolic-ref --quiet --short HEAD":                     "feature\n",
+		logArgs + "refs/heads/main --":                          record(sha3, "2024-03-01T10:00:00+09:00", "Third") + record(sha2, "2024-02-01T10:00:00Z", "Second") + record(sha1, "2024-01-31T10:00:00Z", "Before since"),
+		logArgs + "refs/heads/feature --not refs/heads/main --": record(sha4, "2024-04-01T10:00:00Z", "Feature work"),
+	})
+
+	ms := &MockStore{}
+	repoID := gatherContext(ms, "/work/project").RepoID
+	// sha2 was recorded by the hook, without a commit Meta
+	ms.Events = []model.WipsEvent{{ID: "E1", TS: time.Now(), Type: model.EventTypeGitCommit, Ctx: model.Context{RepoID: repoID, Head: "2222222"}}}
+
+	u := NewBackfillUsecase(ms, git)
+	res, err := u.Backfill(BackfillOptions{Since: since, Author: "me"})
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	if res.Imported != 2 || res.Skipped != 1 {
+		t.Errorf("Backfill() = %+v, want 2 imported and 1 skipped", res)
+	}
+
+	want := []struct {
+		sha, branch string
+		ts          time.Time
+	}{
+		{sha3, "main", time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)},
+		{sha4, "feature", time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	if len(ms.Events) != 1+len(want) {
+		t.Fatalf("store has %d events, want %d", len(ms.Events), 1+len(want))
+	}
+	for i, w := range want {
+		e := ms.Events[1+i]
+		c := e.CommitMeta()
+		if c == nil || c.SHA != w.sha || e.Ctx.Branch != w.branch || e.Ctx.Head != abbrev(w.sha) || !e.TS.Equal(w.ts) {
+			t.Errorf("event %d = %s at %v on %s (meta %+v), want %s at %v on %s", i, e.Content, e.TS, e.Ctx.Branch, c, abbrev(w.sha), w.ts, w.branch)
+		}
+	}
+
+	// Running again imports nothing
+	res, err = u.Backfill(BackfillOptions{Since: since, Author: "me"})
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	if res.Imported != 0 || res.Skipped != 3 {
+		t.Errorf("second Backfill() = %+v, want 0 imported and 3 skipped", res)
+	}
+}
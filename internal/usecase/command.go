@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// CommandUsecase defines the business logic for recording commands run through 'wip run'.
+type CommandUsecase interface {
+	// RecordCommand saves a command event for a finished command, with the same context as a note.
+	// It returns nil if the directory is ignored by the config.
+	RecordCommand(run model.Command, wd string) (*model.WipsEvent, error)
+}
+
+type commandUsecase struct {
+	store store.Store
+}
+
+// NewCommandUsecase creates a new CommandUsecase instance.
+func NewCommandUsecase(s store.Store) CommandUsecase {
+	return &commandUsecase{store: s}
+}
+
+// RecordCommand implementation.
+// The event's Content is the command line, so that search finds it.
+func (u *commandUsecase) RecordCommand(run model.Command, wd string) (*model.WipsEvent, error) {
+	if ignoredByConfig(wd) {
+		return nil, nil
+	}
+
+	content := ShellJoin(run.Argv)
+	event := &model.WipsEvent{
+		ID:      id.GenerateULID(),
+		TS:      time.Now(),
+		Type:    model.EventTypeCommand,
+		Content: content,
+		Tags:    model.ParseTags(content),
+		Ctx:     gatherContext(u.store, wd),
+	}
+	if err := event.SetMeta(model.MetaCommand, run); err != nil {
+		return nil, err
+	}
+
+	if err := u.store.AppendEvent(event); err != nil {
+		return nil, fmt.Errorf("failed to save event: %w", err)
+	}
+	return event, nil
+}
+
+// ShellJoin returns argv as a command line, quoting the arguments a shell would split.
+func ShellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}~#!") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Limits of the output kept by an OutputTail.
+const (
+	outputTailBytes = 4096
+	outputTailLines = 20
+)
+
+// ansiEscape matches terminal color and cursor sequences.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// OutputTail is an io.Writer keeping the end of what is written to it.
+// It is safe to share between a command's stdout and stderr.
+type OutputTail struct {
+	mu        sync.Mutex
+	buf       []byte
+	truncated bool
+}
+
+// Write keeps the last outputTailBytes bytes written.
+func (t *OutputTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - outputTailBytes; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+		t.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the last outputTailLines lines written, without terminal escape sequences,
+// and whether earlier output was dropped.
+func (t *OutputTail) String() (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := strings.ToValidUTF8(string(t.buf), "")
+	out = ansiEscape.ReplaceAllString(out, "")
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+
+	truncated := t.truncated
+	if truncated && len(lines) > 1 {
+		// The first line was cut
+		lines = lines[1:]
+	}
+	if len(lines) > outputTailLines {
+		lines = lines[len(lines)-outputTailLines:]
+		truncated = true
+	}
+	return strings.Join(lines, "\n"), truncated
+}
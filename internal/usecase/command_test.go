@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+func TestRecordCommand(t *testing.T) {
+	ms := &MockStore{}
+	u := NewCommandUsecase(ms)
+
+	run := model.Command{Argv: []string{"go", "test", "-run", "Test Foo"}, ExitCode: 1, Duration: 3 * time.Second, Output: "FAIL"}
+	event, err := u.RecordCommand(run, "/work/project")
+	if err != nil {
+		t.Fatalf("RecordCommand() error = %v", err)
+	}
+	if event == nil || len(ms.Events) != 1 {
+		t.Fatalf("RecordCommand() recorded %d events, want 1", len(ms.Events))
+	}
+
+	e := ms.Events[0]
+	if e.Type != model.EventTypeCommand || e.Content != "go test -run 'Test Foo'" {
+		t.Errorf("event = %s %q", e.Type, e.Content)
+	}
+	if c := e.CommandMeta(); c == nil || !c.Failed() || c.Output != "FAIL" || c.Duration != run.Duration {
+		t.Errorf("meta = %+v, want %+v", c, run)
+	}
+	if e.Ctx.CwdID == nil {
+		t.Error("event has no working directory context")
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{"go", "test", "./..."}, "go test ./..."},
+		{[]string{"echo", "a b", ""}, "echo 'a b' ''"},
+		{[]string{"sh", "-c", "echo 'hi' | cat"}, `sh -c 'echo '\''hi'\'' | cat'`},
+	}
+	for _, tt := range tests {
+		if got := ShellJoin(tt.argv); got != tt.want {
+			t.Errorf("ShellJoin(%q) = %s, want %s", tt.argv, got, tt.want)
+		}
+	}
+}
+
+func TestOutputTail(t *testing.T) {
+	tests := []struct {
+		name          string
+		writes        []string
+		want          string
+		wantTruncated bool
+	}{
+		{
+			name:   "Short output is kept",
+			writes: []string{"ok  \x1b[32mpkg\x1b[0m\r\n", "done\n"},
+			want:   "ok  pkg\ndone",
+		},
+		{
+			name:          "Only the last lines are kept",
+			writes:        []string{strings.Repeat("line\n", outputTailLines), "last\n"},
+			want:          strings.Repeat("line\n", outputTailLines-1) + "last",
+			wantTruncated: true,
+		},
+		{
+			name:          "A line cut by the byte limit is dropped",
+			writes:        []string{strings.Repeat("x", outputTailBytes), "\nend\n"},
+			want:          "end",
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail := &OutputTail{}
+			for _, w := range tt.writes {
+				if _, err := fmt.Fprint(tail, w); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, truncated := tail.String()
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("String() = %q, %v, want %q, %v", got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+}
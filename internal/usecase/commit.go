@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+)
+
+// commitFormat is the 'git show' and 'git log' format read by parseCommits:
+// a record separator, then NUL-terminated fields, followed by the --numstat lines.
+const commitFormat = "%x1e%H%x00%P%x00%an%x00%ae%x00%aI%x00%cI%x00%s%x00%b%x00"
+
+// commitFields is the number of fields in commitFormat.
+const commitFields = 8
+
+// readCommit returns the commit rev, with the files it changed.
+func readCommit(git GitRunner, rev string) (*model.Commit, error) {
+	out, err := git("show", "--no-color", "--numstat", "--format="+commitFormat, rev)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := parseCommits(string(out))
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) != 1 {
+		return nil, fmt.Errorf("expected 1 commit for %s, got %d", rev, len(commits))
+	}
+	return &commits[0], nil
+}
+
+// parseCommits parses the output of git show or git log with --format=commitFormat and --numstat.
+func parseCommits(out string) ([]model.Commit, error) {
+	var commits []model.Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x00", commitFields+1)
+		if len(fields) != commitFields+1 {
+			return nil, fmt.Errorf("unexpected git output: %q", record)
+		}
+
+		c := model.Commit{
+			SHA:     fields[0],
+			Parents: strings.Fields(fields[1]),
+			Author:  fields[2],
+			Email:   fields[3],
+			Subject: fields[6],
+			Body:    strings.TrimSpace(fields[7]),
+		}
+		var err error
+		if c.AuthorDate, err = time.Parse(time.RFC3339, fields[4]); err != nil {
+			return nil, fmt.Errorf("failed to parse author date of %s: %w", c.SHA, err)
+		}
+		if c.CommitDate, err = time.Parse(time.RFC3339, fields[5]); err != nil {
+			return nil, fmt.Errorf("failed to parse commit date of %s: %w", c.SHA, err)
+		}
+
+		// "<insertions>\t<deletions>\t<path>", with "-" counts for binary files
+		for _, line := range strings.Split(fields[8], "\n") {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			f := model.FileChange{Path: parts[2]}
+			if parts[0] == "-" {
+				f.Binary = true
+			} else {
+				f.Insertions, _ = strconv.Atoi(parts[0])
+				f.Deletions, _ = strconv.Atoi(parts[1])
+			}
+			c.Files = append(c.Files, f)
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// commitContent returns the Content of a git_commit event: "<short sha> <subject>",
+// then the body, so that search matches the whole message.
+func commitContent(c *model.Commit) string {
+	content := c.ShortSHA() + " " + c.Subject
+	if c.Body != "" {
+		content += "\n\n" + c.Body
+	}
+	return content
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"github.com/rynskrmt/wips-cli/internal/env"
+	"github.com/rynskrmt/wips-cli/internal/git"
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// gatherContext collects the environment, git repository and working directory
+// context of a new event, saving the dictionary entries it refers to.
+// Parts that cannot be determined or saved are left out.
+func gatherContext(s store.Store, wd string) model.Context {
+	ctx := model.Context{}
+
+	// Env Info
+	envInfo := env.GetInfo()
+	envID := id.GetHashID(envInfo.Host + envInfo.User)
+	if err := s.SaveDict("env", envID, envInfo); err == nil {
+		ctx.EnvID = &envID
+	}
+
+	// Repo Info
+	if repoInfo, err := git.GetInfo(wd); err == nil && repoInfo.Root != "" {
+		repoID := repoIDOf(repoInfo)
+
+		if err := s.SaveDict("repos", repoID, repoInfo); err == nil {
+			ctx.RepoID = &repoID
+		}
+
+		// Head info
+		branch, head, err := git.GetHead(wd)
+		if err == nil {
+			ctx.Branch = branch
+			ctx.Head = head
+		}
+		ctx.Worktree = repoInfo.Worktree
+	}
+
+	// CWD Info
+	cwdID := id.GetHashID(wd)
+	if err := s.SaveDict("dirs", cwdID, wd); err == nil {
+		ctx.CwdID = &cwdID
+	}
+
+	return ctx
+}
+
+// repoIDOf returns the ID of a repository in the repos dictionary:
+// a hash of its remote URL, or of its root if it has no remote.
+func repoIDOf(info git.Info) string {
+	if info.Remote != "" {
+		return id.GetHashID(info.Remote)
+	}
+	return id.GetHashID(info.Root)
+}
+
+// EventContext is the context of an event with its IDs resolved from the dictionaries.
+// Entries missing from the dictionaries are left out.
+type EventContext struct {
+	Repo     *RepoContext   `json:"repo,omitempty"`
+	Dir      string         `json:"dir,omitempty"`
+	Env      *model.EnvInfo `json:"env,omitempty"`
+	Branch   string         `json:"branch,omitempty"`
+	Head     string         `json:"head,omitempty"`
+	Worktree string         `json:"worktree,omitempty"`
+}
+
+// RepoContext is a repository entry of the repos dictionary.
+type RepoContext struct {
+	Name   string `json:"name,omitempty"`
+	Root   string `json:"root,omitempty"`
+	Remote string `json:"remote,omitempty"`
+}
+
+// ResolveContext looks up the dictionary entries ctx refers to.
+func ResolveContext(s store.Store, ctx model.Context) EventContext {
+	resolved := EventContext{Branch: ctx.Branch, Head: ctx.Head, Worktree: ctx.Worktree}
+
+	if ctx.RepoID != nil {
+		if reposDict, err := s.LoadDict("repos"); err == nil {
+			// Entries written by older versions use capitalized keys, so both forms are read
+			if info, ok := reposDict[*ctx.RepoID].(map[string]interface{}); ok {
+				get := func(keys ...string) string {
+					for _, key := range keys {
+						if v, ok := info[key].(string); ok && v != "" {
+							return v
+						}
+					}
+					return ""
+				}
+				resolved.Repo = &RepoContext{
+					Name:   get("name", "Name"),
+					Root:   get("root", "Root"),
+					Remote: get("remote", "Remote"),
+				}
+			}
+		}
+	}
+	if ctx.CwdID != nil {
+		if dirsDict, err := s.LoadDict("dirs"); err == nil {
+			resolved.Dir, _ = dirsDict[*ctx.CwdID].(string)
+		}
+	}
+	if ctx.EnvID != nil {
+		if envDict, err := s.LoadDict("env"); err == nil {
+			if info, ok := envDict[*ctx.EnvID].(map[string]interface{}); ok {
+				resolved.Env = &model.EnvInfo{}
+				resolved.Env.Host, _ = info["host"].(string)
+				resolved.Env.OS, _ = info["os"].(string)
+				resolved.Env.Arch, _ = info["arch"].(string)
+				resolved.Env.User, _ = info["user"].(string)
+			}
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rynskrmt/wips-cli/internal/git"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func TestResolveContext(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	repoID, dirID, envID, missing := "repo", "dir", "env", "missing"
+	// Repositories are stored as git.Info, with capitalized keys
+	if err := s.SaveDict("repos", repoID, git.Info{Root: "/src/app", Remote: "git@example.com:me/app.git"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveDict("dirs", dirID, "/src/app/api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveDict("env", envID, model.EnvInfo{Host: "laptop", OS: "linux", Arch: "amd64", User: "me"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ResolveContext(s, model.Context{RepoID: &repoID, CwdID: &dirID, EnvID: &envID, Branch: "main", Head: "abc1234"})
+	if got.Repo == nil || got.Repo.Root != "/src/app" || got.Repo.Remote != "git@example.com:me/app.git" {
+		t.Errorf("Repo = %+v, want the stored repository", got.Repo)
+	}
+	if got.Dir != "/src/app/api" || got.Branch != "main" || got.Head != "abc1234" {
+		t.Errorf("ResolveContext() = %+v", got)
+	}
+	if got.Env == nil || got.Env.User != "me" || got.Env.Host != "laptop" {
+		t.Errorf("Env = %+v, want the stored environment", got.Env)
+	}
+
+	got = ResolveContext(s, model.Context{RepoID: &missing, CwdID: &missing})
+	if got.Repo != nil || got.Dir != "" || got.Env != nil {
+		t.Errorf("ResolveContext() of missing entries = %+v, want them left out", got)
+	}
+}
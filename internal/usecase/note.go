@@ -6,8 +6,6 @@ import (
 	"time"
 
 	"github.com/rynskrmt/wips-cli/internal/config"
-	"github.com/rynskrmt/wips-cli/internal/env"
-	"github.com/rynskrmt/wips-cli/internal/git"
 	"github.com/rynskrmt/wips-cli/internal/id"
 	"github.com/rynskrmt/wips-cli/internal/model"
 	"github.com/rynskrmt/wips-cli/internal/store"
@@ -19,6 +17,9 @@ type NoteUsecase interface {
 	// It automatically gathers context (environment, git repo, working directory).
 	// Returns the recorded event or an error.
 	RecordNote(message string, wd string) (*model.WipsEvent, error)
+
+	// Reply records a note replying to the event with the given ID.
+	Reply(parentID string, message string, wd string) (*model.WipsEvent, error)
 }
 
 type noteUsecase struct {
@@ -35,71 +36,77 @@ func NewNoteUsecase(s store.Store) NoteUsecase {
 // 2. Collects environment info (user, host).
 // 3. Collects git repository info if in a git repo.
 // 4. Saves context dictionaries to store.
-// 5. Appends the event to the store.
+// 5. Resolves the [[id-prefix]] references in the message.
+// 6. Appends the event to the store.
 func (u *noteUsecase) RecordNote(message string, wd string) (*model.WipsEvent, error) {
-	// Check Config
-	cfg, err := config.Load()
-	if err == nil {
-		for _, pattern := range cfg.IgnorePatterns {
-			matched, _ := filepath.Match(pattern, wd)
-			if matched {
-				fmt.Println("Ignored by config.")
-				return nil, nil
-			}
-			// TODO: Implement more robust matching (e.g. support for relative paths, globstar)
-		}
-	}
-
-	// Gather Context
-	ctx := model.Context{}
+	return u.record(message, wd, "")
+}
 
-	// Env Info
-	envInfo := env.GetInfo()
-	envID := id.GetHashID(envInfo.Host + envInfo.User)
-	if err := u.store.SaveDict("env", envID, envInfo); err == nil {
-		ctx.EnvID = &envID
+// Reply implementation.
+func (u *noteUsecase) Reply(parentID string, message string, wd string) (*model.WipsEvent, error) {
+	parent, err := u.store.GetEvent(parentID)
+	if err != nil {
+		return nil, err
 	}
+	return u.record(message, wd, parent.ID)
+}
 
-	// Repo Info
-	if repoInfo, err := git.GetInfo(); err == nil && repoInfo.Root != "" {
-		keyContent := repoInfo.Root
-		if repoInfo.Remote != "" {
-			keyContent = repoInfo.Remote
-		}
-		repoID := id.GetHashID(keyContent)
-
-		if err := u.store.SaveDict("repos", repoID, repoInfo); err == nil {
-			ctx.RepoID = &repoID
-		}
-
-		// Head info
-		branch, head, err := git.GetHead()
-		if err == nil {
-			ctx.Branch = branch
-			ctx.Head = head
-		}
+// record saves a note, replying to the event parentID unless it is empty.
+func (u *noteUsecase) record(message string, wd string, parentID string) (*model.WipsEvent, error) {
+	// Check Config
+	if ignoredByConfig(wd) {
+		fmt.Println("Ignored by config.")
+		return nil, nil
 	}
 
-	// CWD Info
-	// Use the provided working directory
-	cwdID := id.GetHashID(wd)
-	if err := u.store.SaveDict("dirs", cwdID, wd); err == nil {
-		ctx.CwdID = &cwdID
+	refs, err := ResolveRefs(u.store, message)
+	if err != nil {
+		return nil, err
 	}
 
+	// Gather Context
+	ctx := gatherContext(u.store, wd)
+
 	// Create Event
 	event := &model.WipsEvent{
-		ID:      id.GenerateULID(),
-		TS:      time.Now(),
-		Type:    model.EventTypeNote,
-		Content: message,
-		Ctx:     ctx,
+		ID:       id.GenerateULID(),
+		TS:       time.Now(),
+		Type:     model.EventTypeNote,
+		Content:  message,
+		Tags:     model.ParseTags(message),
+		ParentID: parentID,
+		Refs:     refs,
+		Ctx:      ctx,
 	}
 
 	// Save
 	if err := u.store.AppendEvent(event); err != nil {
 		return nil, fmt.Errorf("failed to save event: %w", err)
 	}
+	if err := RecordLinks(u.store, event.ID, parentID, refs); err != nil {
+		return nil, err
+	}
 
 	return event, nil
 }
+
+// ignoredByConfig reports whether wd matches one of the ignore patterns in the config.
+func ignoredByConfig(wd string) bool {
+	cfg, err := config.Load()
+	if err != nil {
+		return false
+	}
+	return isIgnored(cfg, wd)
+}
+
+// isIgnored reports whether wd matches one of the ignore patterns of cfg.
+func isIgnored(cfg *config.Config, wd string) bool {
+	for _, pattern := range cfg.IgnorePatterns {
+		matched, _ := filepath.Match(pattern, wd)
+		if matched {
+			return true
+		}
+		// TODO: Implement more robust matching (e.g. support for relative paths, globstar)
+	}
+	return false
+}
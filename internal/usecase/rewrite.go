@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// applyRewrite points the git_commit events of rewritten commits at the commits that replaced them.
+//
+// Amends and rebases run post-commit for every commit they create, so a new commit
+// may already have an event of its own. For each new commit, the earliest event of
+// the commits it replaces (or of itself) is kept and updated with the new SHA, and
+// the others are marked superseded. This also merges the commits squashed by a fixup.
+func (u *captureUsecase) applyRewrite(repoID *string, m model.Rewrite) error {
+	olds := make(map[string][]string) // New SHA -> the SHAs it replaces, and itself
+	var order []string
+	for _, c := range m.Commits {
+		if _, ok := olds[c.New]; !ok {
+			olds[c.New] = []string{c.New}
+			order = append(order, c.New)
+		}
+		if c.Old != c.New {
+			olds[c.New] = append(olds[c.New], c.Old)
+		}
+	}
+
+	q := store.Query{
+		Start: u.earliestCommit(m),
+		Types: []model.EventType{model.EventTypeGitCommit},
+	}
+	if repoID != nil {
+		q.RepoIDs = []string{*repoID}
+	}
+	var events []model.WipsEvent
+	err := u.store.IterateEvents(q, func(e *model.WipsEvent) error {
+		if e.Superseded() == nil {
+			events = append(events, *e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, sha := range order {
+		var group []model.WipsEvent
+		for _, e := range events {
+			if matchesAny(eventSHA(&e), olds[sha]) {
+				group = append(group, e)
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+
+		// Events are oldest first: keep the one recorded when the work was done
+		kept := group[0]
+		if c := kept.CommitMeta(); c == nil || c.SHA != sha {
+			if err := u.retarget(kept.ID, sha); err != nil {
+				return err
+			}
+		}
+		for _, e := range group[1:] {
+			marker := model.Superseded{By: kept.ID, SHA: sha, At: now}
+			err := u.store.UpdateEvent(e.ID, func(ev *model.WipsEvent) error {
+				return ev.SetMeta(model.MetaSuperseded, marker)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// retarget updates the head, commit Meta and Content of the event with the commit sha.
+func (u *captureUsecase) retarget(eventID, sha string) error {
+	c, err := readCommit(u.git, sha)
+	if err != nil {
+		return err
+	}
+	return u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		e.Ctx.Head = c.ShortSHA()
+		e.SetContent(commitContent(c))
+		return e.SetMeta(model.MetaCommit, c)
+	})
+}
+
+// earliestCommit returns a time before every event recorded for the rewritten commits,
+// or the zero time (no limit) if git cannot tell. Hooks record commits after they are made,
+// and 'wip backfill' at their author date, which comes first.
+func (u *captureUsecase) earliestCommit(m model.Rewrite) time.Time {
+	args := []string{"show", "-s", "--format=%aI"}
+	for _, c := range m.Commits {
+		args = append(args, c.Old)
+	}
+	out, err := u.git(args...)
+	if err != nil {
+		return time.Time{}
+	}
+	var earliest time.Time
+	for _, line := range strings.Fields(string(out)) {
+		t, err := time.Parse(time.RFC3339, line)
+		if err != nil {
+			return time.Time{}
+		}
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+	if earliest.IsZero() {
+		return earliest
+	}
+	// Allow for events stored with a clock slightly behind git's
+	return earliest.Add(-time.Minute)
+}
+
+// eventSHA returns the commit of a git_commit event: the full SHA from its Meta,
+// or the abbreviated head of events recorded without one.
+func eventSHA(e *model.WipsEvent) string {
+	if c := e.CommitMeta(); c != nil {
+		return c.SHA
+	}
+	return e.Ctx.Head
+}
+
+// matchesAny reports whether sha, possibly abbreviated, is one of the full SHAs.
+func matchesAny(sha string, full []string) bool {
+	if len(sha) < 7 {
+		return false
+	}
+	for _, f := range full {
+		if strings.HasPrefix(f, sha) {
+			return true
+		}
+	}
+	return false
+}
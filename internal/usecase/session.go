@@ -0,0 +1,447 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/filter"
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// ErrNoSession is returned when stopping, pausing or resuming without a session running.
+var ErrNoSession = errors.New("no session running (start one with 'wip start')")
+
+// DefaultInferGap is the longest gap between two events still counted as work
+// when sessions are inferred.
+const DefaultInferGap = 30 * time.Minute
+
+// SessionUsecase defines the business logic for time tracking.
+// A session is a start event followed by pause, resume and stop events referring
+// to it. Only one session runs at a time.
+type SessionUsecase interface {
+	// Start starts a session on topic in wd. A running session is stopped first;
+	// its stop event is returned as stopped.
+	Start(topic string, wd string) (started *model.WipsEvent, stopped *model.WipsEvent, err error)
+
+	// Pause pauses the running session.
+	Pause() (*model.WipsEvent, error)
+
+	// Resume resumes the paused session.
+	Resume() (*model.WipsEvent, error)
+
+	// Stop stops the running or paused session.
+	Stop() (*model.WipsEvent, error)
+
+	// Current returns the running or paused session, or nil if there is none.
+	Current() (*WorkSession, error)
+
+	// Report returns the time worked in a period, by repository, branch and tag.
+	Report(opts TimeReportOptions) (*TimeReport, error)
+}
+
+// WorkSession is a session rebuilt from its events.
+type WorkSession struct {
+	Start     model.WipsEvent // The start event, holding the topic and context
+	Intervals []Interval      // Periods worked; the last one has no end while running
+	Paused    bool
+	Stopped   bool
+}
+
+// Interval is a period worked in a session. To is zero while the session runs.
+type Interval struct {
+	From time.Time
+	To   time.Time
+}
+
+// Worked returns the time worked in the session between from and to.
+// A running session counts until now.
+func (s *WorkSession) Worked(from, to, now time.Time) time.Duration {
+	var total time.Duration
+	for _, iv := range s.Intervals {
+		start, end := iv.From, iv.To
+		if end.IsZero() {
+			end = now
+		}
+		if !from.IsZero() && start.Before(from) {
+			start = from
+		}
+		if !to.IsZero() && end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// BuildSessions rebuilds sessions from session events in chronological order.
+// Events of sessions started before the first event given are ignored.
+func BuildSessions(events []model.WipsEvent) []*WorkSession {
+	var sessions []*WorkSession
+	byStart := make(map[string]*WorkSession)
+	for _, e := range events {
+		meta := e.SessionMeta()
+		if meta == nil {
+			continue
+		}
+		if meta.Action == model.SessionStart {
+			s := &WorkSession{Start: e, Intervals: []Interval{{From: e.TS}}}
+			sessions = append(sessions, s)
+			byStart[e.ID] = s
+			continue
+		}
+
+		s := byStart[meta.Start]
+		if s == nil || s.Stopped {
+			continue
+		}
+		running := !s.Paused
+		switch meta.Action {
+		case model.SessionPause:
+			if running {
+				s.Intervals[len(s.Intervals)-1].To = e.TS
+				s.Paused = true
+			}
+		case model.SessionResume:
+			if !running {
+				s.Intervals = append(s.Intervals, Interval{From: e.TS})
+				s.Paused = false
+			}
+		case model.SessionStop:
+			if running {
+				s.Intervals[len(s.Intervals)-1].To = e.TS
+			}
+			s.Stopped = true
+		}
+	}
+	return sessions
+}
+
+type sessionUsecase struct {
+	store store.Store
+}
+
+// NewSessionUsecase creates a new SessionUsecase instance.
+func NewSessionUsecase(s store.Store) SessionUsecase {
+	return &sessionUsecase{store: s}
+}
+
+func (u *sessionUsecase) Start(topic string, wd string) (*model.WipsEvent, *model.WipsEvent, error) {
+	stopped, err := u.Stop()
+	if err != nil && !errors.Is(err, ErrNoSession) {
+		return nil, nil, err
+	}
+
+	event := &model.WipsEvent{
+		ID:      id.GenerateULID(),
+		TS:      time.Now(),
+		Type:    model.EventTypeSession,
+		Content: topic,
+		Tags:    model.ParseTags(topic),
+		Ctx:     gatherContext(u.store, wd),
+	}
+	if err := event.SetMeta(model.MetaSession, model.Session{Action: model.SessionStart}); err != nil {
+		return nil, nil, err
+	}
+	if err := u.store.AppendEvent(event); err != nil {
+		return nil, nil, fmt.Errorf("failed to save event: %w", err)
+	}
+	return event, stopped, nil
+}
+
+func (u *sessionUsecase) Pause() (*model.WipsEvent, error) {
+	s, err := u.running()
+	if err != nil {
+		return nil, err
+	}
+	if s.Paused {
+		return nil, fmt.Errorf("session %q is already paused", s.Start.Content)
+	}
+	return u.record(s, model.Session{Action: model.SessionPause})
+}
+
+func (u *sessionUsecase) Resume() (*model.WipsEvent, error) {
+	s, err := u.running()
+	if err != nil {
+		return nil, err
+	}
+	if !s.Paused {
+		return nil, fmt.Errorf("session %q is not paused", s.Start.Content)
+	}
+	return u.record(s, model.Session{Action: model.SessionResume})
+}
+
+func (u *sessionUsecase) Stop() (*model.WipsEvent, error) {
+	s, err := u.running()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return u.record(s, model.Session{Action: model.SessionStop, Elapsed: s.Worked(time.Time{}, now, now)})
+}
+
+// running returns the current session, or ErrNoSession.
+func (u *sessionUsecase) running() (*WorkSession, error) {
+	s, err := u.Current()
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrNoSession
+	}
+	return s, nil
+}
+
+// Current implementation.
+// The latest session event tells whether a session is running; its events are
+// then read from its start on.
+func (u *sessionUsecase) Current() (*WorkSession, error) {
+	start, err := u.runningAt(time.Time{})
+	if err != nil || start == nil {
+		return nil, err
+	}
+	events, err := u.store.QueryEvents(store.Query{Start: start.TS, Types: []model.EventType{model.EventTypeSession}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	for _, s := range BuildSessions(events) {
+		if s.Start.ID == start.ID && !s.Stopped {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+// runningAt returns the start event of the session running at t, or of the
+// running session if t is zero; nil if there is none. As only one session runs
+// at a time, it is the session of the latest event before t.
+func (u *sessionUsecase) runningAt(t time.Time) (*model.WipsEvent, error) {
+	var latest *model.WipsEvent
+	q := store.Query{Types: []model.EventType{model.EventTypeSession}, Reverse: true}
+	if !t.IsZero() {
+		q.End = t.Add(-time.Nanosecond)
+	}
+	err := u.store.IterateEvents(q, func(e *model.WipsEvent) error {
+		if e.SessionMeta() != nil {
+			latest = e
+			return store.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	if latest == nil || latest.SessionMeta().Action == model.SessionStop {
+		return nil, nil
+	}
+
+	start, err := u.store.GetEvent(latest.SessionMeta().StartID(latest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session start: %w", err)
+	}
+	return start, nil
+}
+
+// record appends an event of the session s.
+func (u *sessionUsecase) record(s *WorkSession, meta model.Session) (*model.WipsEvent, error) {
+	meta.Start = s.Start.ID
+	event := &model.WipsEvent{
+		ID:      id.GenerateULID(),
+		TS:      time.Now(),
+		Type:    model.EventTypeSession,
+		Content: s.Start.Content,
+		Tags:    s.Start.Tags,
+		Ctx:     s.Start.Ctx,
+	}
+	if err := event.SetMeta(model.MetaSession, meta); err != nil {
+		return nil, err
+	}
+	if err := u.store.AppendEvent(event); err != nil {
+		return nil, fmt.Errorf("failed to save event: %w", err)
+	}
+	return event, nil
+}
+
+// TimeReportOptions select the period and the source of a time report.
+type TimeReportOptions struct {
+	Start time.Time
+	End   time.Time // Inclusive
+
+	// Inferred estimates the time worked from notes, tasks and commits instead
+	// of sessions: each is credited with the time since the previous one, up to Gap.
+	Inferred bool
+	Gap      time.Duration
+
+	HiddenDirs []string // Hidden directory patterns from config
+}
+
+// TimeReport is the time worked in a period.
+type TimeReport struct {
+	Start time.Time
+	End   time.Time
+	Total time.Duration
+	Repos []TimeEntry // By repository (or directory), with their branches; most time first
+	Tags  []TimeEntry // Most time first
+}
+
+// TimeEntry is the time worked on a repository, branch or tag.
+type TimeEntry struct {
+	Name     string
+	Duration time.Duration
+	Branches []TimeEntry // Repositories only
+}
+
+func (u *sessionUsecase) Report(opts TimeReportOptions) (*TimeReport, error) {
+	dirsDict, err := u.store.LoadDict("dirs")
+	if err != nil {
+		dirsDict = make(map[string]interface{})
+	}
+	reposDict, err := u.store.LoadDict("repos")
+	if err != nil {
+		reposDict = make(map[string]interface{})
+	}
+
+	t := newTimeTally()
+	credit := func(e *model.WipsEvent, d time.Duration) {
+		if e.Ctx.CwdID != nil {
+			if dirPath, ok := dirsDict[*e.Ctx.CwdID].(string); ok && filter.IsHiddenDir(dirPath, opts.HiddenDirs) {
+				return
+			}
+		}
+		t.add(GroupNames(e, GroupByDir, reposDict, dirsDict)[0], e.Ctx.Branch, e.TagList(), d)
+	}
+
+	if opts.Inferred {
+		err = u.infer(opts, credit)
+	} else {
+		err = u.fromSessions(opts, credit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := t.report()
+	report.Start, report.End = opts.Start, opts.End
+	return report, nil
+}
+
+// fromSessions credits the time worked in sessions during the period to their start events.
+func (u *sessionUsecase) fromSessions(opts TimeReportOptions, credit func(*model.WipsEvent, time.Duration)) error {
+	// A session started before the period may run into it: its events are read
+	// from its start on
+	q := store.Query{Start: opts.Start, End: opts.End, Types: []model.EventType{model.EventTypeSession}}
+	if !opts.Start.IsZero() {
+		start, err := u.runningAt(opts.Start)
+		if err != nil {
+			return err
+		}
+		if start != nil {
+			q.Start = start.TS
+		}
+	}
+	events, err := u.store.QueryEvents(q)
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+	now := time.Now()
+	for _, s := range BuildSessions(events) {
+		if d := s.Worked(opts.Start, opts.End, now); d > 0 {
+			credit(&s.Start, d)
+		}
+	}
+	return nil
+}
+
+// infer credits each note, task and commit in the period with the time since
+// the previous one, up to the gap; the first one after a longer break gets the gap.
+func (u *sessionUsecase) infer(opts TimeReportOptions, credit func(*model.WipsEvent, time.Duration)) error {
+	gap := opts.Gap
+	if gap <= 0 {
+		gap = DefaultInferGap
+	}
+	j, err := journal.Load(u.store)
+	if err != nil {
+		return err
+	}
+
+	var prev time.Time
+	q := store.Query{
+		Start: opts.Start.Add(-gap),
+		End:   opts.End,
+		Types: []model.EventType{model.EventTypeNote, model.EventTypeTask, model.EventTypeGitCommit},
+	}
+	return u.store.IterateEvents(q, func(raw *model.WipsEvent) error {
+		e, visible := j.ResolveEvent(*raw)
+		if !visible {
+			return nil
+		}
+		d := gap
+		if !prev.IsZero() && e.TS.Sub(prev) < gap {
+			d = e.TS.Sub(prev)
+		}
+		prev = e.TS
+		if !e.TS.Before(opts.Start) {
+			credit(&e, d)
+		}
+		return nil
+	})
+}
+
+// timeTally sums durations by repository, branch and tag.
+type timeTally struct {
+	total    time.Duration
+	repos    map[string]time.Duration
+	branches map[string]map[string]time.Duration
+	tags     map[string]time.Duration
+}
+
+func newTimeTally() *timeTally {
+	return &timeTally{
+		repos:    make(map[string]time.Duration),
+		branches: make(map[string]map[string]time.Duration),
+		tags:     make(map[string]time.Duration),
+	}
+}
+
+func (t *timeTally) add(repo, branch string, tags []string, d time.Duration) {
+	t.total += d
+	t.repos[repo] += d
+	if branch != "" {
+		if t.branches[repo] == nil {
+			t.branches[repo] = make(map[string]time.Duration)
+		}
+		t.branches[repo][branch] += d
+	}
+	for _, tag := range tags {
+		t.tags["#"+tag] += d
+	}
+}
+
+func (t *timeTally) report() *TimeReport {
+	r := &TimeReport{Total: t.total, Repos: sortedEntries(t.repos), Tags: sortedEntries(t.tags)}
+	for i := range r.Repos {
+		r.Repos[i].Branches = sortedEntries(t.branches[r.Repos[i].Name])
+	}
+	return r
+}
+
+// sortedEntries returns the durations as entries, most time first.
+func sortedEntries(durations map[string]time.Duration) []TimeEntry {
+	var entries []TimeEntry
+	for name, d := range durations {
+		entries = append(entries, TimeEntry{Name: name, Duration: d})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Duration != entries[j].Duration {
+			return entries[i].Duration > entries[j].Duration
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func newSessionTestStore(t *testing.T) store.Store {
+	t.Helper()
+	tmp, err := os.MkdirTemp("", "wips_test_session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// sessionEvent returns a session event at ts; start is the start event for the other actions.
+func sessionEvent(t *testing.T, ts time.Time, action model.SessionAction, start *model.WipsEvent, topic string, ctx model.Context) *model.WipsEvent {
+	t.Helper()
+	e := &model.WipsEvent{ID: id.GenerateULIDAt(ts), TS: ts, Type: model.EventTypeSession, Content: topic, Tags: model.ParseTags(topic), Ctx: ctx}
+	meta := model.Session{Action: action}
+	if start != nil {
+		meta.Start = start.ID
+	}
+	if err := e.SetMeta(model.MetaSession, meta); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestSessionUsecase(t *testing.T) {
+	s := newSessionTestStore(t)
+	uc := NewSessionUsecase(s)
+	wd := t.TempDir()
+
+	if _, err := uc.Stop(); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Stop() without a session error = %v, want ErrNoSession", err)
+	}
+
+	first, stopped, err := uc.Start("review #backend", wd)
+	if err != nil || stopped != nil {
+		t.Fatalf("Start() = %v, %v, %v", first, stopped, err)
+	}
+	if !first.HasTag("backend") {
+		t.Errorf("session tags = %v, want #backend", first.Tags)
+	}
+
+	if _, err := uc.Resume(); err == nil {
+		t.Error("Resume() of a running session expected error")
+	}
+	if _, err := uc.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := uc.Pause(); err == nil {
+		t.Error("Pause() of a paused session expected error")
+	}
+	current, err := uc.Current()
+	if err != nil || current == nil || !current.Paused || current.Start.ID != first.ID {
+		t.Fatalf("Current() after Pause() = %+v, %v", current, err)
+	}
+	resumed, err := uc.Resume()
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resumed.Content != first.Content || !resumed.HasTag("backend") {
+		t.Errorf("resume event = %+v, want the topic and tags of the start", resumed)
+	}
+
+	// Starting another session stops the running one
+	second, stopped, err := uc.Start("docs", wd)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if stopped == nil || stopped.SessionMeta().StartID(stopped) != first.ID {
+		t.Errorf("Start() stopped %+v, want the first session", stopped)
+	}
+	current, err = uc.Current()
+	if err != nil || current == nil || current.Start.ID != second.ID || current.Paused {
+		t.Fatalf("Current() = %+v, %v, want the second session running", current, err)
+	}
+
+	if _, err := uc.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if current, err := uc.Current(); err != nil || current != nil {
+		t.Errorf("Current() after Stop() = %+v, %v, want nil", current, err)
+	}
+}
+
+func TestBuildSessions(t *testing.T) {
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.Local)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	start := sessionEvent(t, at(0), model.SessionStart, nil, "a", model.Context{})
+	other := sessionEvent(t, at(200), model.SessionStart, nil, "b", model.Context{})
+	events := []model.WipsEvent{
+		*start,
+		*sessionEvent(t, at(60), model.SessionPause, start, "a", model.Context{}),
+		*sessionEvent(t, at(70), model.SessionPause, start, "a", model.Context{}), // Already paused
+		*sessionEvent(t, at(90), model.SessionResume, start, "a", model.Context{}),
+		*sessionEvent(t, at(120), model.SessionStop, start, "a", model.Context{}),
+		*sessionEvent(t, at(130), model.SessionResume, start, "a", model.Context{}), // Already stopped
+		*other,
+	}
+
+	sessions := BuildSessions(events)
+	if len(sessions) != 2 {
+		t.Fatalf("BuildSessions() returned %d sessions, want 2", len(sessions))
+	}
+	now := at(230)
+	tests := []struct {
+		name     string
+		session  *WorkSession
+		from, to time.Time
+		want     time.Duration
+	}{
+		{"Without pauses", sessions[0], time.Time{}, time.Time{}, 90 * time.Minute},
+		{"Clipped", sessions[0], at(30), at(100), 40 * time.Minute},
+		{"Running until now", sessions[1], time.Time{}, time.Time{}, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := tt.session.Worked(tt.from, tt.to, now); got != tt.want {
+			t.Errorf("%s: Worked() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !sessions[0].Stopped || sessions[1].Stopped {
+		t.Errorf("Stopped = %v, %v, want true, false", sessions[0].Stopped, sessions[1].Stopped)
+	}
+}
+
+func TestSessionUsecase_Report(t *testing.T) {
+	s := newSessionTestStore(t)
+	uc := NewSessionUsecase(s)
+
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.Local)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	app := "repo-app"
+	if err := s.SaveDict("repos", app, map[string]interface{}{"name": "app"}); err != nil {
+		t.Fatal(err)
+	}
+	ctxMain := model.Context{RepoID: &app, Branch: "main"}
+	ctxFeature := model.Context{RepoID: &app, Branch: "feature"}
+
+	// The first session starts the day before the period
+	review := sessionEvent(t, base.Add(-30*time.Minute), model.SessionStart, nil, "review #backend", ctxMain)
+	docs := sessionEvent(t, at(120), model.SessionStart, nil, "docs", ctxFeature)
+	for _, e := range []*model.WipsEvent{
+		review,
+		sessionEvent(t, at(60), model.SessionStop, review, review.Content, ctxMain),
+		docs,
+		sessionEvent(t, at(150), model.SessionStop, docs, docs.Content, ctxFeature),
+	} {
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := uc.Report(TimeReportOptions{Start: base, End: base.AddDate(0, 0, 1).Add(-time.Nanosecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 90*time.Minute {
+		t.Errorf("Total = %v, want 1h30m", report.Total)
+	}
+	if len(report.Repos) != 1 || report.Repos[0].Name != "@app" || len(report.Repos[0].Branches) != 2 {
+		t.Fatalf("Repos = %+v, want @app with two branches", report.Repos)
+	}
+	if b := report.Repos[0].Branches[0]; b.Name != "main" || b.Duration != time.Hour {
+		t.Errorf("first branch = %+v, want main for 1h", b)
+	}
+	if len(report.Tags) != 1 || report.Tags[0].Name != "#backend" || report.Tags[0].Duration != time.Hour {
+		t.Errorf("Tags = %+v, want #backend for 1h", report.Tags)
+	}
+	// A period without events, in the middle of a session
+	report, err = uc.Report(TimeReportOptions{Start: at(130), End: at(140)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 10*time.Minute {
+		t.Errorf("Total within a session = %v, want 10m", report.Total)
+	}
+}
+
+func TestSessionUsecase_ReportInferred(t *testing.T) {
+	s := newSessionTestStore(t)
+	uc := NewSessionUsecase(s)
+
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.Local)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	note := func(ts time.Time, content string) {
+		e := &model.WipsEvent{ID: id.GenerateULID(), TS: ts, Type: model.EventTypeNote, Content: content, Tags: model.ParseTags(content)}
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	note(at(-10), "before the period")
+	note(at(5), "first #api") // 15m since the previous one
+	note(at(20), "second")    // 15m
+	note(at(200), "third")    // After a break: the gap
+
+	report, err := uc.Report(TimeReportOptions{Start: base, End: at(600), Inferred: true, Gap: 30 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != time.Hour {
+		t.Errorf("Total = %v, want 1h", report.Total)
+	}
+	if len(report.Tags) != 1 || report.Tags[0].Duration != 15*time.Minute {
+		t.Errorf("Tags = %+v, want #api for 15m", report.Tags)
+	}
+}
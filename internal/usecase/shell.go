@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rynskrmt/wips-cli/internal/config"
+	"github.com/rynskrmt/wips-cli/internal/git"
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/shell"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// ShellUsecase defines the business logic for the events spooled by the shell integration.
+type ShellUsecase interface {
+	// Flush records the entries of the spool file at path, then removes them.
+	// It returns the number of events recorded.
+	Flush(path string) (int, error)
+}
+
+type shellUsecase struct {
+	store store.Store
+	cfg   *config.Config
+}
+
+// NewShellUsecase creates a new ShellUsecase.
+func NewShellUsecase(s store.Store, cfg *config.Config) ShellUsecase {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return &shellUsecase{store: s, cfg: cfg}
+}
+
+// Flush implementation.
+// Directory changes are recorded only when they enter a different repository than the
+// previous one, and commands only when they ran for the configured threshold.
+// Ignored and hidden directories are skipped, as are wip's own commands.
+func (u *shellUsecase) Flush(path string) (int, error) {
+	recorded := 0
+	err := shell.Drain(path, func(entries []shell.Entry) (int, error) {
+		repo, err := u.lastRepo()
+		if err != nil {
+			return 0, err
+		}
+
+		for i, e := range entries {
+			if isIgnored(u.cfg, e.Dir) || u.cfg.IsHiddenDir(e.Dir) {
+				continue
+			}
+
+			var event *model.WipsEvent
+			switch e.Kind {
+			case shell.KindDir:
+				info, _ := git.GetInfo(e.Dir)
+				if info.Root == "" {
+					repo = ""
+					continue
+				}
+				if repoIDOf(info) == repo {
+					continue
+				}
+				repo = repoIDOf(info)
+				event = &model.WipsEvent{Type: model.EventTypeDir, Content: e.Dir}
+
+			case shell.KindCommand:
+				if e.Duration < u.cfg.Shell.Threshold() || isWipCommand(e.Command) {
+					continue
+				}
+				event = &model.WipsEvent{Type: model.EventTypeCommand, Content: e.Command, Tags: model.ParseTags(e.Command)}
+				run := model.Command{ExitCode: e.ExitCode, Duration: e.Duration, Shell: true}
+				if err := event.SetMeta(model.MetaCommand, run); err != nil {
+					return i, err
+				}
+			}
+
+			event.TS = e.At.Local()
+			event.ID = id.GenerateULIDAt(event.TS)
+			event.Ctx = gatherContext(u.store, e.Dir)
+			if err := u.store.AppendEvent(event); err != nil {
+				return i, fmt.Errorf("failed to save event: %w", err)
+			}
+			recorded++
+		}
+		return len(entries), nil
+	})
+	return recorded, err
+}
+
+// lastRepo returns the repository of the last directory change recorded, if any.
+func (u *shellUsecase) lastRepo() (string, error) {
+	repo := ""
+	q := store.Query{Types: []model.EventType{model.EventTypeDir}, Reverse: true}
+	err := u.store.IterateEvents(q, func(e *model.WipsEvent) error {
+		if e.Ctx.RepoID != nil {
+			repo = *e.Ctx.RepoID
+		}
+		return store.ErrStop
+	})
+	return repo, err
+}
+
+// isWipCommand reports whether a command line runs wip, which records 'wip run' itself.
+func isWipCommand(line string) bool {
+	return line == "wip" || strings.HasPrefix(line, "wip ")
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rynskrmt/wips-cli/internal/config"
+	"github.com/rynskrmt/wips-cli/internal/shell"
+)
+
+func TestShellUsecase_Flush(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_shell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	app := filepath.Join(tmp, "app")
+	plain := filepath.Join(tmp, "plain")
+	secret := filepath.Join(tmp, "secret")
+	for _, dir := range []string{filepath.Join(app, "sub"), plain, secret} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := exec.Command("git", "init", "-q", app).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	lines := []string{
+		"cd\t1700000000\t" + app,
+		"cd\t1700000010\t" + filepath.Join(app, "sub"), // Same repository
+		"cmd\t1700000020\t" + app + "\t1\t30\tmake test",
+		"cmd\t1700000030\t" + app + "\t0\t2\tls", // Too short
+		"cmd\t1700000040\t" + app + "\t0\t30\twip run -- make test",
+		"cd\t1700000050\t" + plain, // Leaves the repository
+		"cd\t1700000060\t" + app,
+		"cmd\t1700000070\t" + secret + "\t0\t30\tmake secret", // Hidden
+	}
+	spool := filepath.Join(tmp, shell.SpoolFile)
+	if err := os.WriteFile(spool, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ms := &MockStore{}
+	cfg := &config.Config{HiddenDirectories: []string{secret}, Shell: config.ShellConfig{CommandThreshold: 5}}
+	n, err := NewShellUsecase(ms, cfg).Flush(spool)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	var got []string
+	for _, e := range ms.Events {
+		got = append(got, fmt.Sprintf("%s %s", e.Type, e.Content))
+		if e.TS.Unix() < 1700000000 || e.Ctx.RepoID == nil {
+			t.Errorf("event %s %q has time %v and repo %v", e.Type, e.Content, e.TS, e.Ctx.RepoID)
+		}
+	}
+	want := []string{"dir " + app, "command make test", "dir " + app}
+	if n != len(want) || strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Flush() recorded %d events:\n%s\nwant:\n%s", n, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if c := ms.Events[1].CommandMeta(); c == nil || c.ExitCode != 1 || !c.Shell {
+		t.Errorf("command meta = %+v", c)
+	}
+	if _, err := os.Stat(spool); !os.IsNotExist(err) {
+		t.Error("the spool should be removed")
+	}
+
+	// The repository is remembered across flushes
+	if err := os.WriteFile(spool, []byte("cd\t1700000100\t"+app+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := NewShellUsecase(ms, cfg).Flush(spool); err != nil || n != 0 {
+		t.Errorf("second Flush() = %d, %v, want nothing recorded", n, err)
+	}
+}
@@ -1,10 +1,12 @@
 package usecase
 
 import (
+	"fmt"
 	"sort"
 	"time"
 
 	"github.com/rynskrmt/wips-cli/internal/filter"
+	"github.com/rynskrmt/wips-cli/internal/journal"
 	"github.com/rynskrmt/wips-cli/internal/model"
 	"github.com/rynskrmt/wips-cli/internal/store"
 )
@@ -26,25 +28,89 @@ type SummaryOptions struct {
 	LastWeek      bool     // Filter by last week
 	Days          int      // Filter by past N days
 	CommitsOnly   bool     // Show only git commits
-	NotesOnly     bool     // Show only manual notes
+	NotesOnly     bool     // Show only manual notes and tasks
 	IncludeHidden bool     // Include hidden directories
 	HiddenOnly    bool     // Show only hidden directories
 	HiddenDirs    []string // List of hidden directory patterns from config
 	Date          string   // Filter by specific date (YYYY-MM-DD)
+	All           bool     // Include every event up to now
+	GroupBy       GroupBy  // Group events by directory (default) or by tag
+}
+
+// GroupBy selects how events are grouped within a day.
+type GroupBy string
+
+const (
+	GroupByDir GroupBy = "dir" // By repository, or directory outside repositories
+	GroupByTag GroupBy = "tag" // By tag; events with several tags appear in each group
+)
+
+// Untagged is the name of the group of events without tags when grouping by tag.
+const Untagged = "(untagged)"
+
+// ParseGroupBy parses the value of a --by flag. An empty value groups by directory.
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch GroupBy(s) {
+	case "", GroupByDir:
+		return GroupByDir, nil
+	case GroupByTag:
+		return GroupByTag, nil
+	}
+	return "", fmt.Errorf("invalid grouping %q (expected dir or tag)", s)
+}
+
+// GroupNames returns the names of the groups an event belongs to, using the
+// repos and dirs dictionaries to name directories.
+func GroupNames(e *model.WipsEvent, by GroupBy, reposDict, dirsDict map[string]interface{}) []string {
+	if by == GroupByTag {
+		tags := e.TagList()
+		if len(tags) == 0 {
+			return []string{Untagged}
+		}
+		names := make([]string, len(tags))
+		for i, t := range tags {
+			names[i] = "#" + t
+		}
+		return names
+	}
+
+	if e.Ctx.RepoID != nil {
+		if repoData, ok := reposDict[*e.Ctx.RepoID].(map[string]interface{}); ok {
+			if name, ok := repoData["name"].(string); ok {
+				return []string{"@" + name}
+			}
+		}
+	}
+	if e.Ctx.CwdID != nil {
+		if dirPath, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
+			return []string{"📁 " + dirPath}
+		}
+	}
+	return []string{"(unknown)"}
 }
 
 // SummaryResult holds the grouped data for display.
-// Events are grouped by Day, then by Directory/Context.
+// Events are grouped by Day, then by Directory/Context or by tag.
 type SummaryResult struct {
 	Start     time.Time
 	End       time.Time
 	DayGroups []DayDirGroup
 }
 
-// DirGroup represents a group of events within a specific directory/repository.
+// DirGroup represents a group of events within a specific directory/repository, or with a tag.
 type DirGroup struct {
-	Name   string // Display name (e.g. "@wips-cli" or "📁 /path/to/dir")
+	Name   string // Display name (e.g. "@wips-cli", "📁 /path/to/dir" or "#bug")
 	Events []model.WipsEvent
+
+	// Depths are the reply depths of Events: replies follow the event they
+	// reply to when it is in the group, one level deeper.
+	Depths []int
+
+	// Line churn of the commits in the group. Commits recorded by earlier
+	// versions count towards Commits only, as their line counts are unknown.
+	Commits    int
+	Insertions int
+	Deletions  int
 }
 
 // DayDirGroup represents a group of events for a specific day.
@@ -52,17 +118,23 @@ type DayDirGroup struct {
 	Date     string // YYYY-MM-DD
 	DirMap   map[string]*DirGroup
 	DirOrder []string // Sorted list of keys for DirMap
+
+	// Tasks recorded and completed on the day. A task completed on a later
+	// day counts towards both days.
+	TasksOpened int
+	TasksDone   int
 }
 
-// GetSummary retrieves and organizes events based on options.
-// It returns a tree-like structure (Day -> Directory -> Events) suitable for rendering.
-func (u *SummaryUsecase) GetSummary(opts SummaryOptions) (*SummaryResult, error) {
-	now := time.Now()
+// Period returns the time range selected by the options, relative to now.
+// Without any period option it is today. The end is inclusive.
+func (opts SummaryOptions) Period(now time.Time) (time.Time, time.Time, error) {
 	var start time.Time
 	end := now
 
 	// Determine time range
-	if opts.LastWeek {
+	if opts.All {
+		start = time.Time{}
+	} else if opts.LastWeek {
 		weekday := int(now.Weekday())
 		if weekday == 0 {
 			weekday = 7
@@ -80,7 +152,7 @@ func (u *SummaryUsecase) GetSummary(opts SummaryOptions) (*SummaryResult, error)
 		// Specific Date
 		parsedDate, err := time.ParseInLocation("2006-01-02", opts.Date, time.Local)
 		if err != nil {
-			return nil, err
+			return time.Time{}, time.Time{}, err
 		}
 		start = parsedDate
 		end = parsedDate.Add(24*time.Hour - time.Nanosecond)
@@ -90,8 +162,26 @@ func (u *SummaryUsecase) GetSummary(opts SummaryOptions) (*SummaryResult, error)
 		// Today
 		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	}
+	return start, end, nil
+}
+
+// GetSummary retrieves and organizes events based on options.
+// It returns a tree-like structure (Day -> Directory -> Events) suitable for rendering.
+func (u *SummaryUsecase) GetSummary(opts SummaryOptions) (*SummaryResult, error) {
+	start, end, err := opts.Period(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	q := store.Query{Start: start, End: end}
+	if opts.CommitsOnly {
+		q.Types = []model.EventType{model.EventTypeGitCommit}
+	} else if opts.NotesOnly {
+		q.Types = []model.EventType{model.EventTypeNote, model.EventTypeTask}
+	}
 
-	events, err := u.Store.GetEvents(start, end)
+	// Hide undone events and roll back undone edits
+	j, err := journal.Load(u.Store)
 	if err != nil {
 		return nil, err
 	}
@@ -106,91 +196,125 @@ func (u *SummaryUsecase) GetSummary(opts SummaryOptions) (*SummaryResult, error)
 		reposDict = make(map[string]interface{})
 	}
 
-	// Filter
-	var filteredEvents []model.WipsEvent
-	for _, e := range events {
-		if opts.CommitsOnly && e.Type != model.EventTypeGitCommit {
-			continue
+	// Hidden directory filtering
+	shown := func(e *model.WipsEvent) bool {
+		if len(opts.HiddenDirs) == 0 {
+			return true
 		}
-		if opts.NotesOnly && e.Type != model.EventTypeNote {
-			continue
+		// Get dir path for this event
+		var dirPath string
+		if e.Ctx.CwdID != nil {
+			if dp, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
+				dirPath = dp
+			}
 		}
 
-		// Hidden directory filtering
-		if len(opts.HiddenDirs) > 0 {
-			// Get dir path for this event
-			var dirPath string
-			if e.Ctx.CwdID != nil {
-				if dp, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
-					dirPath = dp
-				}
-			}
+		eventIsHidden := filter.IsHiddenDir(dirPath, opts.HiddenDirs)
+		if opts.HiddenOnly {
+			return eventIsHidden
+		}
+		return opts.IncludeHidden || !eventIsHidden
+	}
 
-			eventIsHidden := filter.IsHiddenDir(dirPath, opts.HiddenDirs)
+	// Filter while streaming, so only the events shown are kept in memory
+	var events []model.WipsEvent
+	err = u.Store.IterateEvents(q, func(raw *model.WipsEvent) error {
+		e, visible := j.ResolveEvent(*raw)
+		if !visible || !shown(&e) {
+			return nil
+		}
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			if opts.HiddenOnly {
-				if !eventIsHidden {
-					continue
-				}
-			} else if !opts.IncludeHidden {
-				if eventIsHidden {
-					continue
-				}
+	// Tasks completed in the period may have been recorded before it: only the
+	// events since the oldest of those done in the period are read
+	tasksDone := make(map[string]int)
+	var doneInPeriod []string
+	if !opts.CommitsOnly {
+		states, err := loadTaskStates(u.Store)
+		if err != nil {
+			return nil, err
+		}
+		for task, done := range states {
+			if done != nil && !done.Before(start) && !done.After(end) {
+				doneInPeriod = append(doneInPeriod, task)
 			}
 		}
-
-		filteredEvents = append(filteredEvents, e)
 	}
-	events = filteredEvents
+	if len(doneInPeriod) > 0 {
+		taskQuery := store.Query{Start: tasksSince(doneInPeriod), End: end, Types: []model.EventType{model.EventTypeTask}}
+		err = u.Store.IterateEvents(taskQuery, func(raw *model.WipsEvent) error {
+			e, visible := j.ResolveEvent(*raw)
+			if !visible || !shown(&e) {
+				return nil
+			}
+			if d := e.Done(); d != nil && !d.At.Before(start) && !d.At.After(end) {
+				tasksDone[d.At.In(time.Local).Format("2006-01-02")]++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if len(events) == 0 {
+	if len(events) == 0 && len(tasksDone) == 0 {
 		return &SummaryResult{Start: start, End: end, DayGroups: nil}, nil
 	}
 
 	// Grouping
 	var dayGroups []DayDirGroup
-	dayGroupMap := make(map[string]*DayDirGroup)
-
-	for _, e := range events {
-		dateStr := e.TS.In(time.Local).Format("2006-01-02")
-
-		if _, exists := dayGroupMap[dateStr]; !exists {
+	dayGroupIndex := make(map[string]int)
+	dayGroupOf := func(dateStr string) *DayDirGroup {
+		if _, exists := dayGroupIndex[dateStr]; !exists {
 			dayGroups = append(dayGroups, DayDirGroup{
 				Date:   dateStr,
 				DirMap: make(map[string]*DirGroup),
 			})
-			dayGroupMap[dateStr] = &dayGroups[len(dayGroups)-1]
+			dayGroupIndex[dateStr] = len(dayGroups) - 1
 		}
-		dayGroup := dayGroupMap[dateStr]
+		return &dayGroups[dayGroupIndex[dateStr]]
+	}
 
-		// Resolve Dir Name
-		var dirName string
-		if e.Ctx.RepoID != nil {
-			if repoData, ok := reposDict[*e.Ctx.RepoID].(map[string]interface{}); ok {
-				if name, ok := repoData["name"].(string); ok {
-					dirName = "@" + name
-				}
-			}
+	for _, e := range events {
+		dayGroup := dayGroupOf(e.TS.In(time.Local).Format("2006-01-02"))
+		if e.Type == model.EventTypeTask {
+			dayGroup.TasksOpened++
 		}
-		if dirName == "" && e.Ctx.CwdID != nil {
-			if dirPath, ok := dirsDict[*e.Ctx.CwdID].(string); ok {
-				dirName = "📁 " + dirPath
+
+		for _, name := range GroupNames(&e, opts.GroupBy, reposDict, dirsDict) {
+			if _, exists := dayGroup.DirMap[name]; !exists {
+				dayGroup.DirMap[name] = &DirGroup{Name: name, Events: []model.WipsEvent{}}
+				dayGroup.DirOrder = append(dayGroup.DirOrder, name)
+			}
+			group := dayGroup.DirMap[name]
+			group.Events = append(group.Events, e)
+			if e.Type == model.EventTypeGitCommit {
+				group.Commits++
+				if c := e.CommitMeta(); c != nil {
+					ins, del := c.Churn()
+					group.Insertions += ins
+					group.Deletions += del
+				}
 			}
 		}
-		if dirName == "" {
-			dirName = "(unknown)"
-		}
+	}
 
-		if _, exists := dayGroup.DirMap[dirName]; !exists {
-			dayGroup.DirMap[dirName] = &DirGroup{Name: dirName, Events: []model.WipsEvent{}}
-			dayGroup.DirOrder = append(dayGroup.DirOrder, dirName)
-		}
-		dayGroup.DirMap[dirName].Events = append(dayGroup.DirMap[dirName].Events, e)
+	for dateStr, n := range tasksDone {
+		dayGroupOf(dateStr).TasksDone = n
 	}
 
 	// Sort
+	sort.Slice(dayGroups, func(i, j int) bool { return dayGroups[i].Date < dayGroups[j].Date })
 	for _, dg := range dayGroups {
 		sort.Strings(dg.DirOrder)
+		for _, group := range dg.DirMap {
+			group.Events, group.Depths = threadOrder(group.Events)
+		}
 	}
 
 	return &SummaryResult{
@@ -1,10 +1,14 @@
 package usecase
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/rynskrmt/wips-cli/internal/id"
 	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
 )
 
 // MockStore implements store.Store for testing
@@ -38,9 +42,51 @@ func (m *MockStore) GetEvents(start, end time.Time) ([]model.WipsEvent, error) {
 	}
 	return filtered, nil
 }
-func (m *MockStore) UpdateEvent(id string, mutator func(*model.WipsEvent) error) error { return nil }
-func (m *MockStore) DeleteEvent(id string) error                                       { return nil }
-func (m *MockStore) GetRootDir() string                                                { return "" }
+func (m *MockStore) QueryEvents(q store.Query) ([]model.WipsEvent, error) {
+	var filtered []model.WipsEvent
+	for i := range m.Events {
+		if q.Matches(&m.Events[i]) {
+			filtered = append(filtered, m.Events[i])
+		}
+	}
+	return filtered, nil
+}
+func (m *MockStore) IterateEvents(q store.Query, fn func(*model.WipsEvent) error) error {
+	for i := range m.Events {
+		e := &m.Events[i]
+		if q.Reverse {
+			e = &m.Events[len(m.Events)-1-i]
+		}
+		if !q.Matches(e) {
+			continue
+		}
+		if err := fn(e); err != nil {
+			if err == store.ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+func (m *MockStore) GetEvent(id string) (*model.WipsEvent, error) {
+	for i := range m.Events {
+		if strings.HasPrefix(m.Events[i].ID, id) {
+			return &m.Events[i], nil
+		}
+	}
+	return nil, store.ErrEventNotFound
+}
+func (m *MockStore) UpdateEvent(id string, mutator func(*model.WipsEvent) error) error {
+	for i := range m.Events {
+		if m.Events[i].ID == id {
+			return mutator(&m.Events[i])
+		}
+	}
+	return store.ErrEventNotFound
+}
+func (m *MockStore) DeleteEvent(id string) error { return nil }
+func (m *MockStore) GetRootDir() string          { return "" }
 
 func TestSummaryUsecase_GetSummary(t *testing.T) {
 	now := time.Now()
@@ -211,3 +257,112 @@ func TestSummaryUsecase_GetSummary(t *testing.T) {
 		}
 	})
 }
+
+func TestGetSummary_Churn(t *testing.T) {
+	day, _ := time.ParseInLocation("2006-01-02", "2024-01-15", time.Local)
+	repoID := "repo1"
+
+	withCommit := func(id string, ins, del int) model.WipsEvent {
+		e := model.WipsEvent{ID: id, TS: day.Add(time.Hour), Type: model.EventTypeGitCommit, Ctx: model.Context{RepoID: &repoID}}
+		c := &model.Commit{SHA: id, Files: []model.FileChange{{Path: "a.go", Insertions: ins, Deletions: del}}}
+		if err := e.SetMeta(model.MetaCommit, c); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	events := []model.WipsEvent{
+		withCommit("c1", 10, 2),
+		withCommit("c2", 5, 0),
+		// Recorded by an earlier version, without line counts
+		{ID: "c3", TS: day.Add(2 * time.Hour), Type: model.EventTypeGitCommit, Content: "abc1234 Old", Ctx: model.Context{RepoID: &repoID}},
+		{ID: "n1", TS: day.Add(3 * time.Hour), Type: model.EventTypeNote, Ctx: model.Context{RepoID: &repoID}},
+	}
+
+	ms := &MockStore{Events: events, ReposDict: map[string]interface{}{"repo1": map[string]interface{}{"name": "my-repo"}}}
+	res, err := NewSummaryUsecase(ms).GetSummary(SummaryOptions{Date: "2024-01-15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := res.DayGroups[0].DirMap["@my-repo"]
+	if g == nil {
+		t.Fatal("Expected @my-repo group")
+	}
+	if g.Commits != 3 || g.Insertions != 15 || g.Deletions != 2 {
+		t.Errorf("churn = %d commits +%d -%d, want 3 commits +15 -2", g.Commits, g.Insertions, g.Deletions)
+	}
+}
+
+func TestGetSummary_GroupByTag(t *testing.T) {
+	day, _ := time.ParseInLocation("2006-01-02", "2024-01-15", time.Local)
+	events := []model.WipsEvent{
+		{ID: "n1", TS: day.Add(time.Hour), Type: model.EventTypeNote, Content: "fix #bug in #parser", Tags: []string{"bug", "parser"}},
+		{ID: "n2", TS: day.Add(2 * time.Hour), Type: model.EventTypeNote, Content: "lunch"},
+		// Recorded before tags were stored
+		{ID: "n3", TS: day.Add(3 * time.Hour), Type: model.EventTypeNote, Content: "another #bug"},
+	}
+
+	res, err := NewSummaryUsecase(&MockStore{Events: events}).GetSummary(SummaryOptions{Date: "2024-01-15", GroupBy: GroupByTag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dg := res.DayGroups[0]
+	if want := []string{"#bug", "#parser", Untagged}; !reflect.DeepEqual(dg.DirOrder, want) {
+		t.Fatalf("groups = %q, want %q", dg.DirOrder, want)
+	}
+	for name, want := range map[string]int{"#bug": 2, "#parser": 1, Untagged: 1} {
+		if got := len(dg.DirMap[name].Events); got != want {
+			t.Errorf("%s has %d events, want %d", name, got, want)
+		}
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	for _, s := range []string{"", "dir", "tag"} {
+		if _, err := ParseGroupBy(s); err != nil {
+			t.Errorf("ParseGroupBy(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseGroupBy("repo"); err == nil {
+		t.Error("ParseGroupBy(\"repo\") expected error")
+	}
+}
+
+func TestGetSummary_Tasks(t *testing.T) {
+	day, _ := time.ParseInLocation("2006-01-02", "2024-01-15", time.Local)
+	task := func(name string, opened time.Time, done time.Time) model.WipsEvent {
+		e := model.WipsEvent{ID: id.GenerateULIDAt(opened), TS: opened, Type: model.EventTypeTask, Content: name}
+		if !done.IsZero() {
+			if err := e.SetDone(&model.Done{At: done}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return e
+	}
+	events := []model.WipsEvent{
+		task("t1", day.Add(-48*time.Hour), day.Add(time.Hour)), // Opened earlier, done on the day
+		task("t2", day.Add(2*time.Hour), day.Add(3*time.Hour)),
+		task("t3", day.Add(4*time.Hour), time.Time{}),
+		task("t4", day.Add(5*time.Hour), day.Add(30*time.Hour)), // Done the next day
+	}
+	ms := &MockStore{Events: events}
+
+	res, err := NewSummaryUsecase(ms).GetSummary(SummaryOptions{Date: "2024-01-15"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.DayGroups) != 1 {
+		t.Fatalf("DayGroups = %d, want 1", len(res.DayGroups))
+	}
+	if dg := res.DayGroups[0]; dg.TasksOpened != 3 || dg.TasksDone != 2 {
+		t.Errorf("tasks = %d opened, %d done, want 3 opened, 2 done", dg.TasksOpened, dg.TasksDone)
+	}
+
+	// A day with completions only
+	res, err = NewSummaryUsecase(ms).GetSummary(SummaryOptions{Date: "2024-01-16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.DayGroups) != 1 || res.DayGroups[0].TasksDone != 1 || len(res.DayGroups[0].DirOrder) != 0 {
+		t.Errorf("DayGroups = %+v, want one day with a task done", res.DayGroups)
+	}
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// TagUsecase defines the business logic for event tags.
+type TagUsecase interface {
+	// Add adds tags to an event. Tags it already has are ignored.
+	Add(id string, tags ...string) error
+
+	// Remove removes tags from an event. Tags written in the content cannot be
+	// removed; they go away when the content is edited.
+	Remove(id string, tags ...string) error
+
+	// Counts returns the tags of the events shown in the period, with the number
+	// of events having each, most used first.
+	Counts(q store.Query) ([]TagCount, error)
+}
+
+// TagCount is a tag and the number of events having it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+type tagUsecase struct {
+	store store.Store
+}
+
+// NewTagUsecase creates a new TagUsecase instance.
+func NewTagUsecase(s store.Store) TagUsecase {
+	return &tagUsecase{store: s}
+}
+
+func (u *tagUsecase) Add(eventID string, tags ...string) error {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	return u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		current := append([]string(nil), e.TagList()...)
+		for _, t := range normalized {
+			if !e.HasTag(t) {
+				current = append(current, t)
+			}
+		}
+		e.SetTags(current)
+		return nil
+	})
+}
+
+func (u *tagUsecase) Remove(eventID string, tags ...string) error {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	return u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		written := model.ParseTags(e.Content)
+		for _, t := range normalized {
+			if !e.HasTag(t) {
+				return fmt.Errorf("event %s is not tagged #%s", eventID, t)
+			}
+			for _, w := range written {
+				if w == t {
+					return fmt.Errorf("#%s is written in the content of event %s (run 'wip edit %s' to remove it)", t, eventID, eventID)
+				}
+			}
+		}
+
+		var kept []string
+		for _, t := range e.TagList() {
+			removed := false
+			for _, r := range normalized {
+				removed = removed || r == t
+			}
+			if !removed {
+				kept = append(kept, t)
+			}
+		}
+		e.SetTags(kept)
+		return nil
+	})
+}
+
+func (u *tagUsecase) Counts(q store.Query) ([]TagCount, error) {
+	j, err := journal.Load(u.store)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	err = u.store.IterateEvents(q, func(raw *model.WipsEvent) error {
+		e, visible := j.ResolveEvent(*raw)
+		if !visible {
+			return nil
+		}
+		for _, t := range e.TagList() {
+			counts[t]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		result = append(result, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result, nil
+}
+
+// normalizeTags validates tags given on the command line and returns them as stored.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		n, ok := model.NormalizeTag(t)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag %q: tags start with a letter and contain letters, digits, '-', '_' or '/'", t)
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
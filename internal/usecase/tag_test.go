@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func TestTagUsecase(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, err := store.NewStore(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewTagUsecase(s)
+
+	tagged := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "fix #bug", Tags: []string{"bug"}}
+	// Recorded before tags were stored
+	legacy := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "another #bug"}
+	for _, e := range []*model.WipsEvent{tagged, legacy} {
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tagsOf := func(eventID string) []string {
+		e, err := s.GetEvent(eventID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.TagList()
+	}
+
+	if err := uc.Add(tagged.ID, "#Urgent", "bug"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if got, want := tagsOf(tagged.ID), []string{"bug", "urgent"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags after Add() = %q, want %q", got, want)
+	}
+	if err := uc.Add(legacy.ID, "review"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if got, want := tagsOf(legacy.ID), []string{"bug", "review"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags of legacy event after Add() = %q, want %q", got, want)
+	}
+	if err := uc.Add(tagged.ID, "not a tag"); err == nil {
+		t.Error("Add() of an invalid tag expected error")
+	}
+
+	if err := uc.Remove(tagged.ID, "bug"); err == nil {
+		t.Error("Remove() of a tag written in the content expected error")
+	}
+	if err := uc.Remove(tagged.ID, "missing"); err == nil {
+		t.Error("Remove() of a missing tag expected error")
+	}
+	if err := uc.Remove(tagged.ID, "urgent"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got, want := tagsOf(tagged.ID), []string{"bug"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tags after Remove() = %q, want %q", got, want)
+	}
+
+	counts, err := uc.Counts(store.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []TagCount{{"bug", 2}, {"review", 1}}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("Counts() = %v, want %v", counts, want)
+	}
+}
@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/filter"
+	"github.com/rynskrmt/wips-cli/internal/git"
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// TaskUsecase defines the business logic for tasks: events that stay open until done.
+type TaskUsecase interface {
+	// RecordTask creates and saves a new open task, with the same context as a note.
+	// Returns nil if the directory is ignored by the config.
+	RecordTask(message string, wd string) (*model.WipsEvent, error)
+
+	// Done marks a task as completed.
+	Done(id string) error
+
+	// Reopen marks a completed task as open again.
+	Reopen(id string) error
+
+	// Open returns the open tasks, oldest first.
+	Open(opts TaskListOptions) ([]model.WipsEvent, error)
+}
+
+// TaskListOptions scope the tasks listed by Open.
+type TaskListOptions struct {
+	// Wd is the current directory. Inside a git repository the tasks of the
+	// repository are listed; elsewhere those recorded in Wd or below, like tail.
+	Wd            string
+	Global        bool     // List the tasks of every directory
+	IncludeHidden bool     // Include tasks of hidden directories
+	HiddenDirs    []string // Hidden directory patterns from config
+}
+
+type taskUsecase struct {
+	store store.Store
+}
+
+// NewTaskUsecase creates a new TaskUsecase instance.
+func NewTaskUsecase(s store.Store) TaskUsecase {
+	return &taskUsecase{store: s}
+}
+
+func (u *taskUsecase) RecordTask(message string, wd string) (*model.WipsEvent, error) {
+	if ignoredByConfig(wd) {
+		return nil, nil
+	}
+
+	refs, err := ResolveRefs(u.store, message)
+	if err != nil {
+		return nil, err
+	}
+	event := &model.WipsEvent{
+		ID:      id.GenerateULID(),
+		TS:      time.Now(),
+		Type:    model.EventTypeTask,
+		Content: message,
+		Tags:    model.ParseTags(message),
+		Refs:    refs,
+		Ctx:     gatherContext(u.store, wd),
+	}
+	if err := u.store.AppendEvent(event); err != nil {
+		return nil, fmt.Errorf("failed to save event: %w", err)
+	}
+	if err := recordTaskChange(u.store, event.ID, taskChange{Task: event.ID}); err != nil {
+		return nil, err
+	}
+	if err := RecordLinks(u.store, event.ID, "", refs); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (u *taskUsecase) Done(eventID string) error {
+	at := time.Now()
+	err := u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		if e.Type != model.EventTypeTask {
+			return fmt.Errorf("event %s is not a task", eventID)
+		}
+		if e.Done() != nil {
+			return fmt.Errorf("task %s is already done", eventID)
+		}
+		return e.SetDone(&model.Done{At: at})
+	})
+	if err != nil {
+		return err
+	}
+	return recordTaskChange(u.store, id.GenerateULIDAt(at), taskChange{Task: eventID, Done: &at})
+}
+
+func (u *taskUsecase) Reopen(eventID string) error {
+	at := time.Now()
+	err := u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		if e.Type != model.EventTypeTask {
+			return fmt.Errorf("event %s is not a task", eventID)
+		}
+		if e.Done() == nil {
+			return fmt.Errorf("task %s is not done", eventID)
+		}
+		return e.SetDone(nil)
+	})
+	if err != nil {
+		return err
+	}
+	return recordTaskChange(u.store, id.GenerateULIDAt(at), taskChange{Task: eventID})
+}
+
+// Open implementation.
+// Tasks are matched by repository when Wd is in one, so tasks recorded anywhere
+// in the repository show up from any of its directories. Only the events since
+// the oldest task open in the tasks dictionary and still shown are read.
+func (u *taskUsecase) Open(opts TaskListOptions) ([]model.WipsEvent, error) {
+	states, err := loadTaskStates(u.store)
+	if err != nil {
+		return nil, err
+	}
+	var open []string
+	for task, done := range states {
+		if done == nil {
+			open = append(open, task)
+		}
+	}
+	if len(open) == 0 {
+		return nil, nil
+	}
+
+	j, err := journal.Load(u.store)
+	if err != nil {
+		return nil, err
+	}
+	if open, err = shownTasks(u.store, j, open); err != nil || len(open) == 0 {
+		return nil, err
+	}
+	dirsDict, err := u.store.LoadDict("dirs")
+	if err != nil {
+		dirsDict = make(map[string]interface{})
+	}
+
+	repoID := ""
+	if !opts.Global {
+		if info, err := git.GetInfo(opts.Wd); err == nil && info.Root != "" {
+			repoID = repoIDOf(info)
+		}
+	}
+
+	var tasks []model.WipsEvent
+	q := store.Query{Start: tasksSince(open), Types: []model.EventType{model.EventTypeTask}}
+	err = u.store.IterateEvents(q, func(raw *model.WipsEvent) error {
+		e, visible := j.ResolveEvent(*raw)
+		if !visible || !e.IsOpenTask() {
+			return nil
+		}
+
+		var dirPath string
+		if e.Ctx.CwdID != nil {
+			dirPath, _ = dirsDict[*e.Ctx.CwdID].(string)
+		}
+		if !opts.IncludeHidden && filter.IsHiddenDir(dirPath, opts.HiddenDirs) {
+			return nil
+		}
+
+		if !opts.Global {
+			if repoID != "" {
+				if e.Ctx.RepoID == nil || *e.Ctx.RepoID != repoID {
+					return nil
+				}
+			} else if dirPath == "" || !strings.HasPrefix(dirPath, opts.Wd) {
+				return nil
+			}
+		}
+
+		tasks = append(tasks, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// tasksDict is the dictionary of tasks recorded and marked done or reopened,
+// keyed by the task ID for the task itself and by a ULID taken at the time
+// for the changes after it, so that keys sort in the order things happened.
+const tasksDict = "tasks"
+
+// taskChange is an entry of the tasks dictionary: the task is open if Done is nil.
+type taskChange struct {
+	Task string     `json:"task"`
+	Done *time.Time `json:"done,omitempty"`
+}
+
+// recordTaskChange saves a change to the tasks dictionary.
+func recordTaskChange(s store.Store, key string, c taskChange) error {
+	// Tasks recorded before the dictionary existed are added first
+	if _, err := loadTaskStates(s); err != nil {
+		return err
+	}
+	if err := s.SaveDict(tasksDict, key, c); err != nil {
+		return fmt.Errorf("failed to save task %s: %w", c.Task, err)
+	}
+	return nil
+}
+
+// loadTaskStates returns the time every task was done at, nil for open tasks.
+// The dictionary is filled from the task events the first time, so that tasks
+// recorded by earlier versions are kept track of.
+func loadTaskStates(s store.Store) (map[string]*time.Time, error) {
+	dict, err := s.LoadDict(tasksDict)
+	if err != nil {
+		return nil, err
+	}
+	if dict == nil {
+		dict = make(map[string]interface{})
+	}
+	err = store.FillDict(s, tasksDict, func() error { return fillTasksDict(s, dict) })
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(dict))
+	for key := range dict {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	states := make(map[string]*time.Time)
+	for _, key := range keys {
+		data, err := json.Marshal(dict[key])
+		if err != nil {
+			return nil, err
+		}
+		var c taskChange
+		if err := json.Unmarshal(data, &c); err != nil || c.Task == "" {
+			continue
+		}
+		states[c.Task] = c.Done
+	}
+	return states, nil
+}
+
+// fillTasksDict records every task event and when it was done in the tasks
+// dictionary, and adds them to its content dict.
+func fillTasksDict(s store.Store, dict map[string]interface{}) error {
+	filled := make(map[string]interface{})
+	q := store.Query{Types: []model.EventType{model.EventTypeTask}, IncludeTrashed: true}
+	err := s.IterateEvents(q, func(e *model.WipsEvent) error {
+		changes := map[string]taskChange{e.ID: {Task: e.ID}}
+		if d := e.Done(); d != nil {
+			at := d.At
+			changes[id.GenerateULIDAt(at)] = taskChange{Task: e.ID, Done: &at}
+		}
+		for key, c := range changes {
+			filled[key] = c
+			dict[key] = c
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get tasks: %w", err)
+	}
+	if err := store.SaveDictEntries(s, tasksDict, filled); err != nil {
+		return fmt.Errorf("failed to save tasks: %w", err)
+	}
+	return nil
+}
+
+// shownTasks returns the open tasks that may still be shown, oldest first.
+// Undone tasks are left out, and so are the oldest ones while they are in the
+// trash or purged, since the tasks dictionary keeps them open and they would
+// otherwise keep every later event read.
+func shownTasks(s store.Store, j *journal.Journal, open []string) ([]string, error) {
+	var shown []string
+	for _, task := range open {
+		if !j.IsUndone(journal.Ref{Action: journal.ActionNote, Target: task}) {
+			shown = append(shown, task)
+		}
+	}
+	sort.Strings(shown)
+	for len(shown) > 0 {
+		e, err := s.GetEvent(shown[0])
+		if err != nil && !errors.Is(err, store.ErrEventNotFound) {
+			return nil, err
+		}
+		if err == nil && e.Trashed() == nil {
+			break
+		}
+		shown = shown[1:]
+	}
+	return shown, nil
+}
+
+// tasksSince returns the time to read task events from to find the given tasks.
+func tasksSince(tasks []string) time.Time {
+	var since time.Time
+	for _, task := range tasks {
+		ts, err := id.Time(task)
+		if err != nil {
+			return time.Time{}
+		}
+		if since.IsZero() || ts.Before(since) {
+			since = ts
+		}
+	}
+	// An event is filed by its timestamp, which can differ slightly from its ID's
+	return since.Add(-24 * time.Hour)
+}
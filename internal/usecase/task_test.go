@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func TestTaskUsecase(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	app := filepath.Join(tmp, "app")
+	plain := filepath.Join(tmp, "plain")
+	for _, dir := range []string{filepath.Join(app, "sub"), filepath.Join(plain, "child")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := exec.Command("git", "init", "-q", app).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	s, err := store.NewStore(filepath.Join(tmp, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewTaskUsecase(s)
+
+	record := func(message, wd string) *model.WipsEvent {
+		e, err := uc.RecordTask(message, wd)
+		if err != nil || e == nil {
+			t.Fatalf("RecordTask() = %v, %v", e, err)
+		}
+		return e
+	}
+	inSub := record("in sub #backend", filepath.Join(app, "sub"))
+	record("in app", app)
+	record("in plain", plain)
+	record("in child", filepath.Join(plain, "child"))
+
+	if !inSub.IsOpenTask() || !inSub.HasTag("backend") {
+		t.Errorf("recorded task = %+v, want an open task tagged #backend", inSub)
+	}
+
+	countOpen := func(opts TaskListOptions) int {
+		tasks, err := uc.Open(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(tasks)
+	}
+	tests := []struct {
+		name string
+		opts TaskListOptions
+		want int
+	}{
+		{"Repository root", TaskListOptions{Wd: app}, 2},
+		{"Anywhere in the repository", TaskListOptions{Wd: filepath.Join(app, "sub")}, 2},
+		{"Outside repositories", TaskListOptions{Wd: plain}, 2},
+		{"Subdirectory", TaskListOptions{Wd: filepath.Join(plain, "child")}, 1},
+		{"Global", TaskListOptions{Wd: plain, Global: true}, 4},
+		{"Hidden", TaskListOptions{Wd: plain, HiddenDirs: []string{filepath.Join(plain, "child")}}, 1},
+	}
+	for _, tt := range tests {
+		if got := countOpen(tt.opts); got != tt.want {
+			t.Errorf("%s: Open() returned %d tasks, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if err := uc.Done(inSub.ID); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	if err := uc.Done(inSub.ID); err == nil {
+		t.Error("Done() of a done task expected error")
+	}
+	if got := countOpen(TaskListOptions{Wd: app}); got != 1 {
+		t.Errorf("open tasks after Done() = %d, want 1", got)
+	}
+	done, err := s.GetEvent(inSub.ID)
+	if err != nil || done.Done() == nil {
+		t.Fatalf("task after Done() = %+v, %v, want done", done, err)
+	}
+
+	if err := uc.Reopen(inSub.ID); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	if got := countOpen(TaskListOptions{Wd: app}); got != 2 {
+		t.Errorf("open tasks after Reopen() = %d, want 2", got)
+	}
+
+	note := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "note"}
+	if err := s.AppendEvent(note); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.Done(note.ID); err == nil {
+		t.Error("Done() of a note expected error")
+	}
+}
+
+func TestTaskUsecase_EarlierTasks(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_task_dict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewTaskUsecase(s)
+
+	// A task recorded before the tasks dictionary existed
+	ts := time.Now().AddDate(-1, 0, 0)
+	old := &model.WipsEvent{ID: id.GenerateULIDAt(ts), TS: ts, Type: model.EventTypeTask, Content: "old"}
+	if err := s.AppendEvent(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.RecordTask("new", tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := uc.Open(TaskListOptions{Wd: tmp, Global: true})
+	if err != nil || len(tasks) != 2 || tasks[0].ID != old.ID {
+		t.Fatalf("Open() = %v, %v, want the old and the new task", tasks, err)
+	}
+	if err := uc.Done(old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, err := uc.Open(TaskListOptions{Wd: tmp, Global: true}); err != nil || len(tasks) != 1 || tasks[0].Content != "new" {
+		t.Errorf("Open() after Done() = %v, %v, want the new task", tasks, err)
+	}
+}
+
+func TestShownTasks(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_task_shown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	task := func(days int) string {
+		ts := time.Now().AddDate(0, 0, -days)
+		e := &model.WipsEvent{ID: id.GenerateULIDAt(ts), TS: ts, Type: model.EventTypeTask, Content: "task"}
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+		return e.ID
+	}
+	purged := id.GenerateULIDAt(time.Now().AddDate(0, 0, -50))
+	undone := task(40)
+	trashed := task(30)
+	shown := task(20)
+	trashedLater := task(10)
+
+	undo := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeUndo}
+	ref := journal.Ref{Action: journal.ActionNote, Target: undone}
+	if err := undo.SetMeta(journal.MetaUndo, ref); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendEvent(undo); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []string{trashed, trashedLater} {
+		if err := NewTrashUsecase(s).Trash(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j, err := journal.Load(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := shownTasks(s, j, []string{trashedLater, shown, trashed, undone, purged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{shown, trashedLater}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shownTasks() = %v, want %v", got, want)
+	}
+}
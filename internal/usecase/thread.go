@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// ResolveRefs returns the IDs of the events referenced in content as [[id-prefix]].
+// Prefixes matching no event are left as text; ambiguous ones are an error.
+func ResolveRefs(s store.Store, content string) ([]string, error) {
+	var ids []string
+	for _, prefix := range model.ParseRefs(content) {
+		e, err := s.GetEvent(prefix)
+		if errors.Is(err, store.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve [[%s]]: %w", prefix, err)
+		}
+		ids = append(ids, e.ID)
+	}
+	return ids, nil
+}
+
+// linksDict is the dictionary of replies and references between events, keyed
+// by the target, source and kind of link, so that the events linking to an
+// event are found without reading all of them.
+const linksDict = "links"
+
+// Kinds of links in the links dictionary.
+const (
+	linkReply = "reply"
+	linkRef   = "ref"
+)
+
+// link is an entry of the links dictionary: Source replies to or references Target.
+type link struct {
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+	Source string `json:"source"`
+}
+
+// RecordLinks saves the reply of the event source to parentID, unless it is
+// empty, and its references to refs in the links dictionary.
+func RecordLinks(s store.Store, source string, parentID string, refs []string) error {
+	if parentID == "" && len(refs) == 0 {
+		return nil
+	}
+	// Links recorded before the dictionary existed are added first
+	if _, err := loadLinks(s); err != nil {
+		return err
+	}
+	if err := store.SaveDictEntries(s, linksDict, linksOf(source, parentID, refs)); err != nil {
+		return fmt.Errorf("failed to save links of %s: %w", source, err)
+	}
+	return nil
+}
+
+// linksOf returns the entries of the links dictionary for the reply of source
+// to parentID, unless it is empty, and its references to refs.
+func linksOf(source string, parentID string, refs []string) map[string]interface{} {
+	links := make(map[string]interface{})
+	if parentID != "" {
+		links[parentID+"/"+source+"/"+linkReply] = link{Kind: linkReply, Target: parentID, Source: source}
+	}
+	for _, ref := range refs {
+		links[ref+"/"+source+"/"+linkRef] = link{Kind: linkRef, Target: ref, Source: source}
+	}
+	return links
+}
+
+// loadLinks returns the events linking to each event, by kind of link.
+// Links may have been removed since by an edit, so the events still have to
+// be checked. The dictionary is filled from the events the first time.
+func loadLinks(s store.Store) (map[string]map[string][]string, error) {
+	dict, err := s.LoadDict(linksDict)
+	if err != nil {
+		return nil, err
+	}
+	if dict == nil {
+		dict = make(map[string]interface{})
+	}
+	err = store.FillDict(s, linksDict, func() error {
+		filled := make(map[string]interface{})
+		q := store.Query{IncludeTrashed: true}
+		err := s.IterateEvents(q, func(e *model.WipsEvent) error {
+			for key, l := range linksOf(e.ID, e.ParentID, e.Refs) {
+				filled[key] = l
+				dict[key] = l
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get events: %w", err)
+		}
+		if err := store.SaveDictEntries(s, linksDict, filled); err != nil {
+			return fmt.Errorf("failed to save links: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	links := map[string]map[string][]string{linkReply: {}, linkRef: {}}
+	for _, value := range dict {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var l link
+		if err := json.Unmarshal(data, &l); err != nil || links[l.Kind] == nil {
+			continue
+		}
+		links[l.Kind][l.Target] = append(links[l.Kind][l.Target], l.Source)
+	}
+	// ULIDs sort by time
+	for _, byTarget := range links {
+		for _, sources := range byTarget {
+			sort.Strings(sources)
+		}
+	}
+	return links, nil
+}
+
+// ThreadUsecase defines the business logic for reading replies and references.
+type ThreadUsecase interface {
+	// Thread returns the thread of the event with the given ID: the event it
+	// ultimately replies to and all the replies below it, with the events the
+	// given one references and those referencing it.
+	Thread(id string) (*Thread, error)
+}
+
+// Thread is an event with its replies, and the references from and to it.
+type Thread struct {
+	Root      *ThreadNode       // The first event of the thread
+	Links     []model.WipsEvent // Events referenced by the event
+	Backlinks []model.WipsEvent // Events referencing the event
+}
+
+// ThreadNode is an event of a thread and its replies, oldest first.
+type ThreadNode struct {
+	Event   model.WipsEvent
+	Replies []*ThreadNode
+}
+
+// Node returns the node of the event with the given ID, or nil if it is not in the thread.
+func (t *Thread) Node(id string) *ThreadNode {
+	var find func(n *ThreadNode) *ThreadNode
+	find = func(n *ThreadNode) *ThreadNode {
+		if n.Event.ID == id {
+			return n
+		}
+		for _, reply := range n.Replies {
+			if found := find(reply); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(t.Root)
+}
+
+type threadUsecase struct {
+	store store.Store
+}
+
+// NewThreadUsecase creates a new ThreadUsecase instance.
+func NewThreadUsecase(s store.Store) ThreadUsecase {
+	return &threadUsecase{store: s}
+}
+
+// Thread implementation.
+// Replies and references are found in the links dictionary, then only the
+// events of the thread are read. Undone and trashed events are left out.
+func (u *threadUsecase) Thread(id string) (*Thread, error) {
+	j, err := journal.Load(u.store)
+	if err != nil {
+		return nil, err
+	}
+	links, err := loadLinks(u.store)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.WipsEvent)
+	get := func(id string) (model.WipsEvent, bool) {
+		if e, ok := byID[id]; ok {
+			if e == nil {
+				return model.WipsEvent{}, false
+			}
+			return *e, true
+		}
+		byID[id] = nil
+		raw, err := u.store.GetEvent(id)
+		if err != nil || raw.ID != id || raw.Trashed() != nil {
+			return model.WipsEvent{}, false
+		}
+		e, visible := j.ResolveEvent(*raw)
+		if !visible {
+			return model.WipsEvent{}, false
+		}
+		byID[id] = &e
+		return e, true
+	}
+
+	e, ok := get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrEventNotFound, id)
+	}
+	t := &Thread{}
+	for _, ref := range e.RefList() {
+		if linked, ok := get(ref); ok {
+			t.Links = append(t.Links, linked)
+		}
+	}
+	for _, source := range links[linkRef][id] {
+		if linked, ok := get(source); ok && containsName(linked.RefList(), id) {
+			t.Backlinks = append(t.Backlinks, linked)
+		}
+	}
+
+	// Walk up to the first event still shown. The events on the way are
+	// replies even if the links dictionary lacks them, so that the event is
+	// always in its thread.
+	root := e
+	up := map[string]bool{root.ID: true}
+	chain := make(map[string]string) // Parent ID -> reply on the way to the event
+	for root.ParentID != "" {
+		parent, ok := get(root.ParentID)
+		if !ok || up[parent.ID] {
+			break
+		}
+		chain[parent.ID] = root.ID
+		root = parent
+		up[root.ID] = true
+	}
+
+	seen := map[string]bool{root.ID: true}
+	var build func(e model.WipsEvent) *ThreadNode
+	build = func(e model.WipsEvent) *ThreadNode {
+		node := &ThreadNode{Event: e}
+		sources := links[linkReply][e.ID]
+		if reply, ok := chain[e.ID]; ok && !containsName(sources, reply) {
+			sources = append(append([]string{}, sources...), reply)
+			sort.Strings(sources)
+		}
+		for _, source := range sources {
+			if reply, ok := get(source); ok && reply.ParentID == e.ID && !seen[reply.ID] {
+				seen[reply.ID] = true
+				node.Replies = append(node.Replies, build(reply))
+			}
+		}
+		return node
+	}
+	t.Root = build(root)
+	return t, nil
+}
+
+// threadOrder orders events so that replies follow the event they reply to,
+// and returns the reply depth of each: 0, or 1 for a reply to an event in
+// events, 2 for a reply to that reply and so on. events are oldest first.
+func threadOrder(events []model.WipsEvent) ([]model.WipsEvent, []int) {
+	index := make(map[string]int, len(events))
+	for i, e := range events {
+		index[e.ID] = i
+	}
+	replies := make(map[int][]int)
+	var roots []int
+	for i, e := range events {
+		if parent, ok := index[e.ParentID]; ok && parent < i {
+			replies[parent] = append(replies[parent], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	ordered := make([]model.WipsEvent, 0, len(events))
+	depths := make([]int, 0, len(events))
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		ordered = append(ordered, events[i])
+		depths = append(depths, depth)
+		for _, r := range replies[i] {
+			walk(r, depth+1)
+		}
+	}
+	for _, i := range roots {
+		walk(i, 0)
+	}
+	return ordered, depths
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func TestThreadUsecase(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_thread")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	notes := NewNoteUsecase(s)
+	wd := t.TempDir()
+
+	question, err := notes.RecordNote("why is login slow?", wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, err := notes.Reply(question.ID[:20], "turned out to be the cache", wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.ParentID != question.ID {
+		t.Errorf("reply ParentID = %q, want %q", answer.ParentID, question.ID)
+	}
+	fix, err := notes.Reply(answer.ID, "fixed", wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mention, err := notes.RecordNote("same as [["+question.ID[:20]+"]], see [[01ZZZZZZ]]", wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{question.ID}; !reflect.DeepEqual(mention.Refs, want) {
+		t.Errorf("Refs = %q, want %q", mention.Refs, want)
+	}
+	if _, err := notes.RecordNote("ambiguous [["+question.ID[:6]+"]]", wd); err == nil {
+		t.Error("RecordNote() with an ambiguous reference expected error")
+	}
+
+	uc := NewThreadUsecase(s)
+	thread, err := uc.Thread(answer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Root.Event.ID != question.ID {
+		t.Errorf("Root = %s, want the question", thread.Root.Event.Content)
+	}
+	if len(thread.Root.Replies) != 1 || len(thread.Root.Replies[0].Replies) != 1 || thread.Root.Replies[0].Replies[0].Event.ID != fix.ID {
+		t.Errorf("Root.Replies = %+v, want answer then fix", thread.Root.Replies)
+	}
+	if len(thread.Backlinks) != 0 {
+		t.Errorf("Backlinks of the answer = %+v, want none", thread.Backlinks)
+	}
+
+	thread, err = uc.Thread(question.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Backlinks) != 1 || thread.Backlinks[0].ID != mention.ID {
+		t.Errorf("Backlinks of the question = %+v, want the mention", thread.Backlinks)
+	}
+
+	thread, err = uc.Thread(mention.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Links) != 1 || thread.Links[0].ID != question.ID {
+		t.Errorf("Links of the mention = %+v, want the question", thread.Links)
+	}
+}
+
+func TestThreadUsecase_EarlierLinks(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_thread_links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Events recorded before the links dictionary existed
+	question := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "question"}
+	answer := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "answer", ParentID: question.ID}
+	mention := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "see [[" + question.ID + "]]", Refs: []string{question.ID}}
+	for _, e := range []*model.WipsEvent{question, answer, mention} {
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	thread, err := NewThreadUsecase(s).Thread(question.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thread.Root.Replies) != 1 || thread.Root.Replies[0].Event.ID != answer.ID {
+		t.Errorf("Root.Replies = %+v, want the answer", thread.Root.Replies)
+	}
+	if len(thread.Backlinks) != 1 || thread.Backlinks[0].ID != mention.ID {
+		t.Errorf("Backlinks = %+v, want the mention", thread.Backlinks)
+	}
+}
+
+func TestThreadUsecase_MissingLink(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "wips_test_thread_missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	s, err := store.NewStore(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewThreadUsecase(s)
+
+	question := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "question"}
+	if err := s.AppendEvent(question); err != nil {
+		t.Fatal(err)
+	}
+	// The links dictionary is filled before the reply is saved without its link
+	if _, err := uc.Thread(question.ID); err != nil {
+		t.Fatal(err)
+	}
+	answer := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "answer", ParentID: question.ID}
+	if err := s.AppendEvent(answer); err != nil {
+		t.Fatal(err)
+	}
+
+	thread, err := uc.Thread(answer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Root.Event.ID != question.ID {
+		t.Errorf("Root = %s, want the question", thread.Root.Event.Content)
+	}
+	if node := thread.Node(answer.ID); node == nil {
+		t.Errorf("the reply is missing from its thread: %+v", thread.Root)
+	}
+}
+
+func TestThreadOrder(t *testing.T) {
+	events := []model.WipsEvent{
+		{ID: "a"},
+		{ID: "b", ParentID: "a"},
+		{ID: "c"},
+		{ID: "d", ParentID: "b"},
+		{ID: "e", ParentID: "elsewhere"},
+		{ID: "f", ParentID: "a"},
+	}
+
+	ordered, depths := threadOrder(events)
+	var ids []string
+	for _, e := range ordered {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"a", "b", "d", "f", "c", "e"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("order = %q, want %q", ids, want)
+	}
+	if want := []int{0, 1, 2, 1, 0, 0}; !reflect.DeepEqual(depths, want) {
+		t.Errorf("depths = %v, want %v", depths, want)
+	}
+}
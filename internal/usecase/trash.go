@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// TrashUsecase defines the business logic for soft deletion.
+// Deleted events are marked as trashed and hidden from every listing
+// until they are restored or purged.
+type TrashUsecase interface {
+	// Trash moves an event to the trash.
+	Trash(id string) error
+
+	// Restore takes an event out of the trash.
+	Restore(id string) error
+
+	// List returns the events in the trash, most recently deleted last.
+	List() ([]model.WipsEvent, error)
+
+	// Purge permanently deletes events moved to the trash before the cutoff.
+	// Returns the number of deleted events.
+	Purge(before time.Time) (int, error)
+}
+
+type trashUsecase struct {
+	store store.Store
+}
+
+// NewTrashUsecase creates a new TrashUsecase instance.
+func NewTrashUsecase(s store.Store) TrashUsecase {
+	return &trashUsecase{store: s}
+}
+
+func (u *trashUsecase) Trash(eventID string) error {
+	t := model.Trash{ID: id.GenerateULID(), At: time.Now()}
+	var target string
+	err := u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		if e.Trashed() != nil {
+			return fmt.Errorf("event %s is already in the trash", eventID)
+		}
+		target = e.ID
+		return e.SetTrashed(&t)
+	})
+	if err != nil {
+		return err
+	}
+	// Recorded so that the deletion can be undone
+	return journal.RecordChange(u.store, journal.Ref{Action: journal.ActionDelete, Target: target, Deletion: t.ID}, t.At)
+}
+
+func (u *trashUsecase) Restore(eventID string) error {
+	return u.store.UpdateEvent(eventID, func(e *model.WipsEvent) error {
+		if e.Trashed() == nil {
+			return fmt.Errorf("event %s is not in the trash", eventID)
+		}
+		return e.SetTrashed(nil)
+	})
+}
+
+func (u *trashUsecase) List() ([]model.WipsEvent, error) {
+	events, err := u.store.QueryEvents(store.Query{IncludeTrashed: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var trashed []model.WipsEvent
+	for _, e := range events {
+		if e.Trashed() != nil {
+			trashed = append(trashed, e)
+		}
+	}
+	sort.SliceStable(trashed, func(i, j int) bool {
+		return trashed[i].Trashed().At.Before(trashed[j].Trashed().At)
+	})
+	return trashed, nil
+}
+
+func (u *trashUsecase) Purge(before time.Time) (int, error) {
+	trashed, err := u.List()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, e := range trashed {
+		if !e.Trashed().At.Before(before) {
+			continue
+		}
+		if err := u.store.DeleteEvent(e.ID); err != nil {
+			return purged, fmt.Errorf("failed to delete event %s: %w", e.ID, err)
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+func TestTrashUsecase(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_trash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, err := store.NewStore(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewTrashUsecase(s)
+
+	keep := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "keep"}
+	drop := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "drop"}
+	for _, e := range []*model.WipsEvent{keep, drop} {
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	countVisible := func() int {
+		events, err := s.GetEvents(time.Time{}, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(events)
+	}
+
+	if err := uc.Trash(drop.ID); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+	if err := uc.Trash(drop.ID); err == nil {
+		t.Error("Trash() of a trashed event expected error")
+	}
+	if n := countVisible(); n != 1 {
+		t.Errorf("visible events after trash = %d, want 1", n)
+	}
+
+	trashed, err := uc.List()
+	if err != nil || len(trashed) != 1 || trashed[0].ID != drop.ID {
+		t.Fatalf("List() = %v, %v, want [%s]", trashed, err, drop.ID)
+	}
+
+	if err := uc.Restore(drop.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if err := uc.Restore(keep.ID); err == nil {
+		t.Error("Restore() of an event not in the trash expected error")
+	}
+	if n := countVisible(); n != 2 {
+		t.Errorf("visible events after restore = %d, want 2", n)
+	}
+
+	// Only events trashed before the cutoff are purged
+	if err := uc.Trash(drop.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := uc.Purge(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("Purge(an hour ago) = %d, %v, want 0", n, err)
+	}
+	if n, err := uc.Purge(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("Purge(now) = %d, %v, want 1", n, err)
+	}
+	all, err := s.QueryEvents(store.Query{IncludeTrashed: true})
+	if err != nil || len(all) != 1 || all[0].ID != keep.ID {
+		t.Errorf("events after purge = %v, %v, want only %s", all, err, keep.ID)
+	}
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+var (
+	// ErrNothingToUndo is returned by Undo when no action is left to revert.
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no undo is left to revert.
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// UndoUsecase defines the business logic for undo and redo.
+// Both append an undo event instead of rewriting history.
+type UndoUsecase interface {
+	// Undo reverts the most recent note, edit or delete that is still in effect.
+	// Returns the recorded undo event.
+	Undo() (*model.WipsEvent, error)
+
+	// Redo reverts the most recent undo that is still in effect.
+	// Returns the recorded undo event.
+	Redo() (*model.WipsEvent, error)
+}
+
+type undoUsecase struct {
+	store store.Store
+}
+
+// NewUndoUsecase creates a new UndoUsecase instance.
+func NewUndoUsecase(s store.Store) UndoUsecase {
+	return &undoUsecase{store: s}
+}
+
+// action is an undoable action found in the log.
+type action struct {
+	ref         journal.Ref
+	at          time.Time
+	description string
+}
+
+// Undo implementation.
+// 1. Finds the latest note or task still shown, reading the log backwards.
+// 2. Finds the latest edit or deletion still in effect from the changes dictionary.
+// 3. Picks the most recent of the two.
+// 4. Restores the event from the trash if it is a deletion.
+// 5. Appends an undo event referencing it.
+func (u *undoUsecase) Undo() (*model.WipsEvent, error) {
+	j, err := journal.Load(u.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load undo history: %w", err)
+	}
+
+	var latest *action
+	q := store.Query{Types: []model.EventType{model.EventTypeNote, model.EventTypeTask}, Reverse: true}
+	err = u.store.IterateEvents(q, func(e *model.WipsEvent) error {
+		ref := journal.Ref{Action: journal.ActionNote, Target: e.ID}
+		if j.IsUndone(ref) {
+			return nil
+		}
+		r, _ := j.ResolveEvent(*e)
+		latest = &action{ref: ref, at: e.TS, description: string(e.Type) + ": " + firstLine(r.Content)}
+		return store.ErrStop
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	changes, err := journal.LoadChanges(u.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load changes: %w", err)
+	}
+	for _, c := range changes {
+		if latest != nil && !c.At.After(latest.at) {
+			break
+		}
+		if j.IsUndone(c.Ref) {
+			continue
+		}
+		a, err := u.change(j, c)
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			latest = a
+			break
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrNothingToUndo
+	}
+
+	if latest.ref.Action == journal.ActionDelete {
+		err := u.store.UpdateEvent(latest.ref.Target, func(e *model.WipsEvent) error {
+			return e.SetTrashed(nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore event %s: %w", latest.ref.Target, err)
+		}
+	}
+
+	return u.record(latest.ref, "Undo "+latest.description)
+}
+
+// change returns the action of a recorded edit or deletion, or nil if the event
+// has changed since in a way that leaves nothing to undo: it was purged,
+// restored, deleted again, or the note it belongs to was undone.
+func (u *undoUsecase) change(j *journal.Journal, c journal.Change) (*action, error) {
+	e, err := u.store.GetEvent(c.Ref.Target)
+	if errors.Is(err, store.ErrEventNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event %s: %w", c.Ref.Target, err)
+	}
+
+	// Describe actions with the content as currently shown
+	r, visible := j.ResolveEvent(*e)
+	switch c.Ref.Action {
+	case journal.ActionDelete:
+		if t := e.Trashed(); t == nil || t.ID != c.Ref.Deletion {
+			return nil, nil
+		}
+		return &action{ref: c.Ref, at: c.At, description: "delete: " + firstLine(r.Content)}, nil
+	case journal.ActionEdit:
+		// Edits of a hidden or trashed event are not undone separately
+		if !visible || e.Trashed() != nil || c.Ref.Rev > len(journal.Revisions(e))+1 {
+			return nil, nil
+		}
+		return &action{ref: c.Ref, at: c.At, description: "edit: " + firstLine(r.Content)}, nil
+	}
+	return nil, nil
+}
+
+// Redo implementation.
+// 1. Picks the most recent undo (not redo) that is still in effect.
+// 2. Moves the event back to the trash if it undid a deletion.
+// 3. Appends an undo event referencing the undo.
+func (u *undoUsecase) Redo() (*model.WipsEvent, error) {
+	j, err := journal.Load(u.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load undo history: %w", err)
+	}
+
+	var target *model.WipsEvent
+	var targetRef journal.Ref
+	for _, undoID := range j.Undos() {
+		ref, _ := j.RefOf(undoID)
+		if ref.Action == journal.ActionUndo {
+			continue // Redos are not redone
+		}
+		if j.IsUndone(journal.Ref{Action: journal.ActionUndo, Target: undoID}) {
+			continue
+		}
+		target, err = u.store.GetEvent(undoID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get undo event %s: %w", undoID, err)
+		}
+		targetRef = ref
+		break
+	}
+	if target == nil {
+		return nil, ErrNothingToRedo
+	}
+
+	if targetRef.Action == journal.ActionDelete {
+		err := u.store.UpdateEvent(targetRef.Target, func(e *model.WipsEvent) error {
+			return e.SetTrashed(&model.Trash{ID: targetRef.Deletion, At: time.Now()})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete event %s: %w", targetRef.Target, err)
+		}
+	}
+
+	description := strings.TrimPrefix(target.Content, "Undo ")
+	return u.record(journal.Ref{Action: journal.ActionUndo, Target: target.ID}, "Redo "+description)
+}
+
+// record appends an undo event reverting ref.
+func (u *undoUsecase) record(ref journal.Ref, content string) (*model.WipsEvent, error) {
+	event := &model.WipsEvent{
+		ID:      id.GenerateULID(),
+		TS:      time.Now(),
+		Type:    model.EventTypeUndo,
+		Content: content,
+	}
+	if err := event.SetMeta(journal.MetaUndo, ref); err != nil {
+		return nil, err
+	}
+	if err := u.store.AppendEvent(event); err != nil {
+		return nil, fmt.Errorf("failed to append event: %w", err)
+	}
+	if err := journal.RecordUndo(u.store, event.ID, ref); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rynskrmt/wips-cli/internal/id"
+	"github.com/rynskrmt/wips-cli/internal/journal"
+	"github.com/rynskrmt/wips-cli/internal/model"
+	"github.com/rynskrmt/wips-cli/internal/store"
+)
+
+// visible returns the content of events after applying undo events.
+func visible(t *testing.T, s store.Store) []string {
+	t.Helper()
+	events, err := s.QueryEvents(store.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := journal.Load(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, e := range j.Resolve(events) {
+		contents = append(contents, e.Content)
+	}
+	return contents
+}
+
+func assertVisible(t *testing.T, s store.Store, want ...string) {
+	t.Helper()
+	got := visible(t, s)
+	if len(got) != len(want) {
+		t.Fatalf("visible events = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("visible events = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestUndoUsecase(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_undo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, err := store.NewStore(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	uc := NewUndoUsecase(s)
+
+	if _, err := uc.Undo(); err != ErrNothingToUndo {
+		t.Fatalf("Undo() on empty store error = %v, want ErrNothingToUndo", err)
+	}
+
+	// Record two notes, edit the first and delete the second
+	first := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "first"}
+	second := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeNote, Content: "second"}
+	for _, e := range []*model.WipsEvent{first, second} {
+		if err := s.AppendEvent(e); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := journal.Edit(s, first.ID, "first (edited)", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := NewTrashUsecase(s).Trash(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	assertVisible(t, s, "first (edited)")
+
+	steps := []struct {
+		name string
+		redo bool
+		want []string
+	}{
+		{"undo delete", false, []string{"first (edited)", "second"}},
+		{"undo edit", false, []string{"first", "second"}},
+		{"undo second note", false, []string{"first"}},
+		{"redo note", true, []string{"first", "second"}},
+		{"redo edit", true, []string{"first (edited)", "second"}},
+		{"redo delete", true, []string{"first (edited)"}},
+	}
+	for _, step := range steps {
+		var err error
+		if step.redo {
+			_, err = uc.Redo()
+		} else {
+			_, err = uc.Undo()
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		time.Sleep(2 * time.Millisecond)
+		assertVisible(t, s, step.want...)
+	}
+
+	if _, err := uc.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Redo() with nothing undone error = %v, want ErrNothingToRedo", err)
+	}
+
+	// A redone deletion can be undone again
+	if _, err := uc.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	assertVisible(t, s, "first (edited)", "second")
+}
+
+func TestUndoUsecase_Task(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wips_test_undo_task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s, err := store.NewStore(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	task := &model.WipsEvent{ID: id.GenerateULID(), TS: time.Now(), Type: model.EventTypeTask, Content: "todo"}
+	if err := s.AppendEvent(task); err != nil {
+		t.Fatal(err)
+	}
+
+	undo, err := NewUndoUsecase(s).Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undo.Content != "Undo task: todo" {
+		t.Errorf("undo content = %q, want %q", undo.Content, "Undo task: todo")
+	}
+	assertVisible(t, s)
+}